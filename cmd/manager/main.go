@@ -6,6 +6,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"runtime"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
@@ -16,6 +17,7 @@ import (
 
 	"github.com/web-servers/jws-operator/pkg/apis"
 	"github.com/web-servers/jws-operator/pkg/controller"
+	"github.com/web-servers/jws-operator/pkg/webhook"
 
 	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
 	kubemetrics "github.com/operator-framework/operator-sdk/pkg/kube-metrics"
@@ -35,6 +37,9 @@ var (
 	metricsHost               = "0.0.0.0"
 	metricsPort         int32 = 8383
 	operatorMetricsPort int32 = 8686
+	webhookPort               = 9443
+	// webhookCertDir is the directory in which the Secret of the certificate of the webhooks is mounted
+	webhookCertDir = filepath.Join(os.TempDir(), "k8s-webhook-server", "serving-certs")
 )
 var log = logf.Log.WithName("cmd")
 
@@ -93,6 +98,8 @@ func main() {
 	mgr, err := manager.New(cfg, manager.Options{
 		Namespace:          namespace,
 		MetricsBindAddress: fmt.Sprintf("%s:%d", metricsHost, metricsPort),
+		Port:               webhookPort,
+		CertDir:            webhookCertDir,
 	})
 	if err != nil {
 		log.Error(err, "")
@@ -113,6 +120,17 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Setup all Webhooks, they need a certificate and are disabled when running the operator locally
+	if os.Getenv("ENABLE_WEBHOOKS") == "false" {
+		log.Info("Webhooks are disabled, the WebServers are not validated at admission time.")
+	} else if err := webhookCertificateExists(); err != nil {
+		// The webhook server would fail to start without a certificate, on Kubernetes the Secret isn't created
+		log.Info("Webhooks are disabled, the WebServers are not validated at admission time: " + err.Error())
+	} else if err := webhook.AddToManager(mgr); err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
+
 	addMetrics(ctx, cfg)
 
 	log.Info("Starting the Cmd.")
@@ -124,6 +142,16 @@ func main() {
 	}
 }
 
+// webhookCertificateExists checks that the certificate and the key of the webhook server are mounted
+func webhookCertificateExists() error {
+	for _, file := range []string{"tls.crt", "tls.key"} {
+		if _, err := os.Stat(filepath.Join(webhookCertDir, file)); err != nil {
+			return fmt.Errorf("the certificate of the webhooks is missing: %v", err)
+		}
+	}
+	return nil
+}
+
 // addMetrics will create the Services and Service Monitors to allow the operator export the metrics by using
 // the Prometheus operator
 func addMetrics(ctx context.Context, cfg *rest.Config) {
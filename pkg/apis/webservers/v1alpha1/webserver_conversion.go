@@ -0,0 +1,5 @@
+package v1alpha1
+
+// Hub marks v1alpha1 as the version the other versions of the WebServer are converted to and from.
+// It is also the storage version and the version handled by the controller.
+func (*WebServer) Hub() {}
@@ -0,0 +1,361 @@
+package v1alpha1
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Default values of the optional fields of the WebServer
+const (
+	// DefaultWebAppName is the default name of the web application, it is deployed as the root context
+	DefaultWebAppName = "ROOT"
+	// DefaultWebAppDeployPath is the default path on which the application war is mounted
+	DefaultWebAppDeployPath = "/deployments/"
+	// DefaultApplicationSizeLimit is the default size of the PersistentVolumeClaim containing the application war
+	DefaultApplicationSizeLimit = "1Gi"
+	// DefaultCatalinaBase is the CATALINA_BASE directory of Tomcat in the JWS images
+	DefaultCatalinaBase = "/opt/jws-5.4/tomcat"
+	// DefaultTLSCertificateSecretSuffix is appended to the application name to name the Secret of the HTTPS connector
+	DefaultTLSCertificateSecretSuffix = "-tls"
+	// DefaultKeystoreType is the default type of the keystore of the HTTPS connector
+	DefaultKeystoreType = "PKCS12"
+	// DefaultCertManagerIssuerKind is the default kind of the cert-manager issuer of the HTTPS connector certificate
+	DefaultCertManagerIssuerKind = "Issuer"
+	// DefaultSessionManagerType is the default session manager of the session clustering, it replicates the sessions to all the pods
+	DefaultSessionManagerType = "DeltaManager"
+	// DefaultChannelSendOptions is the default channelSendOptions of the session clustering: asynchronous with acknowledgements
+	DefaultChannelSendOptions = 6
+	// DefaultSessionTable is the default table of the sessions stored in a database
+	DefaultSessionTable = "tomcat_sessions"
+	// DefaultSessionDrainingTimeoutSeconds is the default time to wait for the sessions of a draining pod to expire
+	DefaultSessionDrainingTimeoutSeconds = 300
+	// DefaultUpdateStrategyType is the default strategy replacing the pods, it stops all the pods before starting the new ones
+	DefaultUpdateStrategyType = "Recreate"
+	// DefaultMaxSurge is the default number of pods created above the replicas during a rolling update
+	DefaultMaxSurge = "25%"
+	// DefaultMaxUnavailable is the default number of unavailable pods during a rolling update, the old pods are stopped
+	// once the new ones are ready
+	DefaultMaxUnavailable = 0
+	// DefaultSmokeTestExpectedStatus is the default status code of the smoke tests of a blue/green update
+	DefaultSmokeTestExpectedStatus = 200
+	// DefaultCanaryMaxRestarts is the default number of restarts of a canary pod rolling the release back
+	DefaultCanaryMaxRestarts = 3
+	// DefaultProbePath is the default path of the HTTP probes, it is answered by the HealthCheckValve of Tomcat
+	DefaultProbePath = "/health"
+	// DefaultProbePort is the default port of the HTTP and TCP probes
+	DefaultProbePort = 8080
+	// DefaultProbeScheme is the default scheme of the HTTP probes
+	DefaultProbeScheme = "HTTP"
+	// DefaultProbePeriodSeconds is the default number of seconds between two probes
+	DefaultProbePeriodSeconds = 10
+	// DefaultProbeTimeoutSeconds is the default number of seconds after which a probe times out
+	DefaultProbeTimeoutSeconds = 5
+	// DefaultProbeFailureThreshold is the default number of consecutive failures of the readiness and liveness probes
+	DefaultProbeFailureThreshold = 3
+	// DefaultStartupProbeFailureThreshold is the default number of consecutive failures of the startup probe, the
+	// application has 5 minutes to start
+	DefaultStartupProbeFailureThreshold = 30
+	// DefaultJVMHeapSizing is the default option sizing the heap of the JVM, the JVM computes it from the memory limit
+	DefaultJVMHeapSizing = "MaxRAMPercentage"
+	// DefaultJVMHeapPercentage is the default percentage of the memory limit used by the heap of the JVM, the rest is
+	// left to the metaspace, the threads and the native memory
+	DefaultJVMHeapPercentage = 75
+)
+
+// DefaultCanarySteps are the default steps of a canary release
+var DefaultCanarySteps = []CanaryStepSpec{
+	{Weight: 10, PauseSeconds: 60},
+	{Weight: 50, PauseSeconds: 60},
+	{Weight: 100, PauseSeconds: 60},
+}
+
+// ReservedEnvVars are the environment variables of the Tomcat container set by the operator, they can't be set in env
+var ReservedEnvVars = []string{"KUBERNETES_NAMESPACE", "CATALINA_OPTS", "JAVA_OPTS", "TLS_KEYSTORE_PASSWORD"}
+
+// IsReservedEnvVar returns true if the environment variable of the Tomcat container is set by the operator
+func IsReservedEnvVar(name string) bool {
+	for _, reserved := range ReservedEnvVars {
+		if name == reserved {
+			return true
+		}
+	}
+	return false
+}
+
+// ReservedVolumeNames are the names of the volumes of the pods added by the operator, they can't be used in volumes
+// with the names starting with ReservedVolumeNamePrefix
+var ReservedVolumeNames = []string{"app-volume"}
+
+// ReservedVolumeNamePrefix starts the names of the volumes of the pods generated by the operator
+const ReservedVolumeNamePrefix = "webserver-"
+
+// IsReservedVolumeName returns true if the volume name is used, or may be used, by the volumes of the operator
+func IsReservedVolumeName(name string) bool {
+	if strings.HasPrefix(name, ReservedVolumeNamePrefix) {
+		return true
+	}
+	for _, reserved := range ReservedVolumeNames {
+		if name == reserved {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultApplicationBuildScript is the script used to build a web application when the WebApp doesn't provide one.
+// The build pod describes the application to build in the environment of the script with
+// webAppWarFileName, webAppSourceRepositoryURL, webAppSourceRepositoryRef and webAppSourceRepositoryContextDir.
+const DefaultApplicationBuildScript = `
+		# Some pods don't have root privileges, so the build takes place in /tmp
+		cd tmp;
+
+		# Create a custom .m2 repo in a location where no root privileges are required
+		mkdir -p /tmp/.m2/repo;
+
+		# Create custom maven settings that change the location of the .m2 repo
+		echo '<settings xmlns="http://maven.apache.org/SETTINGS/1.0.0" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"' >> /tmp/.m2/settings.xml
+		echo 'xsi:schemaLocation="http://maven.apache.org/SETTINGS/1.0.0 https://maven.apache.org/xsd/settings-1.0.0.xsd">' >> /tmp/.m2/settings.xml
+		echo '<localRepository>/tmp/.m2/repo</localRepository>' >> /tmp/.m2/settings.xml
+		echo '</settings>' >> /tmp/.m2/settings.xml
+
+		if [ -z ${webAppSourceRepositoryURL} ]; then
+			echo "Need an URL like https://github.com/jfclere/demo-webapp.git";
+			exit 1;
+		fi;
+
+		git clone ${webAppSourceRepositoryURL};
+		if [ $? -ne 0 ]; then
+			echo "Can't clone ${webAppSourceRepositoryURL}";
+			exit 1;
+		fi;
+
+		# Get the name of the source code directory
+		DIR=$(echo ${webAppSourceRepositoryURL##*/});
+		DIR=$(echo ${DIR%%.*});
+
+		cd ${DIR};
+
+		if [ ! -z ${webAppSourceRepositoryRef} ]; then
+			git checkout ${webAppSourceRepositoryRef};
+		fi;
+
+		if [ ! -z ${webAppSourceRepositoryContextDir} ]; then
+			cd ${webAppSourceRepositoryContextDir};
+		fi;
+
+		# Builds the webapp using the custom maven settings
+		mvn clean install -gs /tmp/.m2/settings.xml;
+		if [ $? -ne 0 ]; then
+			echo "mvn install failed please check the pom.xml in ${webAppSourceRepositoryURL}";
+			exit 1;
+		fi
+
+		# Copies the resulting war to the mounted persistent volume
+		cp target/*.war /mnt/${webAppWarFileName};`
+
+// IsSessionClusteringEnabled returns true if the sessions of the WebServer are replicated between the pods,
+// useSessionClustering enables the session clustering without sessionClustering.
+func IsSessionClusteringEnabled(t *WebServer) bool {
+	return t.Spec.UseSessionClustering || t.Spec.SessionClustering != nil
+}
+
+// HasColors returns true if the pod template changes of the WebServer are deployed next to the active pods by a
+// blue/green update or a canary release, only the Deployments of an application image support it. The Deployments are
+// then named after their color.
+func HasColors(t *WebServer) bool {
+	return t.Spec.UpdateStrategy != nil && (t.Spec.UpdateStrategy.Type == "BlueGreen" || t.Spec.UpdateStrategy.Type == "Canary") && t.Spec.WebImage != nil
+}
+
+// SessionClusteringFor returns a copy of the session clustering of the WebServer with the default values of the fields
+// which are not set, nil when the session clustering is disabled.
+func SessionClusteringFor(t *WebServer) *SessionClusteringSpec {
+	if !IsSessionClusteringEnabled(t) {
+		return nil
+	}
+	sessionClustering := &SessionClusteringSpec{}
+	if t.Spec.SessionClustering != nil {
+		sessionClustering = t.Spec.SessionClustering.DeepCopy()
+	}
+	if sessionClustering.ManagerType == "" {
+		sessionClustering.ManagerType = DefaultSessionManagerType
+	}
+	if sessionClustering.ChannelSendOptions == 0 {
+		sessionClustering.ChannelSendOptions = DefaultChannelSendOptions
+	}
+	return sessionClustering
+}
+
+// SetDefaults sets the default values of the optional fields of the WebServer which are not set.
+// It returns true if the WebServer has been modified.
+func SetDefaults(t *WebServer) bool {
+	modified := false
+	if t.Spec.CatalinaBase == "" {
+		t.Spec.CatalinaBase = DefaultCatalinaBase
+		modified = true
+	}
+	if sessionClustering := t.Spec.SessionClustering; sessionClustering != nil {
+		if sessionClustering.ManagerType == "" {
+			sessionClustering.ManagerType = DefaultSessionManagerType
+			modified = true
+		}
+		if sessionClustering.ChannelSendOptions == 0 {
+			sessionClustering.ChannelSendOptions = DefaultChannelSendOptions
+			modified = true
+		}
+	}
+	if sessionStore := t.Spec.SessionStore; sessionStore != nil && sessionStore.JDBC != nil && sessionStore.JDBC.SessionTable == "" {
+		sessionStore.JDBC.SessionTable = DefaultSessionTable
+		modified = true
+	}
+	if sessionDraining := t.Spec.SessionDraining; sessionDraining != nil && sessionDraining.TimeoutSeconds == 0 {
+		sessionDraining.TimeoutSeconds = DefaultSessionDrainingTimeoutSeconds
+		modified = true
+	}
+	if updateStrategy := t.Spec.UpdateStrategy; updateStrategy != nil {
+		if updateStrategy.Type == "" {
+			updateStrategy.Type = DefaultUpdateStrategyType
+			modified = true
+		}
+		if updateStrategy.Type == "RollingUpdate" && updateStrategy.MaxSurge == nil {
+			maxSurge := intstr.FromString(DefaultMaxSurge)
+			updateStrategy.MaxSurge = &maxSurge
+			modified = true
+		}
+		if updateStrategy.Type == "RollingUpdate" && updateStrategy.MaxUnavailable == nil {
+			maxUnavailable := intstr.FromInt(DefaultMaxUnavailable)
+			updateStrategy.MaxUnavailable = &maxUnavailable
+			modified = true
+		}
+		if blueGreen := updateStrategy.BlueGreen; blueGreen != nil {
+			for i := range blueGreen.SmokeTests {
+				if blueGreen.SmokeTests[i].ExpectedStatus == 0 {
+					blueGreen.SmokeTests[i].ExpectedStatus = DefaultSmokeTestExpectedStatus
+					modified = true
+				}
+			}
+		}
+		if updateStrategy.Type == "Canary" && updateStrategy.Canary == nil {
+			updateStrategy.Canary = &CanarySpec{}
+			modified = true
+		}
+		if canary := updateStrategy.Canary; canary != nil {
+			if len(canary.Steps) == 0 {
+				canary.Steps = append([]CanaryStepSpec{}, DefaultCanarySteps...)
+				modified = true
+			}
+			if canary.MaxRestarts == 0 {
+				canary.MaxRestarts = DefaultCanaryMaxRestarts
+				modified = true
+			}
+		}
+	}
+	if jvmHeap := t.Spec.JVMHeap; jvmHeap != nil {
+		if jvmHeap.Sizing == "" {
+			jvmHeap.Sizing = DefaultJVMHeapSizing
+			modified = true
+		}
+		if jvmHeap.Sizing != "None" && jvmHeap.Percentage == 0 {
+			jvmHeap.Percentage = DefaultJVMHeapPercentage
+			modified = true
+		}
+	}
+	if t.Spec.WebImage != nil && t.Spec.WebImage.WebApp != nil {
+		webApp := t.Spec.WebImage.WebApp
+		if webApp.Name == "" {
+			webApp.Name = DefaultWebAppName
+			modified = true
+		}
+		if webApp.DeployPath == "" {
+			webApp.DeployPath = DefaultWebAppDeployPath
+			modified = true
+		}
+		if webApp.ApplicationSizeLimit == "" {
+			webApp.ApplicationSizeLimit = DefaultApplicationSizeLimit
+			modified = true
+		}
+		if webApp.Builder != nil && webApp.Builder.ApplicationBuildScript == "" {
+			webApp.Builder.ApplicationBuildScript = DefaultApplicationBuildScript
+			modified = true
+		}
+	}
+	healthChecks := []*WebServerHealthCheckSpec{}
+	if t.Spec.WebImage != nil && t.Spec.WebImage.WebServerHealthCheck != nil {
+		healthChecks = append(healthChecks, t.Spec.WebImage.WebServerHealthCheck)
+	}
+	if t.Spec.WebImageStream != nil && t.Spec.WebImageStream.WebServerHealthCheck != nil {
+		healthChecks = append(healthChecks, t.Spec.WebImageStream.WebServerHealthCheck)
+	}
+	for _, healthCheck := range healthChecks {
+		if SetProbeDefaults(healthCheck.ReadinessProbe, DefaultProbeFailureThreshold) {
+			modified = true
+		}
+		if SetProbeDefaults(healthCheck.LivenessProbe, DefaultProbeFailureThreshold) {
+			modified = true
+		}
+		if SetProbeDefaults(healthCheck.StartupProbe, DefaultStartupProbeFailureThreshold) {
+			modified = true
+		}
+	}
+	if tls := t.Spec.TLS; tls != nil {
+		if tls.CertificateSecretName == "" {
+			tls.CertificateSecretName = t.Spec.ApplicationName + DefaultTLSCertificateSecretSuffix
+			modified = true
+		}
+		if tls.Keystore != nil && tls.Keystore.Type == "" {
+			tls.Keystore.Type = DefaultKeystoreType
+			modified = true
+		}
+		if tls.CertManager != nil && tls.CertManager.IssuerKind == "" {
+			tls.CertManager.IssuerKind = DefaultCertManagerIssuerKind
+			modified = true
+		}
+	}
+	return modified
+}
+
+// SetProbeDefaults sets the default handler and timings of a probe which are not set, the probe may be nil.
+// It returns true if the probe has been modified.
+func SetProbeDefaults(probe *ProbeSpec, failureThreshold int32) bool {
+	if probe == nil {
+		return false
+	}
+	modified := false
+	if probe.HTTPGet == nil && probe.TCPSocket == nil && probe.Exec == nil {
+		probe.HTTPGet = &HTTPGetProbeSpec{}
+		modified = true
+	}
+	if httpGet := probe.HTTPGet; httpGet != nil {
+		if httpGet.Path == "" {
+			httpGet.Path = DefaultProbePath
+			modified = true
+		}
+		if httpGet.Port == nil {
+			port := intstr.FromInt(DefaultProbePort)
+			httpGet.Port = &port
+			modified = true
+		}
+		if httpGet.Scheme == "" {
+			httpGet.Scheme = DefaultProbeScheme
+			modified = true
+		}
+	}
+	if tcpSocket := probe.TCPSocket; tcpSocket != nil && tcpSocket.Port == nil {
+		port := intstr.FromInt(DefaultProbePort)
+		tcpSocket.Port = &port
+		modified = true
+	}
+	if probe.PeriodSeconds == 0 {
+		probe.PeriodSeconds = DefaultProbePeriodSeconds
+		modified = true
+	}
+	if probe.TimeoutSeconds == 0 {
+		probe.TimeoutSeconds = DefaultProbeTimeoutSeconds
+		modified = true
+	}
+	if probe.FailureThreshold == 0 {
+		probe.FailureThreshold = failureThreshold
+		modified = true
+	}
+	return modified
+}
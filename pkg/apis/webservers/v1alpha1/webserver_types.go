@@ -1,18 +1,14 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
-// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
-// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
-
 // WebServerSpec defines the desired state of WebServer
 type WebServerSpec struct {
-	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
-	// Important: Run "operator-sdk generate k8s" to regenerate code after modifying this file
-	// Add custom validation using kubebuilder tags: https://book-v1.book.kubebuilder.io/beyond_basics/generating_crd.html
-
 	// The base for the names of the deployed application resources
 	// +kubebuilder:validation:Pattern=^[a-z]([-a-z0-9]*[a-z0-9])?$
 	ApplicationName string `json:"applicationName"`
@@ -21,10 +17,325 @@ type WebServerSpec struct {
 	Replicas int32 `json:"replicas"`
 	// Use Session Clustering
 	UseSessionClustering bool `json:"useSessionClustering,omitempty"`
+	// (Optional) Configuration of the session clustering, setting it enables the session clustering
+	SessionClustering *SessionClusteringSpec `json:"sessionClustering,omitempty"`
+	// (Optional) Store the sessions in Redis or in a database, it is an alternative to the session clustering
+	SessionStore *SessionStoreSpec `json:"sessionStore,omitempty"`
+	// (Optional) Drain the sessions of the pods before scaling down: the pods are removed from the Service and the
+	// replicas are lowered once their sessions have expired
+	SessionDraining *SessionDrainingSpec `json:"sessionDraining,omitempty"`
+	// The CATALINA_BASE directory of Tomcat in the application image, the server.xml generated by the operator
+	// is mounted in its conf directory (default /opt/jws-5.4/tomcat)
+	CatalinaBase string `json:"catalinaBase,omitempty"`
 	// (Deployment method 1) Application image
 	WebImage *WebImageSpec `json:"webImage,omitempty"`
 	// (Deployment method 2) Imagestream
 	WebImageStream *WebImageStreamSpec `json:"webImageStream,omitempty"`
+	// (Optional) Scale the application automatically with a HorizontalPodAutoscaler, replicas is then managed by the autoscaler
+	Autoscaling *AutoscalingSpec `json:"autoscaling,omitempty"`
+	// (Optional) How the pods are replaced when the application is updated (default Recreate)
+	UpdateStrategy *UpdateStrategySpec `json:"updateStrategy,omitempty"`
+	// (Optional) Expose the application with an Ingress on Kubernetes, on OpenShift the application is exposed by a Route
+	Ingress *IngressSpec `json:"ingress,omitempty"`
+	// (Optional) Configuration of the Route exposing the application on OpenShift
+	Route *RouteSpec `json:"route,omitempty"`
+	// (Optional) Serve the application over HTTPS on port 8443, in addition to HTTP on port 8080
+	TLS *TLSSpec `json:"tls,omitempty"`
+	// (Optional) The compute resources of the Tomcat container, the pods have the BestEffort QoS class and are the first
+	// evicted when not set
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+	// (Optional) How the maximum heap of the JVM follows the memory limit of the Tomcat container (default 75% of the
+	// limit with -XX:MaxRAMPercentage)
+	JVMHeap *JVMHeapSpec `json:"jvmHeap,omitempty"`
+	// (Optional) Environment variables of the Tomcat container, in the format of the env of a Kubernetes container. The
+	// variables set by the operator can't be set, see catalinaOpts and javaOpts.
+	Env []corev1.EnvVar `json:"env,omitempty"`
+	// (Optional) ConfigMaps and Secrets whose keys are set as environment variables of the Tomcat container, the
+	// variables of env and of the operator take precedence
+	EnvFrom []corev1.EnvFromSource `json:"envFrom,omitempty"`
+	// (Optional) Options of Tomcat added to CATALINA_OPTS after the options set by the operator, for example system
+	// properties
+	CatalinaOpts string `json:"catalinaOpts,omitempty"`
+	// (Optional) Options of the JVM added to JAVA_OPTS after the heap sizing of jvmHeap, they take precedence over it
+	JavaOpts string `json:"javaOpts,omitempty"`
+	// (Optional) Volumes added to the pods, in the format of the volumes of a Kubernetes pod. The app-volume name and
+	// the names starting with webserver- are reserved for the volumes of the operator.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Volumes []corev1.Volume `json:"volumes,omitempty"`
+	// (Optional) Mounts of the volumes in the Tomcat container, for example of shared libraries in the lib directory
+	// of catalinaBase
+	VolumeMounts []corev1.VolumeMount `json:"volumeMounts,omitempty"`
+	// (Optional) Containers run in order before the Tomcat container, for example to fetch secrets in a shared volume
+	// +kubebuilder:pruning:PreserveUnknownFields
+	InitContainers []corev1.Container `json:"initContainers,omitempty"`
+	// (Optional) Containers run next to the Tomcat container, for example to ship its logs
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Sidecars []corev1.Container `json:"sidecars,omitempty"`
+}
+
+// JVMHeapSpec describes how the maximum heap of the JVM is computed from the memory limit of the Tomcat container, the
+// option is added to JAVA_OPTS. The heap isn't sized by the operator when the container has no memory limit.
+type JVMHeapSpec struct {
+	// The option sizing the heap: MaxRAMPercentage lets the JVM compute the heap from the limit, it requires Java 8u191
+	// or later, Xmx sets the heap computed by the operator and None leaves the heap to the image (default MaxRAMPercentage)
+	// +kubebuilder:validation:Enum=MaxRAMPercentage;Xmx;None
+	Sizing string `json:"sizing,omitempty"`
+	// The percentage of the memory limit used by the heap (default 75)
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	Percentage int32 `json:"percentage,omitempty"`
+}
+
+// UpdateStrategySpec describes how the pods are replaced when the pod template changes
+type UpdateStrategySpec struct {
+	// The strategy: Recreate stops all the pods before starting the new ones, RollingUpdate replaces the pods
+	// progressively, an old pod is only stopped once a new one is ready, BlueGreen deploys the new pods next to
+	// the old ones and switches the traffic once they are verified, Canary deploys the new pods next to the old
+	// ones and shifts the traffic to them step by step (default Recreate)
+	// +kubebuilder:validation:Enum=Recreate;RollingUpdate;BlueGreen;Canary
+	Type string `json:"type,omitempty"`
+	// (RollingUpdate only) The maximum number of pods created above the desired replicas during the update,
+	// a number or a percentage of the replicas (default 25%)
+	MaxSurge *intstr.IntOrString `json:"maxSurge,omitempty"`
+	// (RollingUpdate only) The maximum number of unavailable pods during the update, a number or a percentage
+	// of the replicas (default 0)
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+	// (RollingUpdate only) The number of seconds a new pod has to be ready before an old pod is stopped
+	// +kubebuilder:validation:Minimum=0
+	MinReadySeconds int32 `json:"minReadySeconds,omitempty"`
+	// (BlueGreen only) The verification of the new pods before the traffic is switched to them
+	BlueGreen *BlueGreenSpec `json:"blueGreen,omitempty"`
+	// (Canary only) The steps shifting the traffic to the new pods and the rollback conditions
+	Canary *CanarySpec `json:"canary,omitempty"`
+	// (Optional) Revert the Deployment to the last known good image when the pods of a new image crash or don't
+	// progress, the web.servers.org/rollback annotation of the WebServer requests a rollback when it isn't set
+	AutomaticRollback bool `json:"automaticRollback,omitempty"`
+}
+
+// BlueGreenSpec describes how the candidate pods of a blue/green update are verified. The pods of the WebServer are
+// deployed by two Deployments suffixed with a color, the Service selects the pods of the active color while the
+// candidate color runs the new pod template.
+type BlueGreenSpec struct {
+	// (Optional) HTTP requests sent to each candidate pod once it is ready, they all have to succeed
+	// before the traffic is switched to the candidate
+	SmokeTests []SmokeTestSpec `json:"smokeTests,omitempty"`
+	// (Optional) Wait for the web.servers.org/blue-green annotation of the WebServer to be set to promote
+	// before switching the traffic to the verified candidate
+	ManualPromotion bool `json:"manualPromotion,omitempty"`
+}
+
+// CanarySpec describes how the traffic is shifted to the canary pods. The canary runs the new pod template in a
+// Deployment suffixed with a color next to the stable one and is selected by a second Service suffixed with -canary,
+// the Route splits the requests between the two Services.
+type CanarySpec struct {
+	// (Optional) The steps of the release, the canary is promoted once the pause of the last step is over
+	// (default 10%, 50% and 100% of the requests with pauses of 60 seconds)
+	Steps []CanaryStepSpec `json:"steps,omitempty"`
+	// The number of restarts of a canary pod rolling the release back (default 3)
+	// +kubebuilder:validation:Minimum=1
+	MaxRestarts int32 `json:"maxRestarts,omitempty"`
+}
+
+// CanaryStepSpec describes a share of the requests sent to the canary pods
+type CanaryStepSpec struct {
+	// The percentage of the requests sent to the canary pods
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	Weight int32 `json:"weight"`
+	// The number of seconds the canary pods have to stay ready with this weight before the next step
+	// +kubebuilder:validation:Minimum=0
+	PauseSeconds int32 `json:"pauseSeconds,omitempty"`
+}
+
+// SmokeTestSpec describes an HTTP request checking a candidate pod of a blue/green update
+type SmokeTestSpec struct {
+	// The path of the request on the HTTP port 8080
+	// +kubebuilder:validation:Pattern=^/
+	Path string `json:"path"`
+	// The expected status code of the response (default 200)
+	// +kubebuilder:validation:Minimum=100
+	// +kubebuilder:validation:Maximum=599
+	ExpectedStatus int32 `json:"expectedStatus,omitempty"`
+}
+
+// AutoscalingSpec describes the HorizontalPodAutoscaler created for the application
+type AutoscalingSpec struct {
+	// The lower limit for the number of replicas (default 1)
+	// +kubebuilder:validation:Minimum=1
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+	// The upper limit for the number of replicas, it can't be lower than minReplicas
+	// +kubebuilder:validation:Minimum=1
+	MaxReplicas int32 `json:"maxReplicas"`
+	// The target average CPU utilization of the pods, in percent of the requested CPU
+	// +kubebuilder:validation:Minimum=1
+	TargetCPUUtilizationPercentage *int32 `json:"targetCPUUtilizationPercentage,omitempty"`
+	// The target average memory utilization of the pods, in percent of the requested memory
+	// +kubebuilder:validation:Minimum=1
+	TargetMemoryUtilizationPercentage *int32 `json:"targetMemoryUtilizationPercentage,omitempty"`
+	// Custom metrics of the pods, served by the custom metrics API
+	// +listType=map
+	// +listMapKey=name
+	CustomMetrics []CustomMetricSpec `json:"customMetrics,omitempty"`
+}
+
+// RouteSpec describes the Route created for the application on OpenShift
+type RouteSpec struct {
+	// The host name of the Route, generated by the router if empty
+	Host string `json:"host,omitempty"`
+	// The path of the application
+	Path string `json:"path,omitempty"`
+	// (Optional) TLS termination of the Route, the Route is plain HTTP if not set
+	TLS *RouteTLSSpec `json:"tls,omitempty"`
+	// The timeout of the requests to the application, for example 60s, set in the haproxy.router.openshift.io/timeout annotation
+	Timeout string `json:"timeout,omitempty"`
+	// The name of the cookie used by the router for sticky sessions, set in the router.openshift.io/cookie_name annotation
+	CookieName string `json:"cookieName,omitempty"`
+	// Annotations added to the Route, for example to configure the router
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// SessionClusteringSpec describes the cluster replicating the HTTP sessions between the pods
+type SessionClusteringSpec struct {
+	// The session manager: DeltaManager replicates the sessions to all the pods, BackupManager to a single backup pod
+	// (default DeltaManager)
+	// +kubebuilder:validation:Enum=DeltaManager;BackupManager
+	ManagerType string `json:"managerType,omitempty"`
+	// The channelSendOptions of the cluster, a combination of the flags of org.apache.catalina.tribes.Channel (default 6)
+	// +kubebuilder:validation:Minimum=1
+	ChannelSendOptions int32 `json:"channelSendOptions,omitempty"`
+	// How the pods discover each other: KUBEPing with the Kubernetes API, it requires a RoleBinding, or DNSPing with a
+	// headless Service. When not set KUBEPing is used and the operator falls back to DNSPing if it can't create the RoleBinding.
+	// +kubebuilder:validation:Enum=KUBEPing;DNSPing
+	MembershipProvider string `json:"membershipProvider,omitempty"`
+	// (Optional) Tuning of the membership provider
+	Membership *MembershipSpec `json:"membership,omitempty"`
+	// Expire the sessions of a pod when it shuts down, instead of keeping them on the other pods (DeltaManager only)
+	ExpireSessionsOnShutdown bool `json:"expireSessionsOnShutdown,omitempty"`
+	// Notify the session listeners of the application when a session is replicated (default true)
+	NotifyListenersOnReplication *bool `json:"notifyListenersOnReplication,omitempty"`
+	// Regular expression matching the requests which don't modify the sessions, for example .*\.gif|.*\.js|.*\.css,
+	// the sessions aren't replicated after them
+	ReplicationFilter string `json:"replicationFilter,omitempty"`
+}
+
+// MembershipSpec describes the timeouts of the membership provider, in milliseconds
+type MembershipSpec struct {
+	// The timeout of the connections to the Kubernetes API or to the DNS (default 1000)
+	// +kubebuilder:validation:Minimum=1
+	ConnectTimeout *int32 `json:"connectTimeout,omitempty"`
+	// The timeout of the reads from the Kubernetes API or from the DNS (default 1000)
+	// +kubebuilder:validation:Minimum=1
+	ReadTimeout *int32 `json:"readTimeout,omitempty"`
+	// How long a pod is kept in the cluster without being discovered again (default 5000)
+	// +kubebuilder:validation:Minimum=1
+	ExpirationTime *int32 `json:"expirationTime,omitempty"`
+}
+
+// SessionStoreSpec describes the external store of the HTTP sessions, shared by the pods instead of replicating the sessions
+type SessionStoreSpec struct {
+	// The Secret containing the connection details of the store: url, and optionally username and password
+	SecretName string `json:"secretName"`
+	// (Optional) Store the sessions in Redis with the Redisson session manager, the image must contain the Redisson Tomcat libraries
+	Redis *RedisSessionStoreSpec `json:"redis,omitempty"`
+	// (Optional) Store the sessions in a database with the PersistentManager and the JDBCStore of Tomcat,
+	// the image must contain the JDBC driver
+	JDBC *JDBCSessionStoreSpec `json:"jdbc,omitempty"`
+}
+
+// RedisSessionStoreSpec describes the sessions stored in Redis, the url of the Secret is the address of Redis like redis://redis:6379
+type RedisSessionStoreSpec struct {
+	// (Optional) Prefix of the keys of the sessions in Redis, to share Redis between applications
+	KeyPrefix string `json:"keyPrefix,omitempty"`
+}
+
+// JDBCSessionStoreSpec describes the sessions stored in a database, the url of the Secret is the JDBC URL of the database
+type JDBCSessionStoreSpec struct {
+	// The class name of the JDBC driver
+	DriverName string `json:"driverName"`
+	// The table of the sessions (default tomcat_sessions)
+	SessionTable string `json:"sessionTable,omitempty"`
+}
+
+// SessionDrainingSpec describes how the pods removed by a scale down are drained
+type SessionDrainingSpec struct {
+	// The maximum time in seconds to wait for the active sessions of a draining pod to expire (default 300)
+	// +kubebuilder:validation:Minimum=1
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+}
+
+// TLSSpec describes the HTTPS connector of Tomcat, listening on port 8443
+type TLSSpec struct {
+	// The name of the Secret containing the certificate of the connector, either in the PEM files tls.crt and tls.key
+	// or in a keystore (default <applicationName>-tls)
+	CertificateSecretName string `json:"certificateSecretName,omitempty"`
+	// (Optional) The keystore of the Secret containing the certificate, the PEM files are used if not set
+	Keystore *KeystoreSpec `json:"keystore,omitempty"`
+	// (Optional) Request the certificate from cert-manager, it is then stored in the certificateSecretName Secret
+	CertManager *CertManagerSpec `json:"certManager,omitempty"`
+}
+
+// KeystoreSpec describes a keystore stored in a Secret
+type KeystoreSpec struct {
+	// The key of the keystore file in the Secret
+	Key string `json:"key"`
+	// The type of the keystore: PKCS12 or JKS (default PKCS12)
+	// +kubebuilder:validation:Enum=PKCS12;JKS
+	Type string `json:"type,omitempty"`
+	// The key of the Secret containing the password of the keystore
+	PasswordSecretKeyRef corev1.SecretKeySelector `json:"passwordSecretKeyRef"`
+}
+
+// CertManagerSpec describes the cert-manager Certificate requested for the HTTPS connector
+type CertManagerSpec struct {
+	// The name of the cert-manager issuer
+	IssuerName string `json:"issuerName"`
+	// The kind of the issuer: Issuer or ClusterIssuer (default Issuer)
+	// +kubebuilder:validation:Enum=Issuer;ClusterIssuer
+	IssuerKind string `json:"issuerKind,omitempty"`
+	// DNS names added to the names of the Service of the application
+	DNSNames []string `json:"dnsNames,omitempty"`
+	// The validity of the certificate, for example 2160h
+	Duration string `json:"duration,omitempty"`
+	// How long before its expiry the certificate is renewed, for example 360h
+	RenewBefore string `json:"renewBefore,omitempty"`
+}
+
+// RouteTLSSpec describes the TLS termination of the Route
+type RouteTLSSpec struct {
+	// Where TLS is terminated: edge (by the router), passthrough (by the application) or reencrypt (by the router and the application)
+	// +kubebuilder:validation:Enum=edge;passthrough;reencrypt
+	Termination string `json:"termination"`
+	// What the router does with the plain HTTP requests: None, Allow or Redirect (edge and reencrypt terminations only)
+	// +kubebuilder:validation:Enum=None;Allow;Redirect
+	InsecureEdgeTerminationPolicy string `json:"insecureEdgeTerminationPolicy,omitempty"`
+	// The name of the Secret containing the certificate (tls.crt), the key (tls.key) and optionally the CA certificate (ca.crt)
+	// of the Route, the default certificate of the router is used if empty (edge and reencrypt terminations only)
+	CertificateSecretName string `json:"certificateSecretName,omitempty"`
+	// The name of the Secret containing in ca.crt the CA certificate used by the router to validate the certificate of the application
+	// (reencrypt termination only)
+	DestinationCACertificateSecretName string `json:"destinationCACertificateSecretName,omitempty"`
+}
+
+// IngressSpec describes the Ingress created for the application on Kubernetes
+type IngressSpec struct {
+	// The host name of the application, the Ingress matches all the hosts if empty
+	Host string `json:"host,omitempty"`
+	// The path of the application (default: /)
+	Path string `json:"path,omitempty"`
+	// The class of the Ingress controller serving the Ingress, set in the kubernetes.io/ingress.class annotation
+	IngressClassName string `json:"ingressClassName,omitempty"`
+	// Annotations added to the Ingress, for example to configure the Ingress controller
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// The name of the Secret containing the TLS certificate of the host, the Ingress terminates TLS when set
+	TLSSecretName string `json:"tlsSecretName,omitempty"`
+}
+
+// CustomMetricSpec describes a custom metric of the pods used to scale the application
+type CustomMetricSpec struct {
+	// The name of the metric
+	Name string `json:"name"`
+	// The target value of the metric averaged across the pods
+	TargetAverageValue resource.Quantity `json:"targetAverageValue"`
 }
 
 // (Deployment method 1) Application image
@@ -61,6 +372,8 @@ type BuilderSpec struct {
 	Image string `json:"image"`
 	// The script that the BuilderImage will use to build the application war and move it to /mnt
 	ApplicationBuildScript string `json:"applicationBuildScript,omitempty"`
+	// (Optional) The compute resources of the build pod
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
 }
 
 // (Deployment method 2) Imagestream
@@ -97,13 +410,82 @@ type WebSourcesParamsSpec struct {
 	GenericWebhookSecret string `json:"genericWebhookSecret,omitempty"`
 	// Secret for a Github web hook
 	GithubWebhookSecret string `json:"githubWebhookSecret,omitempty"`
+	// (Optional) The compute resources of the build pods of the BuildConfig
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
 }
 
 type WebServerHealthCheckSpec struct {
 	// String for the pod readiness health check logic
-	ServerReadinessScript string `json:"serverReadinessScript"`
+	ServerReadinessScript string `json:"serverReadinessScript,omitempty"`
 	// String for the pod liveness health check logic
 	ServerLivenessScript string `json:"serverLivenessScript,omitempty"`
+	// (Optional) The probe marking the pods ready to receive requests, it replaces serverReadinessScript (default an HTTP GET
+	// of /health on port 8080)
+	ReadinessProbe *ProbeSpec `json:"readinessProbe,omitempty"`
+	// (Optional) The probe restarting the containers which are no longer alive, it replaces serverLivenessScript (default an
+	// HTTP GET of /health on port 8080)
+	LivenessProbe *ProbeSpec `json:"livenessProbe,omitempty"`
+	// (Optional) The probe delaying the other probes until the application is started (default the liveness probe
+	// with a failure threshold of 30)
+	StartupProbe *ProbeSpec `json:"startupProbe,omitempty"`
+}
+
+// ProbeSpec describes a probe of the pods with one of httpGet, tcpSocket or exec (default an HTTP GET of /health
+// on port 8080) and its timings
+type ProbeSpec struct {
+	// (Optional) An HTTP GET request, the probe succeeds when the status of the response is between 200 and 399
+	HTTPGet *HTTPGetProbeSpec `json:"httpGet,omitempty"`
+	// (Optional) A TCP connection, the probe succeeds when the port is open
+	TCPSocket *TCPSocketProbeSpec `json:"tcpSocket,omitempty"`
+	// (Optional) A command run in the container, the probe succeeds when it exits with 0
+	Exec *ExecProbeSpec `json:"exec,omitempty"`
+	// The number of seconds after the start of the container before the first probe (default 0)
+	// +kubebuilder:validation:Minimum=0
+	InitialDelaySeconds int32 `json:"initialDelaySeconds,omitempty"`
+	// The number of seconds between two probes (default 10)
+	// +kubebuilder:validation:Minimum=1
+	PeriodSeconds int32 `json:"periodSeconds,omitempty"`
+	// The number of seconds after which a probe times out (default 5)
+	// +kubebuilder:validation:Minimum=1
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+	// The number of consecutive failures after which the probe fails (default 3, 30 for the startup probe)
+	// +kubebuilder:validation:Minimum=1
+	FailureThreshold int32 `json:"failureThreshold,omitempty"`
+}
+
+// HTTPGetProbeSpec describes the HTTP GET request of a probe
+type HTTPGetProbeSpec struct {
+	// The path of the request (default /health)
+	// +kubebuilder:validation:Pattern=^/
+	Path string `json:"path,omitempty"`
+	// The number or the name of the port of the container (default 8080)
+	Port *intstr.IntOrString `json:"port,omitempty"`
+	// The scheme of the request (default HTTP)
+	// +kubebuilder:validation:Enum=HTTP;HTTPS
+	Scheme string `json:"scheme,omitempty"`
+	// (Optional) The headers of the request
+	HTTPHeaders []HTTPHeaderSpec `json:"httpHeaders,omitempty"`
+}
+
+// HTTPHeaderSpec describes a header of the HTTP request of a probe
+type HTTPHeaderSpec struct {
+	// The name of the header
+	Name string `json:"name"`
+	// The value of the header
+	Value string `json:"value"`
+}
+
+// TCPSocketProbeSpec describes the TCP connection of a probe
+type TCPSocketProbeSpec struct {
+	// The number or the name of the port of the container (default 8080)
+	Port *intstr.IntOrString `json:"port,omitempty"`
+}
+
+// ExecProbeSpec describes the command of a probe
+type ExecProbeSpec struct {
+	// The command and its arguments, it isn't run in a shell
+	// +kubebuilder:validation:MinItems=1
+	Command []string `json:"command"`
 }
 
 // WebServerStatus defines the observed state of WebServer
@@ -115,11 +497,87 @@ type WebServerStatus struct {
 	Pods []PodStatus `json:"pods,omitempty"`
 	// +listType=set
 	Hosts []string `json:"hosts,omitempty"`
+	// The label selector of the pods of the application, used by the scale subresource
+	Selector string `json:"selector,omitempty"`
 	// Represents the number of pods which are in scaledown process
 	// what particular pod is scaling down can be verified by PodStatus
 	//
 	// Read-only.
 	ScalingdownPods int32 `json:"scalingdownPods"`
+	// The generation of the WebServer that was last processed by the operator
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions represent the latest available observations of the WebServer state
+	// +listType=map
+	// +listMapKey=type
+	Conditions []WebServerCondition `json:"conditions,omitempty"`
+	// The Deployments of the blue/green updates and of the canary releases
+	BlueGreen *BlueGreenStatus `json:"blueGreen,omitempty"`
+	// The state of the canary release in progress
+	Canary *CanaryStatus `json:"canary,omitempty"`
+	// The image, pinned to its digest, of the last pod template whose pods were all ready
+	LastKnownGoodImage string `json:"lastKnownGoodImage,omitempty"`
+	// The application image replaced by the last known good image after a rollback, it isn't deployed again until
+	// the application image changes
+	RolledBackImage string `json:"rolledBackImage,omitempty"`
+}
+
+// BlueGreenStatus describes the Deployments of the blue/green updates and of the canary releases
+type BlueGreenStatus struct {
+	// The color of the Deployment selected by the Service, blue or green
+	ActiveColor string `json:"activeColor,omitempty"`
+	// The color of the Deployment running the new pod template, empty when no update is in progress
+	CandidateColor string `json:"candidateColor,omitempty"`
+	// The state of the candidate: Deploying, Verifying, WaitingForPromotion, Aborted or RolledBack
+	CandidatePhase string `json:"candidatePhase,omitempty"`
+	// The hash of the pod template of the aborted candidate, it isn't deployed again until the WebServer changes
+	AbortedPodTemplateHash string `json:"abortedPodTemplateHash,omitempty"`
+}
+
+// CanaryStatus describes the step of the canary release in progress
+type CanaryStatus struct {
+	// The current step, starting at 1, 0 until the canary pods are ready
+	Step int32 `json:"step,omitempty"`
+	// The percentage of the requests sent to the canary pods
+	Weight int32 `json:"weight,omitempty"`
+	// The time the current step started
+	StepStartTime *metav1.Time `json:"stepStartTime,omitempty"`
+}
+
+// WebServerConditionType is the type of a WebServerCondition
+type WebServerConditionType string
+
+const (
+	// WebServerAvailable means all the desired replicas of the application are ready
+	WebServerAvailable WebServerConditionType = "Available"
+	// WebServerProgressing means the operator is creating or updating resources of the application
+	WebServerProgressing WebServerConditionType = "Progressing"
+	// WebServerBuildSucceeded reports the result of the last build of the application
+	WebServerBuildSucceeded WebServerConditionType = "BuildSucceeded"
+	// WebServerDegraded means the application can't reach the desired state without user action
+	WebServerDegraded WebServerConditionType = "Degraded"
+	// WebServerReconcileError means the last reconciliation of the WebServer returned an error
+	WebServerReconcileError WebServerConditionType = "ReconcileError"
+	// WebServerRolledBack means the application image was replaced by the last known good image
+	WebServerRolledBack WebServerConditionType = "RolledBack"
+)
+
+// WebServerCondition describes the state of a WebServer at a certain point.
+// It follows the shape of the metav1.Condition of newer Kubernetes releases.
+// +k8s:openapi-gen=true
+type WebServerCondition struct {
+	// Type of the condition
+	Type WebServerConditionType `json:"type"`
+	// Status of the condition, one of True, False or Unknown
+	// +kubebuilder:validation:Enum=True;False;Unknown
+	Status corev1.ConditionStatus `json:"status"`
+	// The generation of the WebServer the condition was set for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// The last time the condition transitioned from one status to another
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+	// A programmatic identifier, in CamelCase, for the reason of the last transition
+	Reason string `json:"reason"`
+	// A human readable message with details about the transition
+	Message string `json:"message"`
 }
 
 const (
@@ -128,6 +586,9 @@ const (
 	PodStateActive = "ACTIVE"
 	// PodStatePending represents PodStatus.State when pod is pending
 	PodStatePending = "PENDING"
+	// PodStateDraining represents PodStatus.State when pod is removed from the Service load balancer
+	// and waits for its sessions to expire before being scaled down
+	PodStateDraining = "DRAINING"
 	// PodStateFailed represents PodStatus.State when pod has failed
 	PodStateFailed = "FAILED"
 )
@@ -138,14 +599,16 @@ type PodStatus struct {
 	Name  string `json:"name"`
 	PodIP string `json:"podIP"`
 	// Represent the state of the Pod, it is used especially during scale down.
-	// +kubebuilder:validation:Enum=ACTIVE;PENDING;FAILED
+	// +kubebuilder:validation:Enum=ACTIVE;PENDING;DRAINING;FAILED
 	State string `json:"state"`
 }
 
 // Web Server is the schema for the webservers API
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 // +kubebuilder:subresource:status
+// +kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas,selectorpath=.status.selector
 // +kubebuilder:resource:path=webservers,scope=Namespaced
+// +kubebuilder:storageversion
 type WebServer struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -0,0 +1,632 @@
+package v1beta1
+
+import (
+	"fmt"
+
+	"github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+var _ conversion.Convertible = &WebServer{}
+
+// ConvertTo converts this WebServer to the hub version (v1alpha1)
+func (src *WebServer) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1alpha1.WebServer)
+	dst.ObjectMeta = src.ObjectMeta
+
+	dst.Spec = v1alpha1.WebServerSpec{
+		ApplicationName: src.Spec.ApplicationName,
+		Replicas:        src.Spec.Replicas,
+		Autoscaling:     convertAutoscalingTo(src.Spec.Autoscaling),
+		Resources:       src.Spec.Resources,
+		Env:             src.Spec.Env,
+		EnvFrom:         src.Spec.EnvFrom,
+		Volumes:         src.Spec.Volumes,
+		VolumeMounts:    src.Spec.VolumeMounts,
+		InitContainers:  src.Spec.InitContainers,
+		Sidecars:        src.Spec.Sidecars,
+	}
+	dst.Spec.UpdateStrategy = convertUpdateStrategyTo(src.Spec.UpdateStrategy)
+	if src.Spec.Tomcat != nil {
+		dst.Spec.UseSessionClustering = src.Spec.Tomcat.UseSessionClustering
+		dst.Spec.SessionClustering = convertSessionClusteringTo(src.Spec.Tomcat.SessionClustering)
+		dst.Spec.SessionStore = convertSessionStoreTo(src.Spec.Tomcat.SessionStore)
+		if sessionDraining := src.Spec.Tomcat.SessionDraining; sessionDraining != nil {
+			converted := v1alpha1.SessionDrainingSpec(*sessionDraining)
+			dst.Spec.SessionDraining = &converted
+		}
+		dst.Spec.CatalinaBase = src.Spec.Tomcat.CatalinaBase
+		dst.Spec.TLS = convertTLSTo(src.Spec.Tomcat.TLS)
+		dst.Spec.CatalinaOpts = src.Spec.Tomcat.CatalinaOpts
+		dst.Spec.JavaOpts = src.Spec.Tomcat.JavaOpts
+		if jvmHeap := src.Spec.Tomcat.JVMHeap; jvmHeap != nil {
+			converted := v1alpha1.JVMHeapSpec(*jvmHeap)
+			dst.Spec.JVMHeap = &converted
+		}
+	}
+	if networking := src.Spec.Networking; networking != nil {
+		if networking.Ingress != nil {
+			ingress := v1alpha1.IngressSpec(*networking.Ingress)
+			dst.Spec.Ingress = &ingress
+		}
+		dst.Spec.Route = convertRouteTo(networking.Route)
+	}
+
+	source := src.Spec.Image.Source
+	healthCheck := convertHealthCheckTo(src.Spec.Image.HealthCheck)
+	switch source.Type {
+	case ImageSourceImage:
+		dst.Spec.WebImage = &v1alpha1.WebImageSpec{
+			ApplicationImage:     source.Image,
+			WebServerHealthCheck: healthCheck,
+		}
+	case ImageSourceImageStream:
+		if source.ImageStream == nil {
+			return fmt.Errorf("spec.image.source.imageStream is required for the %s source type", source.Type)
+		}
+		dst.Spec.WebImageStream = &v1alpha1.WebImageStreamSpec{
+			ImageStreamName:      source.ImageStream.Name,
+			ImageStreamNamespace: source.ImageStream.Namespace,
+			WebServerHealthCheck: healthCheck,
+		}
+	case ImageSourceGitBuild:
+		gitBuild := source.GitBuild
+		if gitBuild == nil {
+			return fmt.Errorf("spec.image.source.gitBuild is required for the %s source type", source.Type)
+		}
+		if builderPod := gitBuild.BuilderPod; builderPod != nil {
+			webApp := &v1alpha1.WebAppSpec{
+				Name:                       builderPod.WebAppName,
+				SourceRepositoryURL:        gitBuild.Repository.URL,
+				SourceRepositoryRef:        gitBuild.Repository.Ref,
+				SourceRepositoryContextDir: gitBuild.Repository.ContextDir,
+				DeployPath:                 builderPod.DeployPath,
+				ApplicationSizeLimit:       builderPod.ApplicationSizeLimit,
+			}
+			if builderPod.BuilderImage != "" || builderPod.ApplicationBuildScript != "" || builderPod.Resources != nil {
+				webApp.Builder = &v1alpha1.BuilderSpec{
+					Image:                  builderPod.BuilderImage,
+					ApplicationBuildScript: builderPod.ApplicationBuildScript,
+					Resources:              builderPod.Resources,
+				}
+			}
+			dst.Spec.WebImage = &v1alpha1.WebImageSpec{
+				ApplicationImage:     builderPod.ApplicationImage,
+				WebApp:               webApp,
+				WebServerHealthCheck: healthCheck,
+			}
+		} else if s2i := gitBuild.S2I; s2i != nil {
+			webSources := &v1alpha1.WebSourcesSpec{
+				SourceRepositoryURL: gitBuild.Repository.URL,
+				SourceRepositoryRef: gitBuild.Repository.Ref,
+				ContextDir:          gitBuild.Repository.ContextDir,
+			}
+			if s2i.MavenMirrorURL != "" || s2i.ArtifactDir != "" || s2i.GenericWebhookSecret != "" || s2i.GithubWebhookSecret != "" || s2i.Resources != nil {
+				webSources.WebSourcesParams = &v1alpha1.WebSourcesParamsSpec{
+					MavenMirrorURL:       s2i.MavenMirrorURL,
+					ArtifactDir:          s2i.ArtifactDir,
+					GenericWebhookSecret: s2i.GenericWebhookSecret,
+					GithubWebhookSecret:  s2i.GithubWebhookSecret,
+					Resources:            s2i.Resources,
+				}
+			}
+			dst.Spec.WebImageStream = &v1alpha1.WebImageStreamSpec{
+				ImageStreamName:      s2i.ImageStream.Name,
+				ImageStreamNamespace: s2i.ImageStream.Namespace,
+				WebSources:           webSources,
+				WebServerHealthCheck: healthCheck,
+			}
+		} else {
+			return fmt.Errorf("one of spec.image.source.gitBuild.builderPod or spec.image.source.gitBuild.s2i is required")
+		}
+	default:
+		return fmt.Errorf("unknown image source type %q", source.Type)
+	}
+
+	dst.Status = v1alpha1.WebServerStatus{
+		Replicas:           src.Status.Replicas,
+		Hosts:              src.Status.Hosts,
+		Selector:           src.Status.Selector,
+		ScalingdownPods:    src.Status.ScalingdownPods,
+		ObservedGeneration: src.Status.ObservedGeneration,
+		LastKnownGoodImage: src.Status.LastKnownGoodImage,
+		RolledBackImage:    src.Status.RolledBackImage,
+	}
+	for _, pod := range src.Status.Pods {
+		dst.Status.Pods = append(dst.Status.Pods, v1alpha1.PodStatus(pod))
+	}
+	for _, condition := range src.Status.Conditions {
+		dst.Status.Conditions = append(dst.Status.Conditions, v1alpha1.WebServerCondition{
+			Type:               v1alpha1.WebServerConditionType(condition.Type),
+			Status:             condition.Status,
+			ObservedGeneration: condition.ObservedGeneration,
+			LastTransitionTime: condition.LastTransitionTime,
+			Reason:             condition.Reason,
+			Message:            condition.Message,
+		})
+	}
+	if src.Status.BlueGreen != nil {
+		blueGreen := v1alpha1.BlueGreenStatus(*src.Status.BlueGreen)
+		dst.Status.BlueGreen = &blueGreen
+	}
+	if src.Status.Canary != nil {
+		canary := v1alpha1.CanaryStatus(*src.Status.Canary)
+		dst.Status.Canary = &canary
+	}
+	return nil
+}
+
+// ConvertFrom converts a WebServer of the hub version (v1alpha1) to this version
+func (dst *WebServer) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1alpha1.WebServer)
+	dst.ObjectMeta = src.ObjectMeta
+
+	dst.Spec = WebServerSpec{
+		ApplicationName: src.Spec.ApplicationName,
+		Replicas:        src.Spec.Replicas,
+		Autoscaling:     convertAutoscalingFrom(src.Spec.Autoscaling),
+		Resources:       src.Spec.Resources,
+		Env:             src.Spec.Env,
+		EnvFrom:         src.Spec.EnvFrom,
+		Volumes:         src.Spec.Volumes,
+		VolumeMounts:    src.Spec.VolumeMounts,
+		InitContainers:  src.Spec.InitContainers,
+		Sidecars:        src.Spec.Sidecars,
+	}
+	dst.Spec.UpdateStrategy = convertUpdateStrategyFrom(src.Spec.UpdateStrategy)
+	if src.Spec.UseSessionClustering || src.Spec.SessionClustering != nil || src.Spec.SessionStore != nil || src.Spec.SessionDraining != nil || src.Spec.CatalinaBase != "" || src.Spec.TLS != nil ||
+		src.Spec.JVMHeap != nil || src.Spec.CatalinaOpts != "" || src.Spec.JavaOpts != "" {
+		dst.Spec.Tomcat = &TomcatSpec{
+			UseSessionClustering: src.Spec.UseSessionClustering,
+			SessionClustering:    convertSessionClusteringFrom(src.Spec.SessionClustering),
+			SessionStore:         convertSessionStoreFrom(src.Spec.SessionStore),
+			CatalinaBase:         src.Spec.CatalinaBase,
+			TLS:                  convertTLSFrom(src.Spec.TLS),
+			CatalinaOpts:         src.Spec.CatalinaOpts,
+			JavaOpts:             src.Spec.JavaOpts,
+		}
+		if src.Spec.SessionDraining != nil {
+			sessionDraining := SessionDrainingSpec(*src.Spec.SessionDraining)
+			dst.Spec.Tomcat.SessionDraining = &sessionDraining
+		}
+		if src.Spec.JVMHeap != nil {
+			jvmHeap := JVMHeapSpec(*src.Spec.JVMHeap)
+			dst.Spec.Tomcat.JVMHeap = &jvmHeap
+		}
+	}
+	if src.Spec.Ingress != nil || src.Spec.Route != nil {
+		dst.Spec.Networking = &NetworkingSpec{
+			Route: convertRouteFrom(src.Spec.Route),
+		}
+		if src.Spec.Ingress != nil {
+			ingress := IngressSpec(*src.Spec.Ingress)
+			dst.Spec.Networking.Ingress = &ingress
+		}
+	}
+
+	if webImage := src.Spec.WebImage; webImage != nil {
+		dst.Spec.Image.HealthCheck = convertHealthCheckFrom(webImage.WebServerHealthCheck)
+		if webApp := webImage.WebApp; webApp != nil {
+			builderPod := &BuilderPodSpec{
+				ApplicationImage:     webImage.ApplicationImage,
+				WebAppName:           webApp.Name,
+				DeployPath:           webApp.DeployPath,
+				ApplicationSizeLimit: webApp.ApplicationSizeLimit,
+			}
+			if webApp.Builder != nil {
+				builderPod.BuilderImage = webApp.Builder.Image
+				builderPod.ApplicationBuildScript = webApp.Builder.ApplicationBuildScript
+				builderPod.Resources = webApp.Builder.Resources
+			}
+			dst.Spec.Image.Source = ImageSourceSpec{
+				Type: ImageSourceGitBuild,
+				GitBuild: &GitBuildSpec{
+					Repository: GitRepositorySpec{
+						URL:        webApp.SourceRepositoryURL,
+						Ref:        webApp.SourceRepositoryRef,
+						ContextDir: webApp.SourceRepositoryContextDir,
+					},
+					BuilderPod: builderPod,
+				},
+			}
+		} else {
+			dst.Spec.Image.Source = ImageSourceSpec{
+				Type:  ImageSourceImage,
+				Image: webImage.ApplicationImage,
+			}
+		}
+	} else if webImageStream := src.Spec.WebImageStream; webImageStream != nil {
+		dst.Spec.Image.HealthCheck = convertHealthCheckFrom(webImageStream.WebServerHealthCheck)
+		imageStream := ImageStreamReference{
+			Name:      webImageStream.ImageStreamName,
+			Namespace: webImageStream.ImageStreamNamespace,
+		}
+		if webSources := webImageStream.WebSources; webSources != nil {
+			s2i := &S2ISpec{
+				ImageStream: imageStream,
+			}
+			if params := webSources.WebSourcesParams; params != nil {
+				s2i.MavenMirrorURL = params.MavenMirrorURL
+				s2i.ArtifactDir = params.ArtifactDir
+				s2i.GenericWebhookSecret = params.GenericWebhookSecret
+				s2i.GithubWebhookSecret = params.GithubWebhookSecret
+				s2i.Resources = params.Resources
+			}
+			dst.Spec.Image.Source = ImageSourceSpec{
+				Type: ImageSourceGitBuild,
+				GitBuild: &GitBuildSpec{
+					Repository: GitRepositorySpec{
+						URL:        webSources.SourceRepositoryURL,
+						Ref:        webSources.SourceRepositoryRef,
+						ContextDir: webSources.ContextDir,
+					},
+					S2I: s2i,
+				},
+			}
+		} else {
+			dst.Spec.Image.Source = ImageSourceSpec{
+				Type:        ImageSourceImageStream,
+				ImageStream: &imageStream,
+			}
+		}
+	}
+
+	dst.Status = WebServerStatus{
+		Replicas:           src.Status.Replicas,
+		Hosts:              src.Status.Hosts,
+		Selector:           src.Status.Selector,
+		ScalingdownPods:    src.Status.ScalingdownPods,
+		ObservedGeneration: src.Status.ObservedGeneration,
+		LastKnownGoodImage: src.Status.LastKnownGoodImage,
+		RolledBackImage:    src.Status.RolledBackImage,
+	}
+	for _, pod := range src.Status.Pods {
+		dst.Status.Pods = append(dst.Status.Pods, PodStatus(pod))
+	}
+	for _, condition := range src.Status.Conditions {
+		dst.Status.Conditions = append(dst.Status.Conditions, WebServerCondition{
+			Type:               WebServerConditionType(condition.Type),
+			Status:             condition.Status,
+			ObservedGeneration: condition.ObservedGeneration,
+			LastTransitionTime: condition.LastTransitionTime,
+			Reason:             condition.Reason,
+			Message:            condition.Message,
+		})
+	}
+	if src.Status.BlueGreen != nil {
+		blueGreen := BlueGreenStatus(*src.Status.BlueGreen)
+		dst.Status.BlueGreen = &blueGreen
+	}
+	if src.Status.Canary != nil {
+		canary := CanaryStatus(*src.Status.Canary)
+		dst.Status.Canary = &canary
+	}
+	return nil
+}
+
+func convertHealthCheckTo(healthCheck *HealthCheckSpec) *v1alpha1.WebServerHealthCheckSpec {
+	if healthCheck == nil {
+		return nil
+	}
+	return &v1alpha1.WebServerHealthCheckSpec{
+		ServerReadinessScript: healthCheck.ReadinessScript,
+		ServerLivenessScript:  healthCheck.LivenessScript,
+		ReadinessProbe:        convertProbeTo(healthCheck.ReadinessProbe),
+		LivenessProbe:         convertProbeTo(healthCheck.LivenessProbe),
+		StartupProbe:          convertProbeTo(healthCheck.StartupProbe),
+	}
+}
+
+func convertHealthCheckFrom(healthCheck *v1alpha1.WebServerHealthCheckSpec) *HealthCheckSpec {
+	if healthCheck == nil {
+		return nil
+	}
+	return &HealthCheckSpec{
+		ReadinessScript: healthCheck.ServerReadinessScript,
+		LivenessScript:  healthCheck.ServerLivenessScript,
+		ReadinessProbe:  convertProbeFrom(healthCheck.ReadinessProbe),
+		LivenessProbe:   convertProbeFrom(healthCheck.LivenessProbe),
+		StartupProbe:    convertProbeFrom(healthCheck.StartupProbe),
+	}
+}
+
+func convertProbeTo(probe *ProbeSpec) *v1alpha1.ProbeSpec {
+	if probe == nil {
+		return nil
+	}
+	converted := &v1alpha1.ProbeSpec{
+		InitialDelaySeconds: probe.InitialDelaySeconds,
+		PeriodSeconds:       probe.PeriodSeconds,
+		TimeoutSeconds:      probe.TimeoutSeconds,
+		FailureThreshold:    probe.FailureThreshold,
+	}
+	if httpGet := probe.HTTPGet; httpGet != nil {
+		converted.HTTPGet = &v1alpha1.HTTPGetProbeSpec{
+			Path:   httpGet.Path,
+			Port:   httpGet.Port,
+			Scheme: httpGet.Scheme,
+		}
+		for _, header := range httpGet.HTTPHeaders {
+			converted.HTTPGet.HTTPHeaders = append(converted.HTTPGet.HTTPHeaders, v1alpha1.HTTPHeaderSpec(header))
+		}
+	}
+	if probe.TCPSocket != nil {
+		tcpSocket := v1alpha1.TCPSocketProbeSpec(*probe.TCPSocket)
+		converted.TCPSocket = &tcpSocket
+	}
+	if probe.Exec != nil {
+		exec := v1alpha1.ExecProbeSpec(*probe.Exec)
+		converted.Exec = &exec
+	}
+	return converted
+}
+
+func convertProbeFrom(probe *v1alpha1.ProbeSpec) *ProbeSpec {
+	if probe == nil {
+		return nil
+	}
+	converted := &ProbeSpec{
+		InitialDelaySeconds: probe.InitialDelaySeconds,
+		PeriodSeconds:       probe.PeriodSeconds,
+		TimeoutSeconds:      probe.TimeoutSeconds,
+		FailureThreshold:    probe.FailureThreshold,
+	}
+	if httpGet := probe.HTTPGet; httpGet != nil {
+		converted.HTTPGet = &HTTPGetProbeSpec{
+			Path:   httpGet.Path,
+			Port:   httpGet.Port,
+			Scheme: httpGet.Scheme,
+		}
+		for _, header := range httpGet.HTTPHeaders {
+			converted.HTTPGet.HTTPHeaders = append(converted.HTTPGet.HTTPHeaders, HTTPHeaderSpec(header))
+		}
+	}
+	if probe.TCPSocket != nil {
+		tcpSocket := TCPSocketProbeSpec(*probe.TCPSocket)
+		converted.TCPSocket = &tcpSocket
+	}
+	if probe.Exec != nil {
+		exec := ExecProbeSpec(*probe.Exec)
+		converted.Exec = &exec
+	}
+	return converted
+}
+
+func convertAutoscalingTo(autoscaling *AutoscalingSpec) *v1alpha1.AutoscalingSpec {
+	if autoscaling == nil {
+		return nil
+	}
+	converted := &v1alpha1.AutoscalingSpec{
+		MinReplicas:                       autoscaling.MinReplicas,
+		MaxReplicas:                       autoscaling.MaxReplicas,
+		TargetCPUUtilizationPercentage:    autoscaling.TargetCPUUtilizationPercentage,
+		TargetMemoryUtilizationPercentage: autoscaling.TargetMemoryUtilizationPercentage,
+	}
+	for _, metric := range autoscaling.CustomMetrics {
+		converted.CustomMetrics = append(converted.CustomMetrics, v1alpha1.CustomMetricSpec(metric))
+	}
+	return converted
+}
+
+func convertAutoscalingFrom(autoscaling *v1alpha1.AutoscalingSpec) *AutoscalingSpec {
+	if autoscaling == nil {
+		return nil
+	}
+	converted := &AutoscalingSpec{
+		MinReplicas:                       autoscaling.MinReplicas,
+		MaxReplicas:                       autoscaling.MaxReplicas,
+		TargetCPUUtilizationPercentage:    autoscaling.TargetCPUUtilizationPercentage,
+		TargetMemoryUtilizationPercentage: autoscaling.TargetMemoryUtilizationPercentage,
+	}
+	for _, metric := range autoscaling.CustomMetrics {
+		converted.CustomMetrics = append(converted.CustomMetrics, CustomMetricSpec(metric))
+	}
+	return converted
+}
+
+func convertUpdateStrategyTo(updateStrategy *UpdateStrategySpec) *v1alpha1.UpdateStrategySpec {
+	if updateStrategy == nil {
+		return nil
+	}
+	converted := &v1alpha1.UpdateStrategySpec{
+		Type:              updateStrategy.Type,
+		MaxSurge:          updateStrategy.MaxSurge,
+		MaxUnavailable:    updateStrategy.MaxUnavailable,
+		MinReadySeconds:   updateStrategy.MinReadySeconds,
+		AutomaticRollback: updateStrategy.AutomaticRollback,
+	}
+	if blueGreen := updateStrategy.BlueGreen; blueGreen != nil {
+		converted.BlueGreen = &v1alpha1.BlueGreenSpec{
+			ManualPromotion: blueGreen.ManualPromotion,
+		}
+		for _, smokeTest := range blueGreen.SmokeTests {
+			converted.BlueGreen.SmokeTests = append(converted.BlueGreen.SmokeTests, v1alpha1.SmokeTestSpec(smokeTest))
+		}
+	}
+	if canary := updateStrategy.Canary; canary != nil {
+		converted.Canary = &v1alpha1.CanarySpec{
+			MaxRestarts: canary.MaxRestarts,
+		}
+		for _, step := range canary.Steps {
+			converted.Canary.Steps = append(converted.Canary.Steps, v1alpha1.CanaryStepSpec(step))
+		}
+	}
+	return converted
+}
+
+func convertUpdateStrategyFrom(updateStrategy *v1alpha1.UpdateStrategySpec) *UpdateStrategySpec {
+	if updateStrategy == nil {
+		return nil
+	}
+	converted := &UpdateStrategySpec{
+		Type:              updateStrategy.Type,
+		MaxSurge:          updateStrategy.MaxSurge,
+		MaxUnavailable:    updateStrategy.MaxUnavailable,
+		MinReadySeconds:   updateStrategy.MinReadySeconds,
+		AutomaticRollback: updateStrategy.AutomaticRollback,
+	}
+	if blueGreen := updateStrategy.BlueGreen; blueGreen != nil {
+		converted.BlueGreen = &BlueGreenSpec{
+			ManualPromotion: blueGreen.ManualPromotion,
+		}
+		for _, smokeTest := range blueGreen.SmokeTests {
+			converted.BlueGreen.SmokeTests = append(converted.BlueGreen.SmokeTests, SmokeTestSpec(smokeTest))
+		}
+	}
+	if canary := updateStrategy.Canary; canary != nil {
+		converted.Canary = &CanarySpec{
+			MaxRestarts: canary.MaxRestarts,
+		}
+		for _, step := range canary.Steps {
+			converted.Canary.Steps = append(converted.Canary.Steps, CanaryStepSpec(step))
+		}
+	}
+	return converted
+}
+
+func convertRouteTo(route *RouteSpec) *v1alpha1.RouteSpec {
+	if route == nil {
+		return nil
+	}
+	converted := &v1alpha1.RouteSpec{
+		Host:        route.Host,
+		Path:        route.Path,
+		Timeout:     route.Timeout,
+		CookieName:  route.CookieName,
+		Annotations: route.Annotations,
+	}
+	if route.TLS != nil {
+		tls := v1alpha1.RouteTLSSpec(*route.TLS)
+		converted.TLS = &tls
+	}
+	return converted
+}
+
+func convertRouteFrom(route *v1alpha1.RouteSpec) *RouteSpec {
+	if route == nil {
+		return nil
+	}
+	converted := &RouteSpec{
+		Host:        route.Host,
+		Path:        route.Path,
+		Timeout:     route.Timeout,
+		CookieName:  route.CookieName,
+		Annotations: route.Annotations,
+	}
+	if route.TLS != nil {
+		tls := RouteTLSSpec(*route.TLS)
+		converted.TLS = &tls
+	}
+	return converted
+}
+
+func convertTLSTo(tls *TLSSpec) *v1alpha1.TLSSpec {
+	if tls == nil {
+		return nil
+	}
+	converted := &v1alpha1.TLSSpec{
+		CertificateSecretName: tls.CertificateSecretName,
+	}
+	if tls.Keystore != nil {
+		keystore := v1alpha1.KeystoreSpec(*tls.Keystore)
+		converted.Keystore = &keystore
+	}
+	if tls.CertManager != nil {
+		certManager := v1alpha1.CertManagerSpec(*tls.CertManager)
+		converted.CertManager = &certManager
+	}
+	return converted
+}
+
+func convertTLSFrom(tls *v1alpha1.TLSSpec) *TLSSpec {
+	if tls == nil {
+		return nil
+	}
+	converted := &TLSSpec{
+		CertificateSecretName: tls.CertificateSecretName,
+	}
+	if tls.Keystore != nil {
+		keystore := KeystoreSpec(*tls.Keystore)
+		converted.Keystore = &keystore
+	}
+	if tls.CertManager != nil {
+		certManager := CertManagerSpec(*tls.CertManager)
+		converted.CertManager = &certManager
+	}
+	return converted
+}
+
+func convertSessionClusteringTo(sessionClustering *SessionClusteringSpec) *v1alpha1.SessionClusteringSpec {
+	if sessionClustering == nil {
+		return nil
+	}
+	converted := &v1alpha1.SessionClusteringSpec{
+		ManagerType:                  sessionClustering.ManagerType,
+		ChannelSendOptions:           sessionClustering.ChannelSendOptions,
+		MembershipProvider:           sessionClustering.MembershipProvider,
+		ExpireSessionsOnShutdown:     sessionClustering.ExpireSessionsOnShutdown,
+		NotifyListenersOnReplication: sessionClustering.NotifyListenersOnReplication,
+		ReplicationFilter:            sessionClustering.ReplicationFilter,
+	}
+	if sessionClustering.Membership != nil {
+		membership := v1alpha1.MembershipSpec(*sessionClustering.Membership)
+		converted.Membership = &membership
+	}
+	return converted
+}
+
+func convertSessionClusteringFrom(sessionClustering *v1alpha1.SessionClusteringSpec) *SessionClusteringSpec {
+	if sessionClustering == nil {
+		return nil
+	}
+	converted := &SessionClusteringSpec{
+		ManagerType:                  sessionClustering.ManagerType,
+		ChannelSendOptions:           sessionClustering.ChannelSendOptions,
+		MembershipProvider:           sessionClustering.MembershipProvider,
+		ExpireSessionsOnShutdown:     sessionClustering.ExpireSessionsOnShutdown,
+		NotifyListenersOnReplication: sessionClustering.NotifyListenersOnReplication,
+		ReplicationFilter:            sessionClustering.ReplicationFilter,
+	}
+	if sessionClustering.Membership != nil {
+		membership := MembershipSpec(*sessionClustering.Membership)
+		converted.Membership = &membership
+	}
+	return converted
+}
+
+func convertSessionStoreTo(sessionStore *SessionStoreSpec) *v1alpha1.SessionStoreSpec {
+	if sessionStore == nil {
+		return nil
+	}
+	converted := &v1alpha1.SessionStoreSpec{
+		SecretName: sessionStore.SecretName,
+	}
+	if sessionStore.Redis != nil {
+		redis := v1alpha1.RedisSessionStoreSpec(*sessionStore.Redis)
+		converted.Redis = &redis
+	}
+	if sessionStore.JDBC != nil {
+		jdbc := v1alpha1.JDBCSessionStoreSpec(*sessionStore.JDBC)
+		converted.JDBC = &jdbc
+	}
+	return converted
+}
+
+func convertSessionStoreFrom(sessionStore *v1alpha1.SessionStoreSpec) *SessionStoreSpec {
+	if sessionStore == nil {
+		return nil
+	}
+	converted := &SessionStoreSpec{
+		SecretName: sessionStore.SecretName,
+	}
+	if sessionStore.Redis != nil {
+		redis := RedisSessionStoreSpec(*sessionStore.Redis)
+		converted.Redis = &redis
+	}
+	if sessionStore.JDBC != nil {
+		jdbc := JDBCSessionStoreSpec(*sessionStore.JDBC)
+		converted.JDBC = &jdbc
+	}
+	return converted
+}
@@ -0,0 +1,399 @@
+package v1beta1
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func objectMeta() metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:       "example-webserver",
+		Namespace:  "jws",
+		Generation: 3,
+		Labels:     map[string]string{"app": "example"},
+	}
+}
+
+func hubWebServers() map[string]*v1alpha1.WebServer {
+	minReplicas := int32(2)
+	targetCPUUtilization := int32(80)
+	notifyListenersOnReplication := false
+	expirationTime := int32(10000)
+	maxSurge := intstr.FromInt(1)
+	maxUnavailable := intstr.FromString("0%")
+	stepStartTime := metav1.Unix(1600000000, 0)
+	probePort := intstr.FromString("http")
+	buildResources := &corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")},
+	}
+	return map[string]*v1alpha1.WebServer{
+		"ApplicationImage": {
+			ObjectMeta: objectMeta(),
+			Spec: v1alpha1.WebServerSpec{
+				ApplicationName: "example",
+				Replicas:        2,
+				Ingress: &v1alpha1.IngressSpec{
+					Host:             "example.com",
+					Path:             "/example",
+					IngressClassName: "nginx",
+					Annotations:      map[string]string{"nginx.ingress.kubernetes.io/ssl-redirect": "true"},
+					TLSSecretName:    "example-tls",
+				},
+				SessionStore: &v1alpha1.SessionStoreSpec{
+					SecretName: "example-redis",
+					Redis:      &v1alpha1.RedisSessionStoreSpec{KeyPrefix: "example:"},
+				},
+				TLS: &v1alpha1.TLSSpec{
+					CertificateSecretName: "example-tls",
+					Keystore: &v1alpha1.KeystoreSpec{
+						Key:  "keystore.p12",
+						Type: "PKCS12",
+						PasswordSecretKeyRef: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "example-keystore"},
+							Key:                  "password",
+						},
+					},
+				},
+				UpdateStrategy: &v1alpha1.UpdateStrategySpec{
+					Type:              "RollingUpdate",
+					MaxSurge:          &maxSurge,
+					MaxUnavailable:    &maxUnavailable,
+					MinReadySeconds:   10,
+					AutomaticRollback: true,
+				},
+				Resources: &corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("500m"),
+						corev1.ResourceMemory: resource.MustParse("512Mi"),
+					},
+					Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+				},
+				JVMHeap:  &v1alpha1.JVMHeapSpec{Sizing: "Xmx", Percentage: 60},
+				JavaOpts: "-XX:+UseG1GC",
+				WebImage: &v1alpha1.WebImageSpec{
+					ApplicationImage: "quay.io/example/tomcat:latest",
+					WebServerHealthCheck: &v1alpha1.WebServerHealthCheckSpec{
+						ServerReadinessScript: "/bin/true",
+						ServerLivenessScript:  "/bin/true",
+					},
+				},
+			},
+			Status: v1alpha1.WebServerStatus{
+				Replicas:           2,
+				Hosts:              []string{"example.apps.cluster"},
+				Selector:           "WebServer=example-webserver,deploymentConfig=example",
+				ObservedGeneration: 3,
+				LastKnownGoodImage: "quay.io/example/tomcat@sha256:4d2d9e3c1e2f0a5b6c7d8e9f00112233445566778899aabbccddeeff00112233",
+				RolledBackImage:    "quay.io/example/tomcat:latest",
+				Pods: []v1alpha1.PodStatus{
+					{Name: "example-1", PodIP: "10.0.0.1", State: v1alpha1.PodStateActive},
+				},
+				Conditions: []v1alpha1.WebServerCondition{
+					{
+						Type:               v1alpha1.WebServerAvailable,
+						Status:             corev1.ConditionTrue,
+						ObservedGeneration: 3,
+						LastTransitionTime: metav1.Unix(1600000000, 0),
+						Reason:             "ReplicasReady",
+						Message:            "2 of 2 replicas are ready",
+					},
+				},
+			},
+		},
+		"WebApp": {
+			ObjectMeta: objectMeta(),
+			Spec: v1alpha1.WebServerSpec{
+				ApplicationName:      "example",
+				Replicas:             1,
+				UseSessionClustering: true,
+				CatalinaBase:         "/opt/jws-5.4/tomcat",
+				CatalinaOpts:         "-Dexample.mode=$(EXAMPLE_MODE)",
+				Env: []corev1.EnvVar{
+					{Name: "EXAMPLE_MODE", Value: "production"},
+					{
+						Name: "EXAMPLE_PASSWORD",
+						ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "example-credentials"},
+							Key:                  "password",
+						}},
+					},
+				},
+				EnvFrom: []corev1.EnvFromSource{{
+					Prefix:       "EXAMPLE_",
+					ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "example-config"}},
+				}},
+				Volumes: []corev1.Volume{
+					{Name: "libs", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+					{Name: "logs", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+				},
+				VolumeMounts: []corev1.VolumeMount{
+					{Name: "libs", MountPath: "/opt/jws-5.4/tomcat/lib/ext", ReadOnly: true},
+					{Name: "logs", MountPath: "/opt/jws-5.4/tomcat/logs"},
+				},
+				InitContainers: []corev1.Container{{
+					Name:         "fetch-libs",
+					Image:        "quay.io/example/fetch-libs:1.0",
+					VolumeMounts: []corev1.VolumeMount{{Name: "libs", MountPath: "/libs"}},
+				}},
+				Sidecars: []corev1.Container{{
+					Name:         "log-shipper",
+					Image:        "quay.io/example/log-shipper:1.0",
+					VolumeMounts: []corev1.VolumeMount{{Name: "logs", MountPath: "/logs", ReadOnly: true}},
+				}},
+				Route: &v1alpha1.RouteSpec{
+					Host:        "example.apps.cluster",
+					Timeout:     "60s",
+					CookieName:  "JWSROUTE",
+					Annotations: map[string]string{"haproxy.router.openshift.io/balance": "roundrobin"},
+					TLS: &v1alpha1.RouteTLSSpec{
+						Termination:                   "edge",
+						InsecureEdgeTerminationPolicy: "Redirect",
+						CertificateSecretName:         "example-route-tls",
+					},
+				},
+				UpdateStrategy: &v1alpha1.UpdateStrategySpec{
+					Type: "BlueGreen",
+					BlueGreen: &v1alpha1.BlueGreenSpec{
+						SmokeTests:      []v1alpha1.SmokeTestSpec{{Path: "/health", ExpectedStatus: 200}},
+						ManualPromotion: true,
+					},
+				},
+				WebImage: &v1alpha1.WebImageSpec{
+					ApplicationImage: "quay.io/example/tomcat:latest",
+					WebApp: &v1alpha1.WebAppSpec{
+						Name:                       "ROOT",
+						SourceRepositoryURL:        "https://github.com/example/demo-webapp.git",
+						SourceRepositoryRef:        "main",
+						SourceRepositoryContextDir: "app",
+						DeployPath:                 "/deployments/",
+						ApplicationSizeLimit:       "1Gi",
+						Builder: &v1alpha1.BuilderSpec{
+							Image:                  "quay.io/example/builder:latest",
+							ApplicationBuildScript: "mvn install",
+							Resources:              buildResources,
+						},
+					},
+				},
+			},
+			Status: v1alpha1.WebServerStatus{
+				Replicas: 1,
+				BlueGreen: &v1alpha1.BlueGreenStatus{
+					ActiveColor:    "blue",
+					CandidateColor: "green",
+					CandidatePhase: "WaitingForPromotion",
+				},
+			},
+		},
+		"ImageStream": {
+			ObjectMeta: objectMeta(),
+			Spec: v1alpha1.WebServerSpec{
+				ApplicationName: "example",
+				Replicas:        1,
+				Autoscaling: &v1alpha1.AutoscalingSpec{
+					MinReplicas:                    &minReplicas,
+					MaxReplicas:                    5,
+					TargetCPUUtilizationPercentage: &targetCPUUtilization,
+					CustomMetrics: []v1alpha1.CustomMetricSpec{
+						{Name: "http_requests", TargetAverageValue: resource.MustParse("100")},
+					},
+				},
+				SessionStore: &v1alpha1.SessionStoreSpec{
+					SecretName: "example-database",
+					JDBC: &v1alpha1.JDBCSessionStoreSpec{
+						DriverName:   "org.postgresql.Driver",
+						SessionTable: "tomcat_sessions",
+					},
+				},
+				SessionDraining: &v1alpha1.SessionDrainingSpec{TimeoutSeconds: 600},
+				WebImageStream: &v1alpha1.WebImageStreamSpec{
+					ImageStreamName:      "jboss-webserver54-openjdk8-tomcat9-ubi8-openshift",
+					ImageStreamNamespace: "openshift",
+				},
+			},
+		},
+		"WebSources": {
+			ObjectMeta: objectMeta(),
+			Spec: v1alpha1.WebServerSpec{
+				ApplicationName: "example",
+				Replicas:        1,
+				SessionClustering: &v1alpha1.SessionClusteringSpec{
+					ManagerType:                  "BackupManager",
+					ChannelSendOptions:           8,
+					MembershipProvider:           "DNSPing",
+					Membership:                   &v1alpha1.MembershipSpec{ExpirationTime: &expirationTime},
+					ExpireSessionsOnShutdown:     true,
+					NotifyListenersOnReplication: &notifyListenersOnReplication,
+					ReplicationFilter:            ".*\\.css",
+				},
+				TLS: &v1alpha1.TLSSpec{
+					CertificateSecretName: "example-tls",
+					CertManager: &v1alpha1.CertManagerSpec{
+						IssuerName:  "ca-issuer",
+						IssuerKind:  "ClusterIssuer",
+						DNSNames:    []string{"example.com"},
+						Duration:    "2160h",
+						RenewBefore: "360h",
+					},
+				},
+				WebImageStream: &v1alpha1.WebImageStreamSpec{
+					ImageStreamName:      "jboss-webserver54-openjdk8-tomcat9-ubi8-openshift",
+					ImageStreamNamespace: "openshift",
+					WebSources: &v1alpha1.WebSourcesSpec{
+						SourceRepositoryURL: "https://github.com/example/demo-webapp.git",
+						SourceRepositoryRef: "main",
+						ContextDir:          "/",
+						WebSourcesParams: &v1alpha1.WebSourcesParamsSpec{
+							MavenMirrorURL:       "https://repo.example.com/maven",
+							ArtifactDir:          "target",
+							GenericWebhookSecret: "generic",
+							GithubWebhookSecret:  "github",
+							Resources:            buildResources,
+						},
+					},
+					WebServerHealthCheck: &v1alpha1.WebServerHealthCheckSpec{
+						ServerReadinessScript: "/bin/true",
+					},
+				},
+			},
+		},
+		"Canary": {
+			ObjectMeta: objectMeta(),
+			Spec: v1alpha1.WebServerSpec{
+				ApplicationName: "example",
+				Replicas:        2,
+				UpdateStrategy: &v1alpha1.UpdateStrategySpec{
+					Type: "Canary",
+					Canary: &v1alpha1.CanarySpec{
+						Steps:       []v1alpha1.CanaryStepSpec{{Weight: 10, PauseSeconds: 300}, {Weight: 100}},
+						MaxRestarts: 2,
+					},
+				},
+				WebImage: &v1alpha1.WebImageSpec{
+					ApplicationImage: "quay.io/example/tomcat:2.0",
+					WebServerHealthCheck: &v1alpha1.WebServerHealthCheckSpec{
+						ReadinessProbe: &v1alpha1.ProbeSpec{
+							HTTPGet: &v1alpha1.HTTPGetProbeSpec{
+								Path:        "/ready",
+								Port:        &probePort,
+								Scheme:      "HTTP",
+								HTTPHeaders: []v1alpha1.HTTPHeaderSpec{{Name: "X-Probe", Value: "readiness"}},
+							},
+							PeriodSeconds:    5,
+							TimeoutSeconds:   2,
+							FailureThreshold: 3,
+						},
+						LivenessProbe: &v1alpha1.ProbeSpec{
+							TCPSocket:           &v1alpha1.TCPSocketProbeSpec{Port: &probePort},
+							InitialDelaySeconds: 10,
+						},
+						StartupProbe: &v1alpha1.ProbeSpec{
+							Exec:             &v1alpha1.ExecProbeSpec{Command: []string{"/bin/sh", "-c", "test -f /tmp/started"}},
+							FailureThreshold: 60,
+						},
+					},
+				},
+			},
+			Status: v1alpha1.WebServerStatus{
+				Replicas: 2,
+				BlueGreen: &v1alpha1.BlueGreenStatus{
+					ActiveColor:    "green",
+					CandidateColor: "blue",
+					CandidatePhase: "Verifying",
+				},
+				Canary: &v1alpha1.CanaryStatus{
+					Step:          1,
+					Weight:        10,
+					StepStartTime: &stepStartTime,
+				},
+			},
+		},
+	}
+}
+
+func TestHubRoundTrip(t *testing.T) {
+	for name, hub := range hubWebServers() {
+		t.Run(name, func(t *testing.T) {
+			spoke := &WebServer{}
+			if err := spoke.ConvertFrom(hub); err != nil {
+				t.Fatalf("ConvertFrom failed: %v", err)
+			}
+			result := &v1alpha1.WebServer{}
+			if err := spoke.ConvertTo(result); err != nil {
+				t.Fatalf("ConvertTo failed: %v", err)
+			}
+			if !reflect.DeepEqual(hub, result) {
+				t.Errorf("round trip changed the WebServer:\nexpected %+v\ngot      %+v", hub, result)
+			}
+		})
+	}
+}
+
+func TestSpokeRoundTrip(t *testing.T) {
+	for name, hub := range hubWebServers() {
+		t.Run(name, func(t *testing.T) {
+			spoke := &WebServer{}
+			if err := spoke.ConvertFrom(hub); err != nil {
+				t.Fatalf("ConvertFrom failed: %v", err)
+			}
+			intermediate := &v1alpha1.WebServer{}
+			if err := spoke.ConvertTo(intermediate); err != nil {
+				t.Fatalf("ConvertTo failed: %v", err)
+			}
+			result := &WebServer{}
+			if err := result.ConvertFrom(intermediate); err != nil {
+				t.Fatalf("ConvertFrom failed: %v", err)
+			}
+			if !reflect.DeepEqual(spoke, result) {
+				t.Errorf("round trip changed the WebServer:\nexpected %+v\ngot      %+v", spoke, result)
+			}
+		})
+	}
+}
+
+func TestConvertFromSourceType(t *testing.T) {
+	expected := map[string]ImageSourceType{
+		"ApplicationImage": ImageSourceImage,
+		"WebApp":           ImageSourceGitBuild,
+		"ImageStream":      ImageSourceImageStream,
+		"WebSources":       ImageSourceGitBuild,
+		"Canary":           ImageSourceImage,
+	}
+	for name, hub := range hubWebServers() {
+		spoke := &WebServer{}
+		if err := spoke.ConvertFrom(hub); err != nil {
+			t.Fatalf("%s: ConvertFrom failed: %v", name, err)
+		}
+		if spoke.Spec.Image.Source.Type != expected[name] {
+			t.Errorf("%s: expected source type %s, got %s", name, expected[name], spoke.Spec.Image.Source.Type)
+		}
+	}
+}
+
+func TestConvertToInvalidSource(t *testing.T) {
+	sources := map[string]ImageSourceSpec{
+		"MissingImageStream": {Type: ImageSourceImageStream},
+		"MissingGitBuild":    {Type: ImageSourceGitBuild},
+		"MissingBuilder":     {Type: ImageSourceGitBuild, GitBuild: &GitBuildSpec{}},
+		"UnknownType":        {Type: "Helm"},
+	}
+	for name, source := range sources {
+		spoke := &WebServer{
+			ObjectMeta: objectMeta(),
+			Spec: WebServerSpec{
+				ApplicationName: "example",
+				Replicas:        1,
+				Image:           ImageSpec{Source: source},
+			},
+		}
+		if err := spoke.ConvertTo(&v1alpha1.WebServer{}); err == nil {
+			t.Errorf("%s: expected ConvertTo to fail", name)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package webserver
+
+import (
+	"reflect"
+	"testing"
+
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// autoscaledWebServer returns a WebServer scaled between 2 and 10 replicas, labeled with labels its pods don't have
+func autoscaledWebServer(autoscaling webserversv1alpha1.AutoscalingSpec) *webserversv1alpha1.WebServer {
+	minReplicas := int32(2)
+	autoscaling.MinReplicas = &minReplicas
+	autoscaling.MaxReplicas = 10
+	return &webserversv1alpha1.WebServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "example-webserver", Namespace: "jws", Labels: map[string]string{"team": "web"}},
+		Spec: webserversv1alpha1.WebServerSpec{
+			ApplicationName: "example",
+			Replicas:        2,
+			WebImage:        &webserversv1alpha1.WebImageSpec{ApplicationImage: "quay.io/example/tomcat:1.0"},
+			Autoscaling:     &autoscaling,
+		},
+	}
+}
+
+func TestHorizontalPodAutoscalerForWebServer(t *testing.T) {
+	cpu := int32(60)
+	memory := int32(70)
+	defaultCPU := int32(80)
+	requests := resource.MustParse("100")
+	resourceMetric := func(name corev1.ResourceName, utilization *int32) autoscalingv2beta2.MetricSpec {
+		return autoscalingv2beta2.MetricSpec{
+			Type: autoscalingv2beta2.ResourceMetricSourceType,
+			Resource: &autoscalingv2beta2.ResourceMetricSource{
+				Name:   name,
+				Target: autoscalingv2beta2.MetricTarget{Type: autoscalingv2beta2.UtilizationMetricType, AverageUtilization: utilization},
+			},
+		}
+	}
+	tests := []struct {
+		name        string
+		autoscaling webserversv1alpha1.AutoscalingSpec
+		metrics     []autoscalingv2beta2.MetricSpec
+	}{
+		{
+			name:    "default",
+			metrics: []autoscalingv2beta2.MetricSpec{resourceMetric(corev1.ResourceCPU, &defaultCPU)},
+		},
+		{
+			name:        "CPU and memory",
+			autoscaling: webserversv1alpha1.AutoscalingSpec{TargetCPUUtilizationPercentage: &cpu, TargetMemoryUtilizationPercentage: &memory},
+			metrics:     []autoscalingv2beta2.MetricSpec{resourceMetric(corev1.ResourceCPU, &cpu), resourceMetric(corev1.ResourceMemory, &memory)},
+		},
+		{
+			name:        "custom metric",
+			autoscaling: webserversv1alpha1.AutoscalingSpec{CustomMetrics: []webserversv1alpha1.CustomMetricSpec{{Name: "http_requests", TargetAverageValue: requests}}},
+			metrics: []autoscalingv2beta2.MetricSpec{{
+				Type: autoscalingv2beta2.PodsMetricSourceType,
+				Pods: &autoscalingv2beta2.PodsMetricSource{
+					Metric: autoscalingv2beta2.MetricIdentifier{Name: "http_requests"},
+					Target: autoscalingv2beta2.MetricTarget{Type: autoscalingv2beta2.AverageValueMetricType, AverageValue: &requests},
+				},
+			}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := newTestReconciler(t)
+			webServer := autoscaledWebServer(test.autoscaling)
+			hpa := r.horizontalPodAutoscalerForWebServer(webServer)
+
+			// The WebServer is scaled through its scale subresource, the replicas of the Deployment follow it
+			scaleTargetRef := autoscalingv2beta2.CrossVersionObjectReference{APIVersion: "web.servers.org/v1alpha1", Kind: "WebServer", Name: "example-webserver"}
+			if hpa.Spec.ScaleTargetRef != scaleTargetRef {
+				t.Errorf("got %+v, expected %+v", hpa.Spec.ScaleTargetRef, scaleTargetRef)
+			}
+			if *hpa.Spec.MinReplicas != 2 || hpa.Spec.MaxReplicas != 10 {
+				t.Errorf("got %d to %d replicas, expected 2 to 10", *hpa.Spec.MinReplicas, hpa.Spec.MaxReplicas)
+			}
+			if !reflect.DeepEqual(hpa.Spec.Metrics, test.metrics) {
+				t.Errorf("got %+v, expected %+v", hpa.Spec.Metrics, test.metrics)
+			}
+			if !metav1.IsControlledBy(hpa, webServer) {
+				t.Error("the HorizontalPodAutoscaler isn't controlled by the WebServer")
+			}
+		})
+	}
+}
+
+func TestScaleSelectorForWebServer(t *testing.T) {
+	webServer := autoscaledWebServer(webserversv1alpha1.AutoscalingSpec{})
+	selector := scaleSelectorForWebServer(webServer)
+	if selector != "WebServer=example-webserver,deploymentConfig=example" {
+		t.Errorf("got %s, expected only the labels of the pods", selector)
+	}
+
+	// The HorizontalPodAutoscaler selects the pods of the WebServer with the selector
+	parsed, err := labels.Parse(selector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := podTemplateSpecForWebServer(webServer, "quay.io/example/tomcat:1.0", false)
+	if !parsed.Matches(labels.Set(template.Labels)) {
+		t.Errorf("the selector %s doesn't select the pods labeled %v", selector, template.Labels)
+	}
+}
@@ -0,0 +1,333 @@
+package webserver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+
+	kbappsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// colorLabel is the label of the Deployments of a blue/green update or of a canary release and of their pods,
+	// the Service selects the pods of the active color
+	colorLabel = "web.servers.org/color"
+	// blueGreenAnnotation is the annotation of the WebServer promoting or aborting the candidate of a blue/green update,
+	// the operator removes it once it is handled
+	blueGreenAnnotation = "web.servers.org/blue-green"
+	blueGreenPromote    = "promote"
+	blueGreenAbort      = "abort"
+	// The phases of the candidate of a blue/green update or of a canary release
+	candidateDeploying           = "Deploying"
+	candidateVerifying           = "Verifying"
+	candidateWaitingForPromotion = "WaitingForPromotion"
+	candidateAborted             = "Aborted"
+	candidateRolledBack          = "RolledBack"
+	// smokeTestsRetryDelay is the delay before the smoke tests of a candidate are sent again after a failure
+	smokeTestsRetryDelay = 10 * time.Second
+	// httpPort is the port of the HTTP connector of the pods
+	httpPort = 8080
+)
+
+// activeColor returns the color of the Deployment selected by the Service
+func activeColor(t *webserversv1alpha1.WebServer) string {
+	if t.Status.BlueGreen != nil && t.Status.BlueGreen.ActiveColor != "" {
+		return t.Status.BlueGreen.ActiveColor
+	}
+	return "blue"
+}
+
+// otherColor returns the color of the candidate when color is active
+func otherColor(color string) string {
+	if color == "blue" {
+		return "green"
+	}
+	return "blue"
+}
+
+// setDeploymentColor names the Deployment after the color and adds the color to its selector and to its pods
+func setDeploymentColor(t *webserversv1alpha1.WebServer, deployment *kbappsv1.Deployment, color string) {
+	deployment.Name = t.Spec.ApplicationName + "-" + color
+	deployment.Labels[colorLabel] = color
+	deployment.Spec.Selector.MatchLabels[colorLabel] = color
+	deployment.Spec.Template.Labels[colorLabel] = color
+	setPodTemplateHash(&deployment.Spec.Template)
+}
+
+// reconcileBlueGreen deploys the pod template of the WebServer with a candidate Deployment when it differs from the
+// active one, verifies the candidate pods and promotes the candidate by switching the Service to its color. A canary
+// release verifies the candidate by shifting the traffic to it step by step. The Deployment of the previous color is
+// retired on the next reconciliation. It returns nil when no update is in progress, the active Deployment is then
+// reconciled as usual.
+func (r *ReconcileWebServer) reconcileBlueGreen(t *webserversv1alpha1.WebServer, desired *kbappsv1.Deployment, active *kbappsv1.Deployment) (*reconcile.Result, error) {
+	if t.Status.BlueGreen == nil {
+		t.Status.BlueGreen = &webserversv1alpha1.BlueGreenStatus{}
+	}
+	status := t.Status.BlueGreen
+	status.ActiveColor = activeColor(t)
+	candidateColor := otherColor(status.ActiveColor)
+	candidate := desired.DeepCopy()
+	setDeploymentColor(t, candidate, candidateColor)
+	hash := podTemplateHash(desired.Spec.Template)
+
+	foundCandidate := &kbappsv1.Deployment{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: candidate.Name, Namespace: candidate.Namespace}, foundCandidate)
+	if err != nil && !errors.IsNotFound(err) {
+		reqLogger.Error(err, "Failed to get the candidate Deployment.")
+		return &reconcile.Result{}, err
+	}
+	candidateFound := err == nil && metav1.IsControlledBy(foundCandidate, t)
+
+	if podTemplateHash(active.Spec.Template) == hash || status.AbortedPodTemplateHash == hash {
+		// No update in progress, the Deployment of the previous color or the aborted candidate is deleted
+		if candidateFound {
+			result, err := r.deleteOwnedObject(t, "Deployment", foundCandidate)
+			return &result, err
+		}
+		status.CandidateColor = ""
+		t.Status.Canary = nil
+		if podTemplateHash(active.Spec.Template) == hash {
+			status.CandidatePhase = ""
+			status.AbortedPodTemplateHash = ""
+		} else if status.CandidatePhase != candidateRolledBack {
+			status.CandidatePhase = candidateAborted
+		}
+		return nil, r.removeAnnotation(t, blueGreenAnnotation)
+	}
+
+	status.CandidateColor = candidateColor
+	if t.Annotations[blueGreenAnnotation] == blueGreenAbort {
+		if err := r.removeAnnotation(t, blueGreenAnnotation); err != nil {
+			return &reconcile.Result{}, err
+		}
+		status.AbortedPodTemplateHash = hash
+		t.Status.Canary = nil
+		r.recorder.Eventf(t, corev1.EventTypeNormal, "Aborted", "Aborted the blue/green update of Deployment %s", candidate.Name)
+		return &reconcile.Result{Requeue: true}, nil
+	}
+
+	replicas := t.Spec.Replicas
+	if !candidateFound {
+		reqLogger.Info("Creating a new candidate Deployment.", "Deployment.Namespace", candidate.Namespace, "Deployment.Name", candidate.Name)
+		status.CandidatePhase = candidateDeploying
+		setProgressing(t, "CreatingDeployment", "Creating Deployment "+candidate.Name+" with the new pod template")
+		candidate.Spec.Replicas = &replicas
+		err = r.client.Create(context.TODO(), candidate)
+		if err != nil && !errors.IsAlreadyExists(err) {
+			reqLogger.Error(err, "Failed to create a new Deployment.", "Deployment.Namespace", candidate.Namespace, "Deployment.Name", candidate.Name)
+			return &reconcile.Result{}, err
+		}
+		r.recorder.Eventf(t, corev1.EventTypeNormal, "Created", "Created Deployment %s", candidate.Name)
+		return &reconcile.Result{Requeue: true}, nil
+	}
+	if podTemplateHash(foundCandidate.Spec.Template) != podTemplateHash(candidate.Spec.Template) || *foundCandidate.Spec.Replicas != replicas {
+		status.CandidatePhase = candidateDeploying
+		t.Status.Canary = nil
+		foundCandidate.Spec.Template = candidate.Spec.Template
+		foundCandidate.Spec.Replicas = &replicas
+		result, err := r.updateOwnedObject(t, "Deployment", foundCandidate)
+		return &result, err
+	}
+
+	if isCanary(t) {
+		failure, err := r.canaryFailure(t, foundCandidate)
+		if err != nil {
+			reqLogger.Error(err, "Failed to check the canary pods.")
+			return &reconcile.Result{}, err
+		}
+		if failure != "" {
+			status.AbortedPodTemplateHash = hash
+			status.CandidatePhase = candidateRolledBack
+			t.Status.Canary = nil
+			setDegraded(t, "CanaryRolledBack", "Rolled back Deployment "+foundCandidate.Name+": "+failure)
+			r.recorder.Eventf(t, corev1.EventTypeWarning, "RolledBack", "Rolled back the canary release of Deployment %s: %s", foundCandidate.Name, failure)
+			return &reconcile.Result{Requeue: true}, nil
+		}
+	}
+
+	if foundCandidate.Status.ObservedGeneration < foundCandidate.Generation || foundCandidate.Status.UpdatedReplicas != replicas ||
+		foundCandidate.Status.Replicas != replicas || foundCandidate.Status.ReadyReplicas != replicas {
+		status.CandidatePhase = candidateDeploying
+		setProgressing(t, "DeployingCandidate", fmt.Sprintf("%d of %d pods of Deployment %s are ready", foundCandidate.Status.ReadyReplicas, replicas, foundCandidate.Name))
+		if isCanary(t) {
+			// The restarts of the canary pods are checked until they are ready
+			return &reconcile.Result{RequeueAfter: canaryCheckDelay}, nil
+		}
+		// The Deployment watch requeues the WebServer when the pods become ready
+		return &reconcile.Result{}, nil
+	}
+
+	status.CandidatePhase = candidateVerifying
+	if isCanary(t) {
+		if result := r.reconcileCanarySteps(t, foundCandidate); result != nil {
+			return result, nil
+		}
+	}
+	if err := r.runSmokeTests(t, candidateColor); err != nil {
+		reqLogger.Info("The smoke tests of the candidate failed: " + err.Error())
+		setProgressing(t, "VerifyingCandidate", "The smoke tests of Deployment "+foundCandidate.Name+" failed: "+err.Error())
+		r.recorder.Eventf(t, corev1.EventTypeWarning, "SmokeTestFailed", "The smoke tests of Deployment %s failed: %v", foundCandidate.Name, err)
+		return &reconcile.Result{RequeueAfter: smokeTestsRetryDelay}, nil
+	}
+
+	blueGreen := t.Spec.UpdateStrategy.BlueGreen
+	if blueGreen != nil && blueGreen.ManualPromotion && t.Annotations[blueGreenAnnotation] != blueGreenPromote {
+		// The WebServer watch requeues the WebServer when the annotation is set
+		status.CandidatePhase = candidateWaitingForPromotion
+		setProgressing(t, "WaitingForPromotion", "Deployment "+foundCandidate.Name+" is ready, set the annotation "+blueGreenAnnotation+": "+blueGreenPromote+" of the WebServer to switch the traffic to it")
+		return &reconcile.Result{}, nil
+	}
+
+	if err := r.removeAnnotation(t, blueGreenAnnotation); err != nil {
+		return &reconcile.Result{}, err
+	}
+	status.ActiveColor = candidateColor
+	status.CandidateColor = ""
+	status.CandidatePhase = ""
+	t.Status.Canary = nil
+	setProgressing(t, "PromotingCandidate", "Switching the Service to Deployment "+foundCandidate.Name)
+	r.recorder.Eventf(t, corev1.EventTypeNormal, "Promoted", "Switched the traffic from Deployment %s to Deployment %s", active.Name, foundCandidate.Name)
+	return &reconcile.Result{Requeue: true}, nil
+}
+
+// previousDeployments returns the Deployments of the WebServer named after the other naming scheme: the Deployment
+// named after the application when the WebServer switched to blue/green updates or canary releases, the Deployments
+// named after the colors when it switched back. They keep serving until the Deployment of the new scheme is ready.
+func (r *ReconcileWebServer) previousDeployments(t *webserversv1alpha1.WebServer) ([]*kbappsv1.Deployment, error) {
+	if t.Spec.WebImage == nil {
+		return nil, nil
+	}
+	names := []string{t.Spec.ApplicationName}
+	if !webserversv1alpha1.HasColors(t) {
+		names = []string{t.Spec.ApplicationName + "-blue", t.Spec.ApplicationName + "-green"}
+	}
+	previous := []*kbappsv1.Deployment{}
+	for _, name := range names {
+		deployment := &kbappsv1.Deployment{}
+		err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: t.Namespace}, deployment)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if metav1.IsControlledBy(deployment, t) {
+			previous = append(previous, deployment)
+		}
+	}
+	return previous, nil
+}
+
+// retirePreviousDeployments deletes the Deployments of the previous naming scheme once the Deployment of the current
+// one is rolled out, the candidate of a blue/green update or of a canary release in progress is deleted first. It
+// returns nil when there is no previous Deployment.
+func (r *ReconcileWebServer) retirePreviousDeployments(t *webserversv1alpha1.WebServer, current *kbappsv1.Deployment, previous []*kbappsv1.Deployment) (*reconcile.Result, error) {
+	if len(previous) == 0 {
+		return nil, nil
+	}
+	retired := previous[0]
+	serving := true
+	if !webserversv1alpha1.HasColors(t) {
+		for _, deployment := range previous {
+			if deployment.Labels[colorLabel] != activeColor(t) {
+				retired = deployment
+				serving = false
+			}
+		}
+	}
+	if serving && !isRolledOut(current) {
+		// The Deployment watch requeues the WebServer when the pods become ready
+		setProgressing(t, "ReplacingDeployment", fmt.Sprintf("%d of %d pods of Deployment %s are ready, Deployment %s keeps serving until then",
+			current.Status.ReadyReplicas, *current.Spec.Replicas, current.Name, retired.Name))
+		return &reconcile.Result{}, nil
+	}
+
+	reqLogger.Info("Deleting the Deployment of the previous update strategy.", "Deployment.Namespace", retired.Namespace, "Deployment.Name", retired.Name)
+	setProgressing(t, "ReplacingDeployment", "Deleting Deployment "+retired.Name+" replaced by Deployment "+current.Name)
+	if err := r.client.Delete(context.TODO(), retired); err != nil && !errors.IsNotFound(err) {
+		reqLogger.Error(err, "Failed to delete Deployment.", "Deployment.Namespace", retired.Namespace, "Deployment.Name", retired.Name)
+		return &reconcile.Result{}, err
+	}
+	r.recorder.Eventf(t, corev1.EventTypeNormal, "Deleted", "Deleted Deployment %s replaced by Deployment %s", retired.Name, current.Name)
+	if len(previous) == 1 && !webserversv1alpha1.HasColors(t) {
+		t.Status.BlueGreen = nil
+		t.Status.Canary = nil
+	}
+	return &reconcile.Result{Requeue: true}, nil
+}
+
+// runSmokeTests sends the smoke tests to the ready pods of the color through the proxy of the API server, the tests
+// fail when no pod is ready
+func (r *ReconcileWebServer) runSmokeTests(t *webserversv1alpha1.WebServer, color string) error {
+	blueGreen := t.Spec.UpdateStrategy.BlueGreen
+	if blueGreen == nil || len(blueGreen.SmokeTests) == 0 {
+		return nil
+	}
+	podList, err := r.podsOfColor(t, color)
+	if err != nil {
+		return err
+	}
+	tested := 0
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.DeletionTimestamp != nil || !isReady(pod) {
+			continue
+		}
+		tested++
+		for _, smokeTest := range blueGreen.SmokeTests {
+			statusCode := 0
+			err := r.podProxy.Get().
+				Namespace(pod.Namespace).
+				Resource("pods").
+				Name(fmt.Sprintf("%s:%d", pod.Name, httpPort)).
+				SubResource("proxy").
+				Suffix(smokeTest.Path).
+				Do().
+				StatusCode(&statusCode).
+				Error()
+			if statusCode != int(smokeTest.ExpectedStatus) {
+				if err != nil {
+					return fmt.Errorf("GET %s on pod %s: %v", smokeTest.Path, pod.Name, err)
+				}
+				return fmt.Errorf("GET %s on pod %s returned %d instead of %d", smokeTest.Path, pod.Name, statusCode, smokeTest.ExpectedStatus)
+			}
+		}
+	}
+	if tested == 0 {
+		return fmt.Errorf("no ready pod of color %s was found", color)
+	}
+	return nil
+}
+
+// podsOfColor returns the pods of the Deployment of the color
+func (r *ReconcileWebServer) podsOfColor(t *webserversv1alpha1.WebServer, color string) (*corev1.PodList, error) {
+	labels := podSelectorForWebServer(t)
+	labels[colorLabel] = color
+	podList := &corev1.PodList{}
+	err := r.client.List(context.TODO(), podList, client.InNamespace(t.Namespace), client.MatchingLabels(labels))
+	return podList, err
+}
+
+// removeAnnotation removes an annotation of the WebServer requesting an action once it is handled
+func (r *ReconcileWebServer) removeAnnotation(t *webserversv1alpha1.WebServer, annotation string) error {
+	if _, found := t.Annotations[annotation]; !found {
+		return nil
+	}
+	webServer := t.DeepCopy()
+	delete(webServer.Annotations, annotation)
+	if err := r.client.Update(context.TODO(), webServer); err != nil {
+		reqLogger.Error(err, "Failed to remove the annotation "+annotation+" of the WebServer.")
+		return err
+	}
+	// The status changed by the reconciliation is kept, it is persisted when the reconciliation ends
+	t.Annotations = webServer.Annotations
+	t.ResourceVersion = webServer.ResourceVersion
+	return nil
+}
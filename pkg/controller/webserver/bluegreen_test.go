@@ -0,0 +1,341 @@
+package webserver
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+
+	kbappsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kubescheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// newTestReconciler returns a reconciler of a fake client containing the objects, recording the events
+func newTestReconciler(t *testing.T, objs ...runtime.Object) *ReconcileWebServer {
+	scheme := runtime.NewScheme()
+	if err := kubescheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := webserversv1alpha1.SchemeBuilder.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	// The logger of the reconciliation is set by Reconcile
+	reqLogger = log
+	return &ReconcileWebServer{
+		client:   fake.NewFakeClientWithScheme(scheme, objs...),
+		scheme:   scheme,
+		recorder: record.NewFakeRecorder(100),
+	}
+}
+
+// blueGreenWebServer returns a WebServer updated with a blue/green update, labeled with labels its pods don't have
+func blueGreenWebServer() *webserversv1alpha1.WebServer {
+	return &webserversv1alpha1.WebServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "example-webserver", Namespace: "jws", Labels: map[string]string{"team": "web"}},
+		Spec: webserversv1alpha1.WebServerSpec{
+			ApplicationName: "example",
+			Replicas:        2,
+			WebImage:        &webserversv1alpha1.WebImageSpec{ApplicationImage: "quay.io/example/tomcat:1.0"},
+			UpdateStrategy: &webserversv1alpha1.UpdateStrategySpec{
+				Type:      "BlueGreen",
+				BlueGreen: &webserversv1alpha1.BlueGreenSpec{SmokeTests: []webserversv1alpha1.SmokeTestSpec{{Path: "/health", ExpectedStatus: 200}}},
+			},
+		},
+	}
+}
+
+// podOfColor returns a pod of the Deployment of the color
+func podOfColor(t *webserversv1alpha1.WebServer, name string, color string, ready bool) *corev1.Pod {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: t.Namespace,
+			Labels: map[string]string{
+				"deploymentConfig": t.Spec.ApplicationName,
+				"WebServer":        t.Name,
+				servingLabel:       "true",
+				colorLabel:         color,
+			},
+		},
+		Status: corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}}},
+	}
+}
+
+func TestPodsOfColor(t *testing.T) {
+	webServer := blueGreenWebServer()
+	r := newTestReconciler(t, podOfColor(webServer, "example-green-1", "green", true), podOfColor(webServer, "example-blue-1", "blue", true))
+
+	podList, err := r.podsOfColor(webServer, "green")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(podList.Items) != 1 || podList.Items[0].Name != "example-green-1" {
+		t.Errorf("got %v, expected the pod example-green-1", podList.Items)
+	}
+}
+
+func TestRunSmokeTestsWithoutReadyPod(t *testing.T) {
+	webServer := blueGreenWebServer()
+	r := newTestReconciler(t, podOfColor(webServer, "example-green-1", "green", false))
+
+	if err := r.runSmokeTests(webServer, "green"); err == nil {
+		t.Error("got no error, expected the smoke tests to fail without a ready pod")
+	}
+	webServer.Spec.UpdateStrategy.BlueGreen = nil
+	if err := r.runSmokeTests(webServer, "green"); err != nil {
+		t.Errorf("got %v, expected no error without smoke tests", err)
+	}
+}
+
+// ownedDeployment returns a Deployment of the WebServer with its rollout status
+func ownedDeployment(t *testing.T, r *ReconcileWebServer, webServer *webserversv1alpha1.WebServer, name string, color string, rolledOut bool) *kbappsv1.Deployment {
+	replicas := webServer.Spec.Replicas
+	deployment := &kbappsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: webServer.Namespace, Labels: map[string]string{}},
+		Spec:       kbappsv1.DeploymentSpec{Replicas: &replicas},
+	}
+	if color != "" {
+		deployment.Labels[colorLabel] = color
+	}
+	if rolledOut {
+		deployment.Status = kbappsv1.DeploymentStatus{Replicas: replicas, UpdatedReplicas: replicas, ReadyReplicas: replicas}
+	}
+	if err := controllerutil.SetControllerReference(webServer, deployment, r.scheme); err != nil {
+		t.Fatal(err)
+	}
+	return deployment
+}
+
+// deploymentExists returns whether the Deployment is found by the client of the reconciler
+func deploymentExists(t *testing.T, r *ReconcileWebServer, webServer *webserversv1alpha1.WebServer, name string) bool {
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: webServer.Namespace}, &kbappsv1.Deployment{})
+	if err != nil && !errors.IsNotFound(err) {
+		t.Fatal(err)
+	}
+	return err == nil
+}
+
+func TestRetirePreviousDeploymentsToColors(t *testing.T) {
+	webServer := blueGreenWebServer()
+	webServer.UID = "example-uid"
+	r := newTestReconciler(t)
+	previous := ownedDeployment(t, r, webServer, "example", "", true)
+	notOwned := ownedDeployment(t, r, webServer, "example-other", "", true)
+	notOwned.OwnerReferences = nil
+	for _, obj := range []runtime.Object{previous, notOwned} {
+		if err := r.client.Create(context.TODO(), obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	found, err := r.previousDeployments(webServer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0].Name != "example" {
+		t.Fatalf("got %v, expected the Deployment example", found)
+	}
+
+	// The previous Deployment serves until the blue Deployment is rolled out
+	result, err := r.retirePreviousDeployments(webServer, ownedDeployment(t, r, webServer, "example-blue", "blue", false), found)
+	if err != nil || result == nil || result.Requeue {
+		t.Errorf("got %v %v, expected to wait for the blue Deployment", result, err)
+	}
+	if !deploymentExists(t, r, webServer, "example") {
+		t.Error("the Deployment example was deleted before the blue Deployment is rolled out")
+	}
+
+	result, err = r.retirePreviousDeployments(webServer, ownedDeployment(t, r, webServer, "example-blue", "blue", true), found)
+	if err != nil || result == nil || !result.Requeue {
+		t.Errorf("got %v %v, expected a requeue", result, err)
+	}
+	if deploymentExists(t, r, webServer, "example") {
+		t.Error("the Deployment example wasn't deleted")
+	}
+}
+
+func TestRetirePreviousDeploymentsFromColors(t *testing.T) {
+	webServer := blueGreenWebServer()
+	webServer.UID = "example-uid"
+	webServer.Spec.UpdateStrategy = &webserversv1alpha1.UpdateStrategySpec{Type: "RollingUpdate"}
+	webServer.Status.BlueGreen = &webserversv1alpha1.BlueGreenStatus{ActiveColor: "green", CandidateColor: "blue", CandidatePhase: candidateVerifying}
+	r := newTestReconciler(t)
+	for _, obj := range []runtime.Object{
+		ownedDeployment(t, r, webServer, "example-blue", "blue", true),
+		ownedDeployment(t, r, webServer, "example-green", "green", true),
+	} {
+		if err := r.client.Create(context.TODO(), obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+	current := ownedDeployment(t, r, webServer, "example", "", false)
+
+	// The candidate is deleted right away, the active Deployment serves until the new one is rolled out
+	found, err := r.previousDeployments(webServer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.retirePreviousDeployments(webServer, current, found); err != nil {
+		t.Fatal(err)
+	}
+	if deploymentExists(t, r, webServer, "example-blue") || !deploymentExists(t, r, webServer, "example-green") {
+		t.Error("got the candidate kept or the active Deployment deleted, expected the candidate to be deleted first")
+	}
+
+	found, err = r.previousDeployments(webServer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := r.retirePreviousDeployments(webServer, current, found)
+	if err != nil || result == nil || result.Requeue || !deploymentExists(t, r, webServer, "example-green") {
+		t.Errorf("got %v %v, expected to wait for the Deployment example", result, err)
+	}
+
+	current = ownedDeployment(t, r, webServer, "example", "", true)
+	if _, err := r.retirePreviousDeployments(webServer, current, found); err != nil {
+		t.Fatal(err)
+	}
+	if deploymentExists(t, r, webServer, "example-green") {
+		t.Error("the Deployment example-green wasn't deleted")
+	}
+	if webServer.Status.BlueGreen != nil {
+		t.Errorf("got %v, expected the blue/green status to be cleared", webServer.Status.BlueGreen)
+	}
+}
+
+// coloredDeployment returns the Deployment of the color running the image
+func coloredDeployment(t *testing.T, r *ReconcileWebServer, webServer *webserversv1alpha1.WebServer, image string, color string, rolledOut bool) *kbappsv1.Deployment {
+	deployment := ownedDeployment(t, r, webServer, webServer.Spec.ApplicationName, "", rolledOut)
+	deployment.Spec.Selector = &metav1.LabelSelector{MatchLabels: podSelectorForWebServer(webServer)}
+	deployment.Spec.Template = corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: podSelectorForWebServer(webServer)},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: webServer.Spec.ApplicationName, Image: image}}},
+	}
+	setDeploymentColor(webServer, deployment, color)
+	return deployment
+}
+
+// newBlueGreenReconciler returns a reconciler of a WebServer updating the image of the blue Deployment with a
+// green candidate Deployment, the candidate is rolled out
+func newBlueGreenReconciler(t *testing.T, webServer *webserversv1alpha1.WebServer) (*ReconcileWebServer, *kbappsv1.Deployment, *kbappsv1.Deployment) {
+	webServer.UID = "example-uid"
+	webServer.Status.BlueGreen = &webserversv1alpha1.BlueGreenStatus{ActiveColor: "blue"}
+	r := newTestReconciler(t, webServer)
+	active := coloredDeployment(t, r, webServer, "quay.io/example/tomcat:1.0", "blue", true)
+	desired := coloredDeployment(t, r, webServer, "quay.io/example/tomcat:2.0", "blue", false)
+	candidate := coloredDeployment(t, r, webServer, "quay.io/example/tomcat:2.0", "green", true)
+	for _, obj := range []runtime.Object{active, candidate} {
+		if err := r.client.Create(context.TODO(), obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return r, desired, active
+}
+
+// recordedEvent returns whether an event of the reason was recorded since the last call
+func recordedEvent(r *ReconcileWebServer, reason string) bool {
+	recorder := r.recorder.(*record.FakeRecorder)
+	found := false
+	for {
+		select {
+		case event := <-recorder.Events:
+			if strings.Contains(event, " "+reason+" ") {
+				found = true
+			}
+		default:
+			return found
+		}
+	}
+}
+
+func TestReconcileBlueGreenPromote(t *testing.T) {
+	webServer := blueGreenWebServer()
+	webServer.Spec.UpdateStrategy.BlueGreen = nil
+	r, desired, active := newBlueGreenReconciler(t, webServer)
+
+	result, err := r.reconcileBlueGreen(webServer, desired, active)
+	if err != nil || result == nil || !result.Requeue {
+		t.Fatalf("got %v %v, expected a requeue", result, err)
+	}
+	if status := webServer.Status.BlueGreen; status.ActiveColor != "green" || status.CandidateColor != "" || status.CandidatePhase != "" {
+		t.Errorf("got %+v, expected the green Deployment to be active", status)
+	}
+	if !recordedEvent(r, "Promoted") {
+		t.Error("got no Promoted event")
+	}
+}
+
+func TestReconcileBlueGreenManualPromotion(t *testing.T) {
+	webServer := blueGreenWebServer()
+	webServer.Spec.UpdateStrategy.BlueGreen = &webserversv1alpha1.BlueGreenSpec{ManualPromotion: true}
+	r, desired, active := newBlueGreenReconciler(t, webServer)
+
+	result, err := r.reconcileBlueGreen(webServer, desired, active)
+	if err != nil || result == nil || result.Requeue {
+		t.Fatalf("got %v %v, expected to wait for the promotion", result, err)
+	}
+	if status := webServer.Status.BlueGreen; status.ActiveColor != "blue" || status.CandidatePhase != candidateWaitingForPromotion {
+		t.Errorf("got %+v, expected the green Deployment to wait for the promotion", status)
+	}
+
+	webServer.Annotations = map[string]string{blueGreenAnnotation: blueGreenPromote}
+	result, err = r.reconcileBlueGreen(webServer, desired, active)
+	if err != nil || result == nil || !result.Requeue {
+		t.Fatalf("got %v %v, expected a requeue", result, err)
+	}
+	if status := webServer.Status.BlueGreen; status.ActiveColor != "green" {
+		t.Errorf("got %+v, expected the green Deployment to be active", status)
+	}
+	if _, found := webServer.Annotations[blueGreenAnnotation]; found {
+		t.Error("the promote annotation wasn't removed")
+	}
+}
+
+func TestReconcileBlueGreenAbort(t *testing.T) {
+	webServer := blueGreenWebServer()
+	webServer.Spec.UpdateStrategy.BlueGreen = nil
+	webServer.Annotations = map[string]string{blueGreenAnnotation: blueGreenAbort}
+	r, desired, active := newBlueGreenReconciler(t, webServer)
+
+	result, err := r.reconcileBlueGreen(webServer, desired, active)
+	if err != nil || result == nil || !result.Requeue {
+		t.Fatalf("got %v %v, expected a requeue", result, err)
+	}
+	if status := webServer.Status.BlueGreen; status.AbortedPodTemplateHash != podTemplateHash(desired.Spec.Template) || status.ActiveColor != "blue" {
+		t.Errorf("got %+v, expected the pod template to be aborted", status)
+	}
+	if _, found := webServer.Annotations[blueGreenAnnotation]; found {
+		t.Error("the abort annotation wasn't removed")
+	}
+	if !recordedEvent(r, "Aborted") {
+		t.Error("got no Aborted event")
+	}
+
+	// The candidate is deleted, then the active Deployment is reconciled as usual
+	if _, err := r.reconcileBlueGreen(webServer, desired, active); err != nil {
+		t.Fatal(err)
+	}
+	if deploymentExists(t, r, webServer, "example-green") {
+		t.Error("the aborted candidate wasn't deleted")
+	}
+	result, err = r.reconcileBlueGreen(webServer, desired, active)
+	if err != nil || result != nil {
+		t.Errorf("got %v %v, expected no update in progress", result, err)
+	}
+	if status := webServer.Status.BlueGreen; status.CandidatePhase != candidateAborted || status.CandidateColor != "" {
+		t.Errorf("got %+v, expected the candidate to be aborted", status)
+	}
+}
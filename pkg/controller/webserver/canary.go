@@ -0,0 +1,129 @@
+package webserver
+
+import (
+	"fmt"
+	"time"
+
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+
+	routev1 "github.com/openshift/api/route/v1"
+	kbappsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// canaryCheckDelay is the delay between two checks of the health of the canary pods
+	canaryCheckDelay = 10 * time.Second
+	// canaryNotSupportedMessage explains why a canary release isn't deployed on Kubernetes
+	canaryNotSupportedMessage = "The requests of a canary release are split by an OpenShift Route, use a BlueGreen update on Kubernetes"
+)
+
+// isCanary returns whether the pod template changes are deployed by a canary release
+func isCanary(t *webserversv1alpha1.WebServer) bool {
+	return webserversv1alpha1.HasColors(t) && t.Spec.UpdateStrategy.Type == "Canary"
+}
+
+// canaryServiceName returns the name of the Service selecting the canary pods
+func canaryServiceName(t *webserversv1alpha1.WebServer) string {
+	return t.Spec.ApplicationName + "-canary"
+}
+
+// isCanaryInProgress returns whether a canary Deployment is running next to the active one
+func isCanaryInProgress(t *webserversv1alpha1.WebServer) bool {
+	return isCanary(t) && t.Status.BlueGreen != nil && t.Status.BlueGreen.CandidateColor != ""
+}
+
+// canaryServiceForWebServer returns the Service selecting the pods of the candidate color, the Route sends it the
+// share of the requests of the current step
+func (r *ReconcileWebServer) canaryServiceForWebServer(t *webserversv1alpha1.WebServer) *corev1.Service {
+	service := r.serviceForWebServer(t)
+	service.Name = canaryServiceName(t)
+	service.Spec.Selector[colorLabel] = t.Status.BlueGreen.CandidateColor
+	return service
+}
+
+// setCanaryBackends splits the requests of the Route between the Service of the active pods and the Service of the
+// canary pods according to the current step
+func setCanaryBackends(t *webserversv1alpha1.WebServer, route *routev1.Route) {
+	weight := int32(0)
+	if t.Status.Canary != nil {
+		weight = t.Status.Canary.Weight
+	}
+	activeWeight := 100 - weight
+	route.Spec.To.Weight = &activeWeight
+	if weight > 0 {
+		route.Spec.AlternateBackends = []routev1.RouteTargetReference{{
+			Kind:   "Service",
+			Name:   canaryServiceName(t),
+			Weight: &weight,
+		}}
+	}
+}
+
+// canaryFailure returns why the canary release has to be rolled back, an empty string while the canary is healthy:
+// the Deployment doesn't progress, a pod restarted too many times or a pod is no longer ready once it receives requests
+func (r *ReconcileWebServer) canaryFailure(t *webserversv1alpha1.WebServer, canary *kbappsv1.Deployment) (string, error) {
+	if progressDeadlineExceeded(canary) {
+		return "the Deployment exceeded its progress deadline", nil
+	}
+	podList, err := r.podsOfColor(t, t.Status.BlueGreen.CandidateColor)
+	if err != nil {
+		return "", err
+	}
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		restarts := int32(0)
+		for _, containerStatus := range pod.Status.ContainerStatuses {
+			restarts += containerStatus.RestartCount
+		}
+		if restarts >= t.Spec.UpdateStrategy.Canary.MaxRestarts {
+			return fmt.Sprintf("pod %s restarted %d times", pod.Name, restarts), nil
+		}
+		if t.Status.Canary != nil && t.Status.Canary.Step > 0 && !isReady(pod) {
+			return fmt.Sprintf("pod %s is no longer ready", pod.Name), nil
+		}
+	}
+	return "", nil
+}
+
+// reconcileCanarySteps shifts the requests to the ready canary pods step by step, the pods are checked during the
+// pause of each step. It returns nil once the pause of the last step is over and the canary can be promoted.
+func (r *ReconcileWebServer) reconcileCanarySteps(t *webserversv1alpha1.WebServer, canary *kbappsv1.Deployment) *reconcile.Result {
+	steps := t.Spec.UpdateStrategy.Canary.Steps
+	if t.Status.Canary == nil {
+		t.Status.Canary = &webserversv1alpha1.CanaryStatus{}
+	}
+	status := t.Status.Canary
+	now := metav1.Now()
+
+	if status.Step > 0 && status.StepStartTime != nil {
+		if int(status.Step) > len(steps) {
+			// The steps were shortened during the release
+			return nil
+		}
+		remaining := time.Duration(steps[status.Step-1].PauseSeconds)*time.Second - now.Sub(status.StepStartTime.Time)
+		if remaining > 0 {
+			setProgressing(t, "CanaryPaused", fmt.Sprintf("%d%% of the requests are sent to Deployment %s, step %d of %d", status.Weight, canary.Name, status.Step, len(steps)))
+			if remaining > canaryCheckDelay {
+				remaining = canaryCheckDelay
+			}
+			return &reconcile.Result{RequeueAfter: remaining}
+		}
+		if int(status.Step) == len(steps) {
+			return nil
+		}
+	}
+
+	status.Step++
+	status.Weight = steps[status.Step-1].Weight
+	status.StepStartTime = &now
+	setProgressing(t, "ShiftingTraffic", fmt.Sprintf("Sending %d%% of the requests to Deployment %s", status.Weight, canary.Name))
+	r.recorder.Eventf(t, corev1.EventTypeNormal, "CanaryStep", "Sending %d%% of the requests to Deployment %s", status.Weight, canary.Name)
+	// The Route is updated with the weight of the step on the next reconciliation
+	return &reconcile.Result{Requeue: true}
+}
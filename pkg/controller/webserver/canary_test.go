@@ -0,0 +1,185 @@
+package webserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+
+	routev1 "github.com/openshift/api/route/v1"
+	kbappsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// canaryWebServer returns a WebServer updated with a canary release of two steps
+func canaryWebServer() *webserversv1alpha1.WebServer {
+	webServer := blueGreenWebServer()
+	webServer.Spec.UpdateStrategy = &webserversv1alpha1.UpdateStrategySpec{
+		Type: "Canary",
+		Canary: &webserversv1alpha1.CanarySpec{
+			Steps:       []webserversv1alpha1.CanaryStepSpec{{Weight: 20, PauseSeconds: 60}, {Weight: 100, PauseSeconds: 60}},
+			MaxRestarts: 3,
+		},
+	}
+	webserversv1alpha1.SetDefaults(webServer)
+	return webServer
+}
+
+// condition returns the condition of the type of the WebServer, nil if it isn't set
+func condition(t *webserversv1alpha1.WebServer, conditionType webserversv1alpha1.WebServerConditionType) *webserversv1alpha1.WebServerCondition {
+	for i := range t.Status.Conditions {
+		if t.Status.Conditions[i].Type == conditionType {
+			return &t.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+func TestCanaryNotSupportedOnKubernetes(t *testing.T) {
+	webServer := canaryWebServer()
+	r := newTestReconciler(t, webServer)
+
+	name := types.NamespacedName{Name: webServer.Name, Namespace: webServer.Namespace}
+	result, err := r.Reconcile(reconcile.Request{NamespacedName: name})
+	if err != nil || result.Requeue {
+		t.Fatalf("got %v %v, expected the reconciliation to stop", result, err)
+	}
+	found := &webserversv1alpha1.WebServer{}
+	if err := r.client.Get(context.TODO(), name, found); err != nil {
+		t.Fatal(err)
+	}
+	if degraded := condition(found, webserversv1alpha1.WebServerDegraded); degraded == nil || degraded.Status != corev1.ConditionTrue || degraded.Reason != "CanaryNotSupported" {
+		t.Errorf("got %+v, expected the WebServer to be Degraded", degraded)
+	}
+	if !recordedEvent(r, "CanaryNotSupported") {
+		t.Error("got no CanaryNotSupported event")
+	}
+}
+
+func TestSetCanaryBackends(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        *webserversv1alpha1.CanaryStatus
+		activeWeight  int32
+		canaryBackend bool
+	}{
+		{name: "canary pods not ready", activeWeight: 100},
+		{name: "first step", status: &webserversv1alpha1.CanaryStatus{Step: 1, Weight: 20}, activeWeight: 80, canaryBackend: true},
+		{name: "last step", status: &webserversv1alpha1.CanaryStatus{Step: 2, Weight: 100}, activeWeight: 0, canaryBackend: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			webServer := canaryWebServer()
+			webServer.Status.Canary = test.status
+			route := &routev1.Route{Spec: routev1.RouteSpec{To: routev1.RouteTargetReference{Kind: "Service", Name: "example"}}}
+			setCanaryBackends(webServer, route)
+			if route.Spec.To.Weight == nil || *route.Spec.To.Weight != test.activeWeight {
+				t.Errorf("got %v, expected the weight %d for the active pods", route.Spec.To.Weight, test.activeWeight)
+			}
+			if !test.canaryBackend {
+				if len(route.Spec.AlternateBackends) > 0 {
+					t.Errorf("got %v, expected no alternate backend", route.Spec.AlternateBackends)
+				}
+				return
+			}
+			if len(route.Spec.AlternateBackends) != 1 || route.Spec.AlternateBackends[0].Name != "example-canary" ||
+				*route.Spec.AlternateBackends[0].Weight != test.status.Weight {
+				t.Errorf("got %v, expected the canary Service with the weight %d", route.Spec.AlternateBackends, test.status.Weight)
+			}
+		})
+	}
+}
+
+func TestReconcileCanarySteps(t *testing.T) {
+	webServer := canaryWebServer()
+	r := newTestReconciler(t)
+	canary := &kbappsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "example-green", Namespace: webServer.Namespace}}
+
+	// The first step starts once the canary pods are ready
+	result := r.reconcileCanarySteps(webServer, canary)
+	if result == nil || !result.Requeue {
+		t.Fatalf("got %v, expected a requeue", result)
+	}
+	if status := webServer.Status.Canary; status.Step != 1 || status.Weight != 20 || status.StepStartTime == nil {
+		t.Errorf("got %+v, expected the first step to start", status)
+	}
+	if !recordedEvent(r, "CanaryStep") {
+		t.Error("got no CanaryStep event")
+	}
+
+	// The canary pods are checked during the pause
+	result = r.reconcileCanarySteps(webServer, canary)
+	if result == nil || result.RequeueAfter <= 0 || result.RequeueAfter > canaryCheckDelay {
+		t.Fatalf("got %v, expected a check of the canary pods during the pause", result)
+	}
+	if status := webServer.Status.Canary; status.Step != 1 {
+		t.Errorf("got the step %d, expected the first step to be paused", status.Step)
+	}
+
+	// The next step starts at the end of the pause
+	pauseEnd := metav1.NewTime(time.Now().Add(-61 * time.Second))
+	webServer.Status.Canary.StepStartTime = &pauseEnd
+	result = r.reconcileCanarySteps(webServer, canary)
+	if result == nil || !result.Requeue {
+		t.Fatalf("got %v, expected a requeue", result)
+	}
+	if status := webServer.Status.Canary; status.Step != 2 || status.Weight != 100 {
+		t.Errorf("got %+v, expected the second step to start", status)
+	}
+
+	// The canary is promoted at the end of the pause of the last step
+	webServer.Status.Canary.StepStartTime = &pauseEnd
+	if result := r.reconcileCanarySteps(webServer, canary); result != nil {
+		t.Errorf("got %v, expected the canary to be promoted", result)
+	}
+}
+
+func TestCanaryFailure(t *testing.T) {
+	tests := []struct {
+		name       string
+		step       int32
+		restarts   int32
+		ready      bool
+		deadline   bool
+		rolledBack bool
+	}{
+		{name: "healthy", step: 1, ready: true},
+		{name: "pods starting", step: 0, restarts: 2},
+		{name: "too many restarts", step: 0, restarts: 3, rolledBack: true},
+		{name: "no longer ready", step: 1, ready: false, rolledBack: true},
+		{name: "progress deadline exceeded", step: 0, deadline: true, rolledBack: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			webServer := canaryWebServer()
+			webServer.Status.BlueGreen = &webserversv1alpha1.BlueGreenStatus{ActiveColor: "blue", CandidateColor: "green"}
+			webServer.Status.Canary = &webserversv1alpha1.CanaryStatus{Step: test.step}
+			pod := podOfColor(webServer, "example-green-1", "green", test.ready)
+			pod.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "example", RestartCount: test.restarts}}
+			// The pods of the active color are not checked
+			activePod := podOfColor(webServer, "example-blue-1", "blue", false)
+			activePod.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "example", RestartCount: 10}}
+			r := newTestReconciler(t, pod, activePod)
+
+			canary := &kbappsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "example-green", Namespace: webServer.Namespace}}
+			if test.deadline {
+				canary.Status.Conditions = []kbappsv1.DeploymentCondition{{
+					Type:   kbappsv1.DeploymentProgressing,
+					Status: corev1.ConditionFalse,
+					Reason: "ProgressDeadlineExceeded",
+				}}
+			}
+			failure, err := r.canaryFailure(webServer, canary)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.rolledBack == (failure == "") {
+				t.Errorf("got %q, expected a failure %v", failure, test.rolledBack)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package webserver
+
+import (
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// setCondition adds or updates the condition of the given type in the WebServer status.
+// The LastTransitionTime is only changed when the status of the condition changes.
+// It returns true when the status or the reason of the condition have changed.
+func setCondition(t *webserversv1alpha1.WebServer, conditionType webserversv1alpha1.WebServerConditionType, status corev1.ConditionStatus, reason string, message string) bool {
+	condition := webserversv1alpha1.WebServerCondition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: t.Generation,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	}
+	for i, existing := range t.Status.Conditions {
+		if existing.Type != conditionType {
+			continue
+		}
+		if existing.Status == status {
+			condition.LastTransitionTime = existing.LastTransitionTime
+		}
+		t.Status.Conditions[i] = condition
+		return existing.Status != status || existing.Reason != reason
+	}
+	t.Status.Conditions = append(t.Status.Conditions, condition)
+	return true
+}
+
+// setProgressing marks the WebServer as being created or updated
+func setProgressing(t *webserversv1alpha1.WebServer, reason string, message string) {
+	setCondition(t, webserversv1alpha1.WebServerProgressing, corev1.ConditionTrue, reason, message)
+}
+
+// setDegraded marks the WebServer as unable to reach its desired state
+func setDegraded(t *webserversv1alpha1.WebServer, reason string, message string) {
+	setCondition(t, webserversv1alpha1.WebServerDegraded, corev1.ConditionTrue, reason, message)
+	setCondition(t, webserversv1alpha1.WebServerProgressing, corev1.ConditionFalse, reason, message)
+}
+
+// setBuildCondition sets the BuildSucceeded condition and emits an event when the build phase changes
+func (r *ReconcileWebServer) setBuildCondition(t *webserversv1alpha1.WebServer, status corev1.ConditionStatus, reason string, message string) {
+	if !setCondition(t, webserversv1alpha1.WebServerBuildSucceeded, status, reason, message) {
+		return
+	}
+	if status == corev1.ConditionFalse {
+		r.recorder.Event(t, corev1.EventTypeWarning, reason, message)
+	} else {
+		r.recorder.Event(t, corev1.EventTypeNormal, reason, message)
+	}
+}
+
+// setReconcileResult records the outcome of a reconciliation in the ReconcileError condition
+// and the ObservedGeneration of the WebServer status.
+func setReconcileResult(t *webserversv1alpha1.WebServer, err error) {
+	if err != nil {
+		setCondition(t, webserversv1alpha1.WebServerReconcileError, corev1.ConditionTrue, "ReconcileFailed", err.Error())
+	} else {
+		setCondition(t, webserversv1alpha1.WebServerReconcileError, corev1.ConditionFalse, "ReconcileSucceeded", "")
+	}
+	t.Status.ObservedGeneration = t.Generation
+}
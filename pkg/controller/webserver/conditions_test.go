@@ -0,0 +1,162 @@
+package webserver
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestSetCondition(t *testing.T) {
+	lastTransitionTime := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	existing := webserversv1alpha1.WebServerCondition{
+		Type:               webserversv1alpha1.WebServerAvailable,
+		Status:             corev1.ConditionTrue,
+		ObservedGeneration: 1,
+		LastTransitionTime: lastTransitionTime,
+		Reason:             "PodsReady",
+		Message:            "2 of 2 pods are ready",
+	}
+	tests := []struct {
+		name               string
+		conditions         []webserversv1alpha1.WebServerCondition
+		status             corev1.ConditionStatus
+		reason             string
+		message            string
+		changed            bool
+		keepTransitionTime bool
+	}{
+		{
+			name:    "new condition",
+			status:  corev1.ConditionTrue,
+			reason:  "PodsReady",
+			message: "2 of 2 pods are ready",
+			changed: true,
+		},
+		{
+			name:               "unchanged",
+			conditions:         []webserversv1alpha1.WebServerCondition{existing},
+			status:             corev1.ConditionTrue,
+			reason:             "PodsReady",
+			message:            "2 of 2 pods are ready",
+			keepTransitionTime: true,
+		},
+		{
+			name:               "message changed",
+			conditions:         []webserversv1alpha1.WebServerCondition{existing},
+			status:             corev1.ConditionTrue,
+			reason:             "PodsReady",
+			message:            "3 of 3 pods are ready",
+			keepTransitionTime: true,
+		},
+		{
+			name:               "reason changed",
+			conditions:         []webserversv1alpha1.WebServerCondition{existing},
+			status:             corev1.ConditionTrue,
+			reason:             "PodsScaled",
+			message:            "3 of 3 pods are ready",
+			changed:            true,
+			keepTransitionTime: true,
+		},
+		{
+			name:       "status changed",
+			conditions: []webserversv1alpha1.WebServerCondition{existing},
+			status:     corev1.ConditionFalse,
+			reason:     "PodsNotReady",
+			message:    "1 of 2 pods are ready",
+			changed:    true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			webServer := &webserversv1alpha1.WebServer{ObjectMeta: metav1.ObjectMeta{Generation: 2}}
+			webServer.Status.Conditions = append(webServer.Status.Conditions, test.conditions...)
+			// The other conditions are left unchanged
+			progressing := webserversv1alpha1.WebServerCondition{Type: webserversv1alpha1.WebServerProgressing, Status: corev1.ConditionFalse, ObservedGeneration: 1}
+			webServer.Status.Conditions = append(webServer.Status.Conditions, progressing)
+
+			changed := setCondition(webServer, webserversv1alpha1.WebServerAvailable, test.status, test.reason, test.message)
+			if changed != test.changed {
+				t.Errorf("got %t, expected %t", changed, test.changed)
+			}
+			if len(webServer.Status.Conditions) != 2 {
+				t.Fatalf("got %v, expected a single Available condition", webServer.Status.Conditions)
+			}
+			available := condition(webServer, webserversv1alpha1.WebServerAvailable)
+			if available.Status != test.status || available.Reason != test.reason || available.Message != test.message || available.ObservedGeneration != 2 {
+				t.Errorf("got %+v, expected the status %s, the reason %s, the message %q and the generation 2", available, test.status, test.reason, test.message)
+			}
+			if available.LastTransitionTime.Equal(&lastTransitionTime) != test.keepTransitionTime {
+				t.Errorf("got the transition time %v, expected it to be kept: %t", available.LastTransitionTime, test.keepTransitionTime)
+			}
+			if *condition(webServer, webserversv1alpha1.WebServerProgressing) != progressing {
+				t.Errorf("got %+v, expected the Progressing condition to be left unchanged", condition(webServer, webserversv1alpha1.WebServerProgressing))
+			}
+		})
+	}
+}
+
+func TestSetReconcileResult(t *testing.T) {
+	webServer := &webserversv1alpha1.WebServer{ObjectMeta: metav1.ObjectMeta{Generation: 3}}
+
+	setReconcileResult(webServer, errors.New("failed to create the Service"))
+	reconcileError := condition(webServer, webserversv1alpha1.WebServerReconcileError)
+	if reconcileError == nil || reconcileError.Status != corev1.ConditionTrue || reconcileError.Reason != "ReconcileFailed" || reconcileError.Message != "failed to create the Service" {
+		t.Errorf("got %+v, expected the ReconcileError condition with the error", reconcileError)
+	}
+	if webServer.Status.ObservedGeneration != 3 {
+		t.Errorf("got the observed generation %d, expected 3", webServer.Status.ObservedGeneration)
+	}
+
+	webServer.Generation = 4
+	setReconcileResult(webServer, nil)
+	reconcileError = condition(webServer, webserversv1alpha1.WebServerReconcileError)
+	if reconcileError.Status != corev1.ConditionFalse || reconcileError.Reason != "ReconcileSucceeded" || reconcileError.Message != "" {
+		t.Errorf("got %+v, expected the ReconcileError condition to be cleared", reconcileError)
+	}
+	if webServer.Status.ObservedGeneration != 4 {
+		t.Errorf("got the observed generation %d, expected 4", webServer.Status.ObservedGeneration)
+	}
+}
+
+func TestSetBuildCondition(t *testing.T) {
+	webServer := &webserversv1alpha1.WebServer{ObjectMeta: metav1.ObjectMeta{Name: "example-webserver", Namespace: "jws"}}
+	r := newTestReconciler(t)
+	recorder := r.recorder.(*record.FakeRecorder)
+	tests := []struct {
+		name   string
+		status corev1.ConditionStatus
+		reason string
+		event  string
+	}{
+		{name: "build running", status: corev1.ConditionUnknown, reason: "BuildRunning", event: "Normal BuildRunning Application build example-1 is running"},
+		{name: "build still running", status: corev1.ConditionUnknown, reason: "BuildRunning"},
+		{name: "build failed", status: corev1.ConditionFalse, reason: "BuildFailed", event: "Warning BuildFailed Application build example-1 failed"},
+		{name: "build still failed", status: corev1.ConditionFalse, reason: "BuildFailed"},
+		{name: "build running again", status: corev1.ConditionUnknown, reason: "BuildRunning", event: "Normal BuildRunning Application build example-1 is running"},
+		{name: "build complete", status: corev1.ConditionTrue, reason: "BuildComplete", event: "Normal BuildComplete Application build example-1 complete"},
+	}
+	messages := map[string]string{
+		"BuildRunning":  "Application build example-1 is running",
+		"BuildFailed":   "Application build example-1 failed",
+		"BuildComplete": "Application build example-1 complete",
+	}
+	for _, test := range tests {
+		r.setBuildCondition(webServer, test.status, test.reason, messages[test.reason])
+		select {
+		case event := <-recorder.Events:
+			if event != test.event {
+				t.Errorf("%s: got the event %q, expected %q", test.name, event, test.event)
+			}
+		default:
+			if test.event != "" {
+				t.Errorf("%s: got no event, expected %q", test.name, test.event)
+			}
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package webserver
+
+import (
+	"context"
+	"encoding/binary"
+	"hash"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// secretsIndexField is the field of the WebServers indexing the names of the Secrets they reference
+	secretsIndexField = "spec.referencedSecrets"
+	// configMapsIndexField is the field of the WebServers indexing the names of the ConfigMaps they reference
+	configMapsIndexField = "spec.referencedConfigMaps"
+	// configHashAnnotation is the annotation of the pod template holding the hash of the data of the ConfigMaps and
+	// Secrets read by the pods. The pods read them when they start, so they are rolled out when the hash changes.
+	configHashAnnotation = "web.servers.org/config-hash"
+)
+
+// podConfigMapsAndSecrets returns the names of the ConfigMaps and of the Secrets read by the pods of the WebServer,
+// through the environment of the Tomcat container, of the init containers and of the sidecars, and through the volumes
+func podConfigMapsAndSecrets(t *webserversv1alpha1.WebServer) ([]string, []string) {
+	configMaps, secrets := envConfigMapsAndSecrets(t.Spec.Env, t.Spec.EnvFrom)
+	containers := append(append([]corev1.Container{}, t.Spec.InitContainers...), t.Spec.Sidecars...)
+	for _, container := range containers {
+		containerConfigMaps, containerSecrets := envConfigMapsAndSecrets(container.Env, container.EnvFrom)
+		configMaps = append(configMaps, containerConfigMaps...)
+		secrets = append(secrets, containerSecrets...)
+	}
+	volumeConfigMaps, volumeSecrets := volumeConfigMapsAndSecrets(userVolumes(t))
+	return append(configMaps, volumeConfigMaps...), append(secrets, volumeSecrets...)
+}
+
+// referencedConfigMaps returns the names of the ConfigMaps used by the WebServer which it doesn't own, they are
+// indexed under configMapsIndexField. The WebServer is reconciled again when one of them changes.
+func referencedConfigMaps(t *webserversv1alpha1.WebServer) []string {
+	configMaps, _ := podConfigMapsAndSecrets(t)
+	return configMaps
+}
+
+// indexReferences indexes the WebServers by the names of the Secrets and of the ConfigMaps they reference, the
+// WebServers referencing a changed object are then listed from the cache without reading all the WebServers
+func indexReferences(mgr manager.Manager) error {
+	err := mgr.GetFieldIndexer().IndexField(&webserversv1alpha1.WebServer{}, secretsIndexField, func(obj runtime.Object) []string {
+		return referencedSecrets(obj.(*webserversv1alpha1.WebServer))
+	})
+	if err != nil {
+		return err
+	}
+	return mgr.GetFieldIndexer().IndexField(&webserversv1alpha1.WebServer{}, configMapsIndexField, func(obj runtime.Object) []string {
+		return referencedConfigMaps(obj.(*webserversv1alpha1.WebServer))
+	})
+}
+
+// referencingWebServers maps a Secret or a ConfigMap to the requests of the WebServers of its namespace referencing
+// it, the WebServers are listed with the index of the references of that kind
+func referencingWebServers(c client.Client, kind string, indexField string) handler.ToRequestsFunc {
+	return func(obj handler.MapObject) []reconcile.Request {
+		webServers := &webserversv1alpha1.WebServerList{}
+		err := c.List(context.TODO(), webServers, client.InNamespace(obj.Meta.GetNamespace()), client.MatchingFields{indexField: obj.Meta.GetName()})
+		if err != nil {
+			log.Error(err, "Failed to list the WebServers referencing a "+kind+".", kind+".Namespace", obj.Meta.GetNamespace(), kind+".Name", obj.Meta.GetName())
+			return nil
+		}
+		requests := []reconcile.Request{}
+		for _, webServer := range webServers.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: webServer.Name, Namespace: webServer.Namespace}})
+		}
+		return requests
+	}
+}
+
+// configHash returns the hash of the data of the ConfigMaps and Secrets read by the pods, an empty string when
+// there are none. A missing ConfigMap or Secret is skipped: the pods wait for it, or ignore it when it is optional,
+// and are rolled out once it is created.
+func (r *ReconcileWebServer) configHash(t *webserversv1alpha1.WebServer) (string, error) {
+	configMaps, secrets := podConfigMapsAndSecrets(t)
+	if len(configMaps) == 0 && len(secrets) == 0 {
+		return "", nil
+	}
+	sort.Strings(configMaps)
+	sort.Strings(secrets)
+	hasher := fnv.New32a()
+	for _, name := range configMaps {
+		configMap := &corev1.ConfigMap{}
+		err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: t.Namespace}, configMap)
+		if err != nil && errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return "", err
+		}
+		data := map[string][]byte{}
+		for key, value := range configMap.Data {
+			data[key] = []byte(value)
+		}
+		for key, value := range configMap.BinaryData {
+			data[key] = value
+		}
+		writeField(hasher, []byte("ConfigMap/"+name))
+		writeData(hasher, data)
+	}
+	for _, name := range secrets {
+		secret := &corev1.Secret{}
+		err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: t.Namespace}, secret)
+		if err != nil && errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return "", err
+		}
+		writeField(hasher, []byte("Secret/"+name))
+		writeData(hasher, secret.Data)
+	}
+	return strconv.FormatUint(uint64(hasher.Sum32()), 16), nil
+}
+
+// writeData writes the keys and the values of a ConfigMap or a Secret to the hasher in the order of the keys
+func writeData(hasher hash.Hash, data map[string][]byte) {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		writeField(hasher, []byte(key))
+		writeField(hasher, data[key])
+	}
+}
+
+// writeField writes the length of the field before the field, the boundaries between the fields change the hash
+func writeField(hasher hash.Hash, field []byte) {
+	length := make([]byte, 8)
+	binary.BigEndian.PutUint64(length, uint64(len(field)))
+	hasher.Write(length)
+	hasher.Write(field)
+}
+
+// podTemplateDriftChange describes the update of a pod template modified outside of the WebServer
+const podTemplateDriftChange = "pod template, reverted the changes made outside of the WebServer"
+
+// podTemplateChange describes the change of the pod template of a Deployment or a DeploymentConfig, the hash
+// annotations tell when the configuration read by the pods changed
+func podTemplateChange(found corev1.PodTemplateSpec, desired corev1.PodTemplateSpec) string {
+	changes := []struct {
+		annotation  string
+		description string
+	}{
+		{configHashAnnotation, "ConfigMaps and Secrets"},
+		{tlsCertificateHashAnnotation, "certificate"},
+		{sessionStoreHashAnnotation, "session store configuration"},
+		{serverXmlHashAnnotation, "server.xml"},
+	}
+	changed := []string{}
+	for _, change := range changes {
+		if found.Annotations[change.annotation] != desired.Annotations[change.annotation] {
+			changed = append(changed, change.description)
+		}
+	}
+	if len(changed) == 0 {
+		return "pod template"
+	}
+	return "pod template, changed " + strings.Join(changed, ", ")
+}
@@ -0,0 +1,102 @@
+package webserver
+
+import (
+	"hash/fnv"
+	"reflect"
+	"testing"
+
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodTemplateChange(t *testing.T) {
+	template := func(annotations map[string]string) corev1.PodTemplateSpec {
+		return corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Annotations: annotations}}
+	}
+	found := template(map[string]string{configHashAnnotation: "1", serverXmlHashAnnotation: "a"})
+	tests := []struct {
+		name    string
+		desired corev1.PodTemplateSpec
+		change  string
+	}{
+		{
+			name:    "pod template",
+			desired: template(map[string]string{configHashAnnotation: "1", serverXmlHashAnnotation: "a"}),
+			change:  "pod template",
+		},
+		{
+			name:    "config",
+			desired: template(map[string]string{configHashAnnotation: "2", serverXmlHashAnnotation: "a"}),
+			change:  "pod template, changed ConfigMaps and Secrets",
+		},
+		{
+			name:    "config removed",
+			desired: template(map[string]string{serverXmlHashAnnotation: "b"}),
+			change:  "pod template, changed ConfigMaps and Secrets, server.xml",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if change := podTemplateChange(found, test.desired); change != test.change {
+				t.Errorf("got %q, expected %q", change, test.change)
+			}
+		})
+	}
+}
+
+func TestPodConfigMapsAndSecrets(t *testing.T) {
+	webServer := &webserversv1alpha1.WebServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "test"},
+		Spec: webserversv1alpha1.WebServerSpec{
+			EnvFrom: []corev1.EnvFromSource{{
+				ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "example-env"}},
+			}},
+			Volumes: []corev1.Volume{
+				{Name: "config", VolumeSource: corev1.VolumeSource{
+					ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "example-config"}},
+				}},
+				{Name: "projected", VolumeSource: corev1.VolumeSource{
+					Projected: &corev1.ProjectedVolumeSource{Sources: []corev1.VolumeProjection{
+						{Secret: &corev1.SecretProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "example-credentials"}}},
+					}},
+				}},
+				{Name: "webserver-example", VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{SecretName: "ignored"},
+				}},
+			},
+			Sidecars: []corev1.Container{{
+				Name: "log-shipper",
+				EnvFrom: []corev1.EnvFromSource{{
+					SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "example-shipper"}},
+				}},
+			}},
+		},
+	}
+
+	configMaps, secrets := podConfigMapsAndSecrets(webServer)
+	if expected := []string{"example-env", "example-config"}; !reflect.DeepEqual(configMaps, expected) {
+		t.Errorf("got ConfigMaps %v, expected %v", configMaps, expected)
+	}
+	if expected := []string{"example-shipper", "example-credentials"}; !reflect.DeepEqual(secrets, expected) {
+		t.Errorf("got Secrets %v, expected %v", secrets, expected)
+	}
+}
+
+func TestWriteData(t *testing.T) {
+	hash := func(data map[string][]byte) uint32 {
+		hasher := fnv.New32a()
+		writeData(hasher, data)
+		return hasher.Sum32()
+	}
+	if hash(map[string][]byte{"a": []byte("bc")}) == hash(map[string][]byte{"ab": []byte("c")}) {
+		t.Error("moving the boundary between a key and its value didn't change the hash")
+	}
+	if hash(map[string][]byte{"a": []byte("b"), "c": nil}) == hash(map[string][]byte{"a": []byte("bc")}) {
+		t.Error("moving a key into a value didn't change the hash")
+	}
+	if hash(map[string][]byte{"a": []byte("1"), "b": []byte("2")}) != hash(map[string][]byte{"b": []byte("2"), "a": []byte("1")}) {
+		t.Error("the hash depends on the order of the keys")
+	}
+}
@@ -0,0 +1,227 @@
+package webserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// servingLabel is the label of the pods selected by the Service, it is set to false on the pods being drained
+	servingLabel = "web.servers.org/serving"
+	// drainingSinceAnnotation is the annotation of a draining pod holding the time the draining started
+	drainingSinceAnnotation = "web.servers.org/draining-since"
+	// podDeletionCostAnnotation makes the ReplicaSet or the ReplicationController of Kubernetes 1.22 and later remove
+	// the draining pods on scale down, the older ones ignore it so the drained pods are deleted by the operator
+	podDeletionCostAnnotation = "controller.kubernetes.io/pod-deletion-cost"
+	// jolokiaPort is the port of the Jolokia agent of the pods
+	jolokiaPort = 8778
+	// activeSessionsRequest is the Jolokia request reading the active sessions of all the applications of Tomcat
+	activeSessionsRequest = "jolokia/read/Catalina:type=Manager,context=*,host=*/activeSessions"
+	// drainingRequeueDelay is the delay between two checks of the sessions of the draining pods
+	drainingRequeueDelay = 10 * time.Second
+)
+
+// isServing returns whether the pod is selected by the Service
+func isServing(pod *corev1.Pod) bool {
+	return pod.Labels[servingLabel] != "false"
+}
+
+// isReady returns whether the pod is ready to serve requests
+func isReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// labelServingPods sets the serving label on the pods deployed before it was added to the pod template,
+// the Service only selects the pods having it
+func (r *ReconcileWebServer) labelServingPods(t *webserversv1alpha1.WebServer) error {
+	podList, err := GetPodsForWebServer(r, t)
+	if err != nil {
+		return err
+	}
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if _, found := pod.Labels[servingLabel]; !found {
+			if err := r.setServing(pod, true); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// setServing adds the pod to the Service or removes it from the Service to drain its sessions
+func (r *ReconcileWebServer) setServing(pod *corev1.Pod, serving bool) error {
+	patch := client.MergeFrom(pod.DeepCopy())
+	if pod.Labels == nil {
+		pod.Labels = map[string]string{}
+	}
+	if pod.Annotations == nil {
+		pod.Annotations = map[string]string{}
+	}
+	pod.Labels[servingLabel] = strconv.FormatBool(serving)
+	if serving {
+		delete(pod.Annotations, drainingSinceAnnotation)
+		delete(pod.Annotations, podDeletionCostAnnotation)
+	} else {
+		pod.Annotations[drainingSinceAnnotation] = time.Now().UTC().Format(time.RFC3339)
+		pod.Annotations[podDeletionCostAnnotation] = "-1"
+	}
+	return r.client.Patch(context.TODO(), pod, patch)
+}
+
+// drainPods drains count pods before a scale down: the pods are removed from the Service and wait for their sessions
+// to expire. The pods drained by a previous scale down serve again when the replicas are raised. It returns whether
+// the replicas can be lowered: the drained pods are then deleted so that the ReplicaSet or the ReplicationController
+// doesn't scale down a serving pod instead. A pod it creates meanwhile isn't ready and is scaled down first.
+func (r *ReconcileWebServer) drainPods(t *webserversv1alpha1.WebServer, count int32) (bool, error) {
+	if t.Spec.SessionDraining == nil || count < 0 {
+		count = 0
+	}
+	podList, err := GetPodsForWebServer(r, t)
+	if err != nil {
+		return false, err
+	}
+	serving := []*corev1.Pod{}
+	draining := []*corev1.Pod{}
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		if isServing(pod) {
+			serving = append(serving, pod)
+		} else {
+			draining = append(draining, pod)
+		}
+	}
+
+	for int32(len(draining)) > count {
+		pod := draining[len(draining)-1]
+		draining = draining[:len(draining)-1]
+		if err := r.setServing(pod, true); err != nil {
+			return false, err
+		}
+		r.recorder.Eventf(t, corev1.EventTypeNormal, "DrainingCancelled", "Pod %s is no longer scaled down and serves requests again", pod.Name)
+	}
+	sortPodsToDrain(serving)
+	for int32(len(draining)) < count && len(serving) > 0 {
+		pod := serving[0]
+		serving = serving[1:]
+		if err := r.setServing(pod, false); err != nil {
+			return false, err
+		}
+		r.recorder.Eventf(t, corev1.EventTypeNormal, "Draining", "Draining the sessions of pod %s before scaling down", pod.Name)
+		draining = append(draining, pod)
+	}
+
+	for _, pod := range draining {
+		if !r.isDrained(pod, time.Duration(t.Spec.SessionDraining.TimeoutSeconds)*time.Second) {
+			return false, nil
+		}
+	}
+	for _, pod := range draining {
+		reqLogger.Info("Deleting the drained pod.", "Pod.Namespace", pod.Namespace, "Pod.Name", pod.Name)
+		if err := r.client.Delete(context.TODO(), pod); err != nil && !errors.IsNotFound(err) {
+			return false, err
+		}
+		r.recorder.Eventf(t, corev1.EventTypeNormal, "Drained", "Deleted pod %s, its sessions are drained", pod.Name)
+	}
+	return true, nil
+}
+
+// sortPodsToDrain sorts the pods in the order a ReplicaSet scales them down: the pods which aren't ready first,
+// then the most recent ones
+func sortPodsToDrain(pods []*corev1.Pod) {
+	sort.SliceStable(pods, func(i, j int) bool {
+		if isReady(pods[i]) != isReady(pods[j]) {
+			return !isReady(pods[i])
+		}
+		return pods[j].CreationTimestamp.Before(&pods[i].CreationTimestamp)
+	})
+}
+
+// isDrained returns whether a draining pod can be scaled down: it has no active session left, it isn't running
+// or the timeout of the draining is reached
+func (r *ReconcileWebServer) isDrained(pod *corev1.Pod, timeout time.Duration) bool {
+	if pod.Status.Phase != corev1.PodRunning {
+		return true
+	}
+	since, err := time.Parse(time.RFC3339, pod.Annotations[drainingSinceAnnotation])
+	if err != nil || time.Since(since) >= timeout {
+		reqLogger.Info("The draining of the pod timed out.", "Pod.Name", pod.Name)
+		return true
+	}
+	sessions, err := r.activeSessions(pod)
+	if err != nil {
+		// The draining pod is scaled down when the timeout is reached
+		reqLogger.Info("Failed to read the active sessions of the pod: "+err.Error(), "Pod.Name", pod.Name)
+		return false
+	}
+	reqLogger.Info(fmt.Sprintf("The draining pod has %d active sessions.", sessions), "Pod.Name", pod.Name)
+	return sessions == 0
+}
+
+// activeSessions returns the number of active sessions of all the applications of the pod, read with Jolokia through
+// the proxy of the API server. The Jolokia agent of the JWS images listens with HTTPS, other images may use HTTP.
+func (r *ReconcileWebServer) activeSessions(pod *corev1.Pod) (int, error) {
+	var err error
+	for _, scheme := range []string{"https", "http"} {
+		var body []byte
+		body, err = r.podProxy.Get().
+			Namespace(pod.Namespace).
+			Resource("pods").
+			Name(fmt.Sprintf("%s:%s:%d", scheme, pod.Name, jolokiaPort)).
+			SubResource("proxy").
+			Suffix(activeSessionsRequest).
+			DoRaw()
+		if err == nil {
+			return parseActiveSessions(body)
+		}
+	}
+	return 0, err
+}
+
+// jolokiaReadResponse is the response of a Jolokia read request matching several MBeans: the value maps the names
+// of the MBeans to their attributes
+type jolokiaReadResponse struct {
+	Status int                       `json:"status"`
+	Error  string                    `json:"error"`
+	Value  map[string]map[string]int `json:"value"`
+}
+
+// parseActiveSessions returns the sum of the active sessions of the session managers of a Jolokia response
+func parseActiveSessions(body []byte) (int, error) {
+	response := &jolokiaReadResponse{}
+	if err := json.Unmarshal(body, response); err != nil {
+		return 0, err
+	}
+	switch response.Status {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		// No application is deployed so there is no session manager
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("Jolokia request failed with status %d: %s", response.Status, response.Error)
+	}
+	sessions := 0
+	for _, attributes := range response.Value {
+		sessions += attributes["activeSessions"]
+	}
+	return sessions, nil
+}
@@ -0,0 +1,284 @@
+package webserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kubescheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+func TestParseActiveSessions(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		sessions int
+		fails    bool
+	}{
+		{
+			name: "applications",
+			body: `{"request":{"mbean":"Catalina:context=*,host=*,type=Manager","attribute":"activeSessions","type":"read"},` +
+				`"value":{"Catalina:context=/,host=localhost,type=Manager":{"activeSessions":2},` +
+				`"Catalina:context=/example,host=localhost,type=Manager":{"activeSessions":3}},"status":200}`,
+			sessions: 5,
+		},
+		{
+			name:     "no application",
+			body:     `{"error_type":"javax.management.InstanceNotFoundException","error":"No matching MBean","status":404}`,
+			sessions: 0,
+		},
+		{
+			name:  "forbidden",
+			body:  `{"error_type":"java.lang.Exception","error":"Access denied","status":403}`,
+			fails: true,
+		},
+		{
+			name:  "not Jolokia",
+			body:  `<html></html>`,
+			fails: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sessions, err := parseActiveSessions([]byte(test.body))
+			if test.fails {
+				if err == nil {
+					t.Errorf("expected an error, got %d sessions", sessions)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sessions != test.sessions {
+				t.Errorf("got %d sessions, expected %d", sessions, test.sessions)
+			}
+		})
+	}
+}
+
+func TestSortPodsToDrain(t *testing.T) {
+	now := time.Now()
+	pod := func(name string, age time.Duration, ready corev1.ConditionStatus) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(now.Add(-age))},
+			Status:     corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: ready}}},
+		}
+	}
+	pods := []*corev1.Pod{
+		pod("old", time.Hour, corev1.ConditionTrue),
+		pod("new", time.Minute, corev1.ConditionTrue),
+		pod("old-not-ready", 2*time.Hour, corev1.ConditionFalse),
+		pod("middle", 10*time.Minute, corev1.ConditionTrue),
+	}
+	sortPodsToDrain(pods)
+
+	names := []string{}
+	for _, pod := range pods {
+		names = append(names, pod.Name)
+	}
+	expected := []string{"old-not-ready", "new", "middle", "old"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("got %v, expected %v", names, expected)
+	}
+}
+
+// jolokiaProxy returns a pod proxy answering the Jolokia requests with the active sessions of the pods,
+// the requests to the pods without sessions fail
+func jolokiaProxy(t *testing.T, sessions map[string]int) rest.Interface {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		for name, count := range sessions {
+			if strings.Contains(req.URL.Path, "/pods/https:"+name+":") {
+				fmt.Fprintf(w, `{"value":{"Catalina:context=/,host=localhost,type=Manager":{"activeSessions":%d}},"status":200}`, count)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+	podProxy, err := rest.RESTClientFor(&rest.Config{
+		Host:    server.URL,
+		APIPath: "/api",
+		ContentConfig: rest.ContentConfig{
+			GroupVersion:         &corev1.SchemeGroupVersion,
+			NegotiatedSerializer: kubescheme.Codecs,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return podProxy
+}
+
+// drainingWebServer returns a WebServer draining the sessions of its pods for a minute
+func drainingWebServer() *webserversv1alpha1.WebServer {
+	return &webserversv1alpha1.WebServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "example-webserver", Namespace: "jws"},
+		Spec: webserversv1alpha1.WebServerSpec{
+			ApplicationName: "example",
+			Replicas:        1,
+			WebImage:        &webserversv1alpha1.WebImageSpec{ApplicationImage: "quay.io/example/tomcat:1.0"},
+			SessionDraining: &webserversv1alpha1.SessionDrainingSpec{TimeoutSeconds: 60},
+		},
+	}
+}
+
+// podToDrain returns a running and ready pod of the WebServer created age ago, draining since drainingFor when it
+// isn't zero
+func podToDrain(t *webserversv1alpha1.WebServer, name string, age time.Duration, drainingFor time.Duration) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         t.Namespace,
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+			Labels:            map[string]string{"deploymentConfig": t.Spec.ApplicationName, "WebServer": t.Name, servingLabel: "true"},
+			Annotations:       map[string]string{},
+		},
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+		},
+	}
+	if drainingFor != 0 {
+		pod.Labels[servingLabel] = "false"
+		pod.Annotations[drainingSinceAnnotation] = time.Now().Add(-drainingFor).UTC().Format(time.RFC3339)
+		pod.Annotations[podDeletionCostAnnotation] = "-1"
+	}
+	return pod
+}
+
+// getPod returns the pod of the fake client, nil when it was deleted
+func getPod(t *testing.T, r *ReconcileWebServer, name string) *corev1.Pod {
+	pod := &corev1.Pod{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: "jws"}, pod)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return pod
+}
+
+func TestDrainPods(t *testing.T) {
+	webServer := drainingWebServer()
+	r := newTestReconciler(t, podToDrain(webServer, "example-old", time.Hour, 0), podToDrain(webServer, "example-new", time.Minute, 0))
+	r.podProxy = jolokiaProxy(t, map[string]int{"example-old": 3, "example-new": 2})
+
+	drained, err := r.drainPods(webServer, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if drained {
+		t.Error("got drained, expected the replicas to be kept while the pod has active sessions")
+	}
+	if !recordedEvent(r, "Draining") {
+		t.Error("expected a Draining event")
+	}
+	pod := getPod(t, r, "example-new")
+	if pod == nil || isServing(pod) || pod.Annotations[drainingSinceAnnotation] == "" || pod.Annotations[podDeletionCostAnnotation] != "-1" {
+		t.Errorf("got %v, expected the most recent pod to be draining", pod)
+	}
+	if pod := getPod(t, r, "example-old"); pod == nil || !isServing(pod) {
+		t.Errorf("got %v, expected the oldest pod to keep serving", pod)
+	}
+}
+
+func TestDrainPodsWithoutActiveSessions(t *testing.T) {
+	webServer := drainingWebServer()
+	r := newTestReconciler(t, podToDrain(webServer, "example-old", time.Hour, 0), podToDrain(webServer, "example-new", time.Minute, time.Second))
+	r.podProxy = jolokiaProxy(t, map[string]int{"example-old": 3, "example-new": 0})
+
+	drained, err := r.drainPods(webServer, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !drained {
+		t.Error("got not drained, expected the replicas to be lowered without active sessions")
+	}
+	if !recordedEvent(r, "Drained") {
+		t.Error("expected a Drained event")
+	}
+	// The ReplicaSet may ignore the pod deletion cost, the drained pod is deleted rather than a serving one
+	if pod := getPod(t, r, "example-new"); pod != nil {
+		t.Errorf("got %v, expected the drained pod to be deleted", pod)
+	}
+	if pod := getPod(t, r, "example-old"); pod == nil || !isServing(pod) {
+		t.Errorf("got %v, expected the serving pod to be kept", pod)
+	}
+}
+
+func TestDrainPodsTimeout(t *testing.T) {
+	webServer := drainingWebServer()
+	r := newTestReconciler(t, podToDrain(webServer, "example-old", time.Hour, 0), podToDrain(webServer, "example-new", time.Minute, 2*time.Minute))
+	r.podProxy = jolokiaProxy(t, map[string]int{"example-old": 3, "example-new": 2})
+
+	drained, err := r.drainPods(webServer, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !drained {
+		t.Error("got not drained, expected the replicas to be lowered once the timeout is reached")
+	}
+	if pod := getPod(t, r, "example-new"); pod != nil {
+		t.Errorf("got %v, expected the pod to be deleted at the timeout", pod)
+	}
+}
+
+func TestDrainPodsCancelledOnScaleUp(t *testing.T) {
+	webServer := drainingWebServer()
+	r := newTestReconciler(t, podToDrain(webServer, "example-old", time.Hour, 0), podToDrain(webServer, "example-new", time.Minute, time.Second))
+	r.podProxy = jolokiaProxy(t, map[string]int{"example-old": 3, "example-new": 2})
+
+	drained, err := r.drainPods(webServer, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !drained {
+		t.Error("got not drained, expected nothing to wait for once the replicas are raised")
+	}
+	if !recordedEvent(r, "DrainingCancelled") {
+		t.Error("expected a DrainingCancelled event")
+	}
+	pod := getPod(t, r, "example-new")
+	if pod == nil || !isServing(pod) || pod.Annotations[drainingSinceAnnotation] != "" || pod.Annotations[podDeletionCostAnnotation] != "" {
+		t.Errorf("got %v, expected the pod to serve again", pod)
+	}
+}
+
+func TestIsDrained(t *testing.T) {
+	webServer := drainingWebServer()
+	r := newTestReconciler(t)
+	r.podProxy = jolokiaProxy(t, map[string]int{"example-idle": 0, "example-active": 2})
+	stopped := podToDrain(webServer, "example-stopped", time.Minute, time.Second)
+	stopped.Status.Phase = corev1.PodSucceeded
+	tests := []struct {
+		name    string
+		pod     *corev1.Pod
+		drained bool
+	}{
+		{name: "no active session", pod: podToDrain(webServer, "example-idle", time.Minute, time.Second), drained: true},
+		{name: "active sessions", pod: podToDrain(webServer, "example-active", time.Minute, time.Second), drained: false},
+		{name: "Jolokia unreachable", pod: podToDrain(webServer, "example-unreachable", time.Minute, time.Second), drained: false},
+		{name: "timeout", pod: podToDrain(webServer, "example-active", time.Minute, 2*time.Minute), drained: true},
+		{name: "not running", pod: stopped, drained: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if drained := r.isDrained(test.pod, time.Minute); drained != test.drained {
+				t.Errorf("got %t, expected %t", drained, test.drained)
+			}
+		})
+	}
+}
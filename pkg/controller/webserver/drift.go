@@ -0,0 +1,331 @@
+package webserver
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+
+	appsv1 "github.com/openshift/api/apps/v1"
+	buildv1 "github.com/openshift/api/build/v1"
+	imagev1 "github.com/openshift/api/image/v1"
+	routev1 "github.com/openshift/api/route/v1"
+	kbappsv1 "k8s.io/api/apps/v1"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	rbac "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// managedAnnotationsAnnotation is the annotation holding the keys of the annotations set by the operator on a Route
+// or an Ingress, they are removed when they are removed from the WebServer
+const managedAnnotationsAnnotation = "web.servers.org/managed-annotations"
+
+// ownedObject is a resource created by the operator for a WebServer
+type ownedObject interface {
+	runtime.Object
+	metav1.Object
+}
+
+// The sync functions below compare the desired state of a resource, as generated from the WebServer,
+// with the state found in the cluster. When they diverge the found resource is modified in place to
+// match the desired state and true is returned, the caller is then responsible for updating it.
+// Fields left empty in the desired state are considered as defaulted by the API server and are ignored.
+
+// derivative returns true if the found value matches the desired one, ignoring unset desired fields
+func derivative(desired interface{}, found interface{}) bool {
+	return equality.Semantic.DeepDerivative(desired, found)
+}
+
+// syncLabels adds the labels of the desired object missing on the found object
+func syncLabels(desired metav1.Object, found metav1.Object) bool {
+	if derivative(desired.GetLabels(), found.GetLabels()) {
+		return false
+	}
+	labels := found.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	for key, value := range desired.GetLabels() {
+		labels[key] = value
+	}
+	found.SetLabels(labels)
+	return true
+}
+
+// setManagedAnnotations lists the annotations of the desired object in its managed annotations annotation,
+// syncAnnotations removes the ones no longer desired
+func setManagedAnnotations(desired metav1.Object) {
+	annotations := desired.GetAnnotations()
+	keys := []string{}
+	for key := range annotations {
+		if key != managedAnnotationsAnnotation {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	annotations[managedAnnotationsAnnotation] = strings.Join(keys, ",")
+	desired.SetAnnotations(annotations)
+}
+
+// syncAnnotations adds the annotations of the desired object missing on the found object and removes the annotations
+// managed by the operator which are no longer desired, the annotations added by the users are kept
+func syncAnnotations(desired metav1.Object, found metav1.Object) bool {
+	annotations := found.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	updated := false
+	for _, key := range strings.Split(annotations[managedAnnotationsAnnotation], ",") {
+		if _, desired := desired.GetAnnotations()[key]; !desired && key != "" {
+			if _, found := annotations[key]; found {
+				delete(annotations, key)
+				updated = true
+			}
+		}
+	}
+	for key, value := range desired.GetAnnotations() {
+		if foundValue, found := annotations[key]; !found || foundValue != value {
+			annotations[key] = value
+			updated = true
+		}
+	}
+	found.SetAnnotations(annotations)
+	return updated
+}
+
+func syncService(desired *corev1.Service, found *corev1.Service) bool {
+	updated := syncLabels(desired, found)
+	if len(desired.Spec.Ports) != len(found.Spec.Ports) || !derivative(desired.Spec.Ports, found.Spec.Ports) {
+		found.Spec.Ports = desired.Spec.Ports
+		updated = true
+	}
+	if !reflect.DeepEqual(desired.Spec.Selector, found.Spec.Selector) {
+		found.Spec.Selector = desired.Spec.Selector
+		updated = true
+	}
+	return updated
+}
+
+func syncRoleBinding(desired *rbac.RoleBinding, found *rbac.RoleBinding) bool {
+	updated := syncLabels(desired, found)
+	if len(desired.Subjects) != len(found.Subjects) || !derivative(desired.Subjects, found.Subjects) {
+		found.Subjects = desired.Subjects
+		updated = true
+	}
+	return updated
+}
+
+func syncDeploymentStrategy(desired *kbappsv1.Deployment, found *kbappsv1.Deployment) bool {
+	if derivative(desired.Spec.Strategy, found.Spec.Strategy) && desired.Spec.MinReadySeconds == found.Spec.MinReadySeconds {
+		return false
+	}
+	// The parameters of the previous strategy are replaced, they are rejected with another type
+	found.Spec.Strategy = desired.Spec.Strategy
+	found.Spec.MinReadySeconds = desired.Spec.MinReadySeconds
+	return true
+}
+
+func syncDeploymentConfigStrategy(desired *appsv1.DeploymentConfig, found *appsv1.DeploymentConfig) bool {
+	if derivative(desired.Spec.Strategy, found.Spec.Strategy) && desired.Spec.MinReadySeconds == found.Spec.MinReadySeconds {
+		return false
+	}
+	// The parameters of the previous strategy are replaced, they are rejected with another type
+	found.Spec.Strategy = desired.Spec.Strategy
+	found.Spec.MinReadySeconds = desired.Spec.MinReadySeconds
+	return true
+}
+
+func syncRole(desired *rbac.Role, found *rbac.Role) bool {
+	updated := syncLabels(desired, found)
+	if len(desired.Rules) != len(found.Rules) || !derivative(desired.Rules, found.Rules) {
+		found.Rules = desired.Rules
+		updated = true
+	}
+	return updated
+}
+
+func syncConfigMap(desired *corev1.ConfigMap, found *corev1.ConfigMap) bool {
+	updated := syncLabels(desired, found)
+	if !reflect.DeepEqual(desired.Data, found.Data) {
+		found.Data = desired.Data
+		updated = true
+	}
+	return updated
+}
+
+func syncSecret(desired *corev1.Secret, found *corev1.Secret) bool {
+	updated := syncLabels(desired, found)
+	if !reflect.DeepEqual(desired.Data, found.Data) {
+		found.Data = desired.Data
+		updated = true
+	}
+	return updated
+}
+
+func syncRoute(desired *routev1.Route, found *routev1.Route) bool {
+	updated := syncLabels(desired, found)
+	if syncAnnotations(desired, found) {
+		updated = true
+	}
+	if (desired.Spec.TLS == nil) != (found.Spec.TLS == nil) || (desired.Spec.Port == nil) != (found.Spec.Port == nil) ||
+		len(desired.Spec.AlternateBackends) != len(found.Spec.AlternateBackends) || !derivative(desired.Spec, found.Spec) {
+		host := found.Spec.Host
+		found.Spec = desired.Spec
+		if found.Spec.Host == "" {
+			// Keep the host generated by the router
+			found.Spec.Host = host
+		}
+		updated = true
+	}
+	return updated
+}
+
+func syncIngress(desired *networkingv1beta1.Ingress, found *networkingv1beta1.Ingress) bool {
+	updated := syncLabels(desired, found)
+	if syncAnnotations(desired, found) {
+		updated = true
+	}
+	if len(desired.Spec.TLS) != len(found.Spec.TLS) || !derivative(desired.Spec, found.Spec) {
+		found.Spec = desired.Spec
+		updated = true
+	}
+	return updated
+}
+
+func syncHorizontalPodAutoscaler(desired *autoscalingv2beta2.HorizontalPodAutoscaler, found *autoscalingv2beta2.HorizontalPodAutoscaler) bool {
+	updated := syncLabels(desired, found)
+	if len(desired.Spec.Metrics) != len(found.Spec.Metrics) || !derivative(desired.Spec, found.Spec) {
+		found.Spec = desired.Spec
+		updated = true
+	}
+	return updated
+}
+
+func syncCertificate(desired *unstructured.Unstructured, found *unstructured.Unstructured) bool {
+	updated := syncLabels(desired, found)
+	if !derivative(desired.Object["spec"], found.Object["spec"]) {
+		found.Object["spec"] = desired.Object["spec"]
+		updated = true
+	}
+	return updated
+}
+
+func syncImageStream(desired *imagev1.ImageStream, found *imagev1.ImageStream) bool {
+	return syncLabels(desired, found)
+}
+
+func syncBuildConfig(desired *buildv1.BuildConfig, found *buildv1.BuildConfig) bool {
+	updated := syncLabels(desired, found)
+	if !derivative(desired.Spec.Source, found.Spec.Source) {
+		found.Spec.Source = desired.Spec.Source
+		updated = true
+	}
+	if !derivative(desired.Spec.Strategy, found.Spec.Strategy) || found.Spec.Strategy.SourceStrategy == nil ||
+		len(desired.Spec.Strategy.SourceStrategy.Env) != len(found.Spec.Strategy.SourceStrategy.Env) {
+		found.Spec.Strategy = desired.Spec.Strategy
+		updated = true
+	}
+	if !derivative(desired.Spec.Output, found.Spec.Output) {
+		found.Spec.Output = desired.Spec.Output
+		updated = true
+	}
+	if !derivative(desired.Spec.Resources, found.Spec.Resources) {
+		found.Spec.Resources = desired.Spec.Resources
+		updated = true
+	}
+	if len(desired.Spec.Triggers) != len(found.Spec.Triggers) || !derivative(desired.Spec.Triggers, found.Spec.Triggers) {
+		found.Spec.Triggers = desired.Spec.Triggers
+		updated = true
+	}
+	return updated
+}
+
+// syncDeploymentConfig synchronizes the DeploymentConfig fields outside of the pod template
+func syncDeploymentConfig(desired *appsv1.DeploymentConfig, found *appsv1.DeploymentConfig) bool {
+	updated := syncLabels(desired, found)
+	if desired.Spec.Strategy.Type != found.Spec.Strategy.Type {
+		found.Spec.Strategy = desired.Spec.Strategy
+		updated = true
+	}
+	if len(desired.Spec.Triggers) != len(found.Spec.Triggers) || !derivative(desired.Spec.Triggers, found.Spec.Triggers) {
+		// Keep the last triggered images to prevent useless deployments
+		for _, trigger := range desired.Spec.Triggers {
+			if trigger.ImageChangeParams == nil {
+				continue
+			}
+			for _, foundTrigger := range found.Spec.Triggers {
+				if foundTrigger.ImageChangeParams != nil && foundTrigger.ImageChangeParams.From == trigger.ImageChangeParams.From {
+					trigger.ImageChangeParams.LastTriggeredImage = foundTrigger.ImageChangeParams.LastTriggeredImage
+				}
+			}
+		}
+		found.Spec.Triggers = desired.Spec.Triggers
+		updated = true
+	}
+	return updated
+}
+
+// podTemplateDrifted returns true when the pod template found in the cluster was modified since it was generated
+// from the WebServer, for example with kubectl set env: a field of the desired template differs or the found
+// template has additional containers, volumes, variables, mounts or ports
+func podTemplateDrifted(desired corev1.PodTemplateSpec, found corev1.PodTemplateSpec) bool {
+	if !derivative(desired, found) || len(desired.Spec.Volumes) != len(found.Spec.Volumes) ||
+		len(desired.Spec.InitContainers) != len(found.Spec.InitContainers) || len(desired.Spec.Containers) != len(found.Spec.Containers) {
+		return true
+	}
+	containers := append(append([]corev1.Container{}, desired.Spec.InitContainers...), desired.Spec.Containers...)
+	foundContainers := append(append([]corev1.Container{}, found.Spec.InitContainers...), found.Spec.Containers...)
+	for i, container := range containers {
+		foundContainer := foundContainers[i]
+		if len(container.Env) != len(foundContainer.Env) || len(container.EnvFrom) != len(foundContainer.EnvFrom) ||
+			len(container.VolumeMounts) != len(foundContainer.VolumeMounts) || len(container.Ports) != len(foundContainer.Ports) {
+			return true
+		}
+	}
+	return false
+}
+
+// buildPodDrifted returns true when the build pod doesn't build the application described in the WebServer.
+// Pods are immutable so the build pod needs to be recreated.
+func buildPodDrifted(desired *corev1.Pod, found *corev1.Pod) bool {
+	return !reflect.DeepEqual(desired.Spec.Containers[0].Image, found.Spec.Containers[0].Image) ||
+		!reflect.DeepEqual(desired.Spec.Containers[0].Args, found.Spec.Containers[0].Args) ||
+		!reflect.DeepEqual(desired.Spec.Containers[0].Env, found.Spec.Containers[0].Env) ||
+		!derivative(desired.Spec.Containers[0].Resources, found.Spec.Containers[0].Resources)
+}
+
+// updateOwnedObject updates a resource which diverged from the state described in the WebServer
+func (r *ReconcileWebServer) updateOwnedObject(t *webserversv1alpha1.WebServer, kind string, obj ownedObject) (reconcile.Result, error) {
+	reqLogger.Info("The "+kind+" does not match the WebServer specification, updating it.", kind+".Namespace", obj.GetNamespace(), kind+".Name", obj.GetName())
+	setProgressing(t, "Updating"+kind, "Updating "+kind+" "+obj.GetName())
+	err := r.client.Update(context.TODO(), obj)
+	if err != nil {
+		reqLogger.Error(err, "Failed to update "+kind+".", kind+".Namespace", obj.GetNamespace(), kind+".Name", obj.GetName())
+		return reconcile.Result{}, err
+	}
+	r.recorder.Eventf(t, corev1.EventTypeNormal, "Updated", "Updated %s %s to match the WebServer specification", kind, obj.GetName())
+	// Resource updated - return and requeue
+	return reconcile.Result{Requeue: true}, nil
+}
+
+// deleteOwnedObject deletes a resource which can't be updated to match the WebServer, it is recreated on the next reconciliation
+func (r *ReconcileWebServer) deleteOwnedObject(t *webserversv1alpha1.WebServer, kind string, obj ownedObject) (reconcile.Result, error) {
+	reqLogger.Info("The "+kind+" does not match the WebServer specification, recreating it.", kind+".Namespace", obj.GetNamespace(), kind+".Name", obj.GetName())
+	setProgressing(t, "Recreating"+kind, "Recreating "+kind+" "+obj.GetName())
+	err := r.client.Delete(context.TODO(), obj)
+	if err != nil {
+		reqLogger.Error(err, "Failed to delete "+kind+".", kind+".Namespace", obj.GetNamespace(), kind+".Name", obj.GetName())
+		return reconcile.Result{}, err
+	}
+	r.recorder.Eventf(t, corev1.EventTypeNormal, "Deleted", "Deleted %s %s to recreate it from the WebServer specification", kind, obj.GetName())
+	// Resource deleted - return and requeue
+	return reconcile.Result{Requeue: true}, nil
+}
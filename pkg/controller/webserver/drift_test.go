@@ -0,0 +1,322 @@
+package webserver
+
+import (
+	"testing"
+
+	appsv1 "github.com/openshift/api/apps/v1"
+	buildv1 "github.com/openshift/api/build/v1"
+	routev1 "github.com/openshift/api/route/v1"
+	kbappsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestSyncLabels(t *testing.T) {
+	desired := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"application": "example"}}}
+	found := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"team": "web"}}}
+	if !syncLabels(desired, found) {
+		t.Fatal("the missing label wasn't added")
+	}
+	if found.Labels["application"] != "example" || found.Labels["team"] != "web" {
+		t.Errorf("got labels %v, expected the desired and the found labels", found.Labels)
+	}
+	if syncLabels(desired, found) {
+		t.Error("the labels were updated again")
+	}
+}
+
+func TestSyncService(t *testing.T) {
+	desired := func() *corev1.Service {
+		return &corev1.Service{Spec: corev1.ServiceSpec{
+			Ports:    []corev1.ServicePort{{Name: "ui", Port: 8080, TargetPort: intstr.FromInt(8080)}},
+			Selector: map[string]string{"deploymentConfig": "example", servingLabel: "true"},
+		}}
+	}
+	tests := []struct {
+		name    string
+		found   func(*corev1.Service)
+		updated bool
+	}{
+		{
+			name: "defaulted",
+			found: func(service *corev1.Service) {
+				service.Spec.ClusterIP = "172.30.0.10"
+				service.Spec.Ports[0].Protocol = corev1.ProtocolTCP
+			},
+			updated: false,
+		},
+		{
+			name: "additional port",
+			found: func(service *corev1.Service) {
+				service.Spec.Ports = append(service.Spec.Ports, corev1.ServicePort{Name: "https", Port: 8443})
+			},
+			updated: true,
+		},
+		{
+			name: "additional selector",
+			found: func(service *corev1.Service) {
+				service.Spec.Selector[colorLabel] = "blue"
+			},
+			updated: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			found := desired()
+			test.found(found)
+			if updated := syncService(desired(), found); updated != test.updated {
+				t.Fatalf("got %t, expected %t", updated, test.updated)
+			}
+			if test.updated && syncService(desired(), found) {
+				t.Error("the Service was updated again")
+			}
+		})
+	}
+}
+
+func TestSyncRoleBinding(t *testing.T) {
+	desired := &rbac.RoleBinding{Subjects: []rbac.Subject{{Kind: "ServiceAccount", Name: "example"}}}
+	found := &rbac.RoleBinding{Subjects: []rbac.Subject{{Kind: "ServiceAccount", Name: "example"}, {Kind: "User", Name: "admin"}}}
+	if !syncRoleBinding(desired, found) || len(found.Subjects) != 1 {
+		t.Errorf("the additional subject wasn't removed: %v", found.Subjects)
+	}
+}
+
+func TestSyncRole(t *testing.T) {
+	desired := &rbac.Role{Rules: []rbac.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get", "list"}}}}
+	found := desired.DeepCopy()
+	if syncRole(desired, found) {
+		t.Error("an identical Role was updated")
+	}
+	found.Rules[0].Verbs = []string{"get"}
+	if !syncRole(desired, found) || len(found.Rules[0].Verbs) != 2 {
+		t.Errorf("the verbs weren't restored: %v", found.Rules)
+	}
+}
+
+func TestSyncConfigMap(t *testing.T) {
+	desired := &corev1.ConfigMap{Data: map[string]string{serverXmlKey: "<Server/>"}}
+	found := &corev1.ConfigMap{Data: map[string]string{serverXmlKey: "<Server/>", "extra": "value"}}
+	if !syncConfigMap(desired, found) || len(found.Data) != 1 {
+		t.Errorf("the additional key wasn't removed: %v", found.Data)
+	}
+	if syncConfigMap(desired, found) {
+		t.Error("the ConfigMap was updated again")
+	}
+}
+
+func TestSyncSecret(t *testing.T) {
+	desired := &corev1.Secret{Data: map[string][]byte{"password": []byte("secret")}}
+	found := &corev1.Secret{Data: map[string][]byte{"password": []byte("changed")}}
+	if !syncSecret(desired, found) || string(found.Data["password"]) != "secret" {
+		t.Errorf("the data wasn't restored: %v", found.Data)
+	}
+}
+
+func TestSyncDeploymentStrategy(t *testing.T) {
+	maxSurge := intstr.FromString("25%")
+	desired := &kbappsv1.Deployment{Spec: kbappsv1.DeploymentSpec{
+		Strategy: kbappsv1.DeploymentStrategy{Type: kbappsv1.RecreateDeploymentStrategyType},
+	}}
+	found := &kbappsv1.Deployment{Spec: kbappsv1.DeploymentSpec{
+		Strategy: kbappsv1.DeploymentStrategy{
+			Type:          kbappsv1.RollingUpdateDeploymentStrategyType,
+			RollingUpdate: &kbappsv1.RollingUpdateDeployment{MaxSurge: &maxSurge},
+		},
+		MinReadySeconds: 10,
+	}}
+	if !syncDeploymentStrategy(desired, found) {
+		t.Fatal("the strategy wasn't updated")
+	}
+	// The parameters of the rolling update are rejected with the Recreate strategy
+	if found.Spec.Strategy.RollingUpdate != nil || found.Spec.MinReadySeconds != 0 {
+		t.Errorf("got strategy %+v and minReadySeconds %d, expected the desired ones", found.Spec.Strategy, found.Spec.MinReadySeconds)
+	}
+	if syncDeploymentStrategy(desired, found) {
+		t.Error("the strategy was updated again")
+	}
+}
+
+func TestSyncRoute(t *testing.T) {
+	desired := &routev1.Route{Spec: routev1.RouteSpec{To: routev1.RouteTargetReference{Kind: "Service", Name: "example"}}}
+	found := &routev1.Route{Spec: routev1.RouteSpec{
+		Host: "example-test.apps.cluster",
+		To:   routev1.RouteTargetReference{Kind: "Service", Name: "example"},
+		TLS:  &routev1.TLSConfig{Termination: routev1.TLSTerminationEdge},
+	}}
+	if !syncRoute(desired, found) {
+		t.Fatal("the TLS configuration wasn't removed")
+	}
+	if found.Spec.TLS != nil || found.Spec.Host != "example-test.apps.cluster" {
+		t.Errorf("got %+v, expected no TLS configuration and the host generated by the router", found.Spec)
+	}
+	if syncRoute(desired, found) {
+		t.Error("the Route was updated again")
+	}
+}
+
+func TestSyncRouteAnnotations(t *testing.T) {
+	desired := func(annotations map[string]string) *routev1.Route {
+		route := &routev1.Route{ObjectMeta: metav1.ObjectMeta{Annotations: annotations}}
+		setManagedAnnotations(route)
+		return route
+	}
+	found := desired(map[string]string{"description": "example", "haproxy.router.openshift.io/timeout": "60s"})
+	found.Annotations["openshift.io/host.generated"] = "true"
+
+	if !syncRoute(desired(map[string]string{"description": "example"}), found) {
+		t.Fatal("the annotation removed from the WebServer wasn't removed")
+	}
+	if _, kept := found.Annotations["haproxy.router.openshift.io/timeout"]; kept {
+		t.Errorf("got %v, expected the timeout annotation to be removed", found.Annotations)
+	}
+	if found.Annotations["openshift.io/host.generated"] != "true" || found.Annotations["description"] != "example" {
+		t.Errorf("got %v, expected the annotations not managed by the operator to be kept", found.Annotations)
+	}
+	if found.Annotations[managedAnnotationsAnnotation] != "description" {
+		t.Errorf("got %s, expected only description to be managed", found.Annotations[managedAnnotationsAnnotation])
+	}
+	if syncRoute(desired(map[string]string{"description": "example"}), found) {
+		t.Error("the Route was updated again")
+	}
+}
+
+func TestSyncBuildConfig(t *testing.T) {
+	desired := func() *buildv1.BuildConfig {
+		return &buildv1.BuildConfig{Spec: buildv1.BuildConfigSpec{CommonSpec: buildv1.CommonSpec{
+			Source: buildv1.BuildSource{Git: &buildv1.GitBuildSource{URI: "https://github.com/example/app.git"}},
+			Strategy: buildv1.BuildStrategy{SourceStrategy: &buildv1.SourceBuildStrategy{
+				Env: []corev1.EnvVar{{Name: "MAVEN_ARGS", Value: "-DskipTests"}},
+			}},
+		}}}
+	}
+	found := desired()
+	found.Spec.Strategy.SourceStrategy.Env = append(found.Spec.Strategy.SourceStrategy.Env, corev1.EnvVar{Name: "EXTRA", Value: "1"})
+	if !syncBuildConfig(desired(), found) || len(found.Spec.Strategy.SourceStrategy.Env) != 1 {
+		t.Errorf("the additional variable wasn't removed: %v", found.Spec.Strategy.SourceStrategy.Env)
+	}
+	found.Spec.Resources = corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")}}
+	if syncBuildConfig(desired(), found) {
+		t.Error("the resources left unset in the WebServer were reverted")
+	}
+	found.Spec.Source.Git.URI = "https://github.com/example/other.git"
+	if !syncBuildConfig(desired(), found) || found.Spec.Source.Git.URI != "https://github.com/example/app.git" {
+		t.Errorf("the source wasn't restored: %+v", found.Spec.Source.Git)
+	}
+}
+
+func TestSyncDeploymentConfig(t *testing.T) {
+	trigger := func(lastTriggeredImage string) appsv1.DeploymentTriggerPolicy {
+		return appsv1.DeploymentTriggerPolicy{
+			Type: appsv1.DeploymentTriggerOnImageChange,
+			ImageChangeParams: &appsv1.DeploymentTriggerImageChangeParams{
+				Automatic:          true,
+				ContainerNames:     []string{"example"},
+				From:               corev1.ObjectReference{Kind: "ImageStreamTag", Name: "example:latest"},
+				LastTriggeredImage: lastTriggeredImage,
+			},
+		}
+	}
+	desired := &appsv1.DeploymentConfig{Spec: appsv1.DeploymentConfigSpec{
+		Triggers: []appsv1.DeploymentTriggerPolicy{trigger(""), {Type: appsv1.DeploymentTriggerOnConfigChange}},
+	}}
+	found := &appsv1.DeploymentConfig{Spec: appsv1.DeploymentConfigSpec{
+		Triggers: []appsv1.DeploymentTriggerPolicy{trigger("image-registry/example@sha256:1234")},
+	}}
+	if !syncDeploymentConfig(desired, found) {
+		t.Fatal("the missing trigger wasn't added")
+	}
+	if len(found.Spec.Triggers) != 2 || found.Spec.Triggers[0].ImageChangeParams.LastTriggeredImage != "image-registry/example@sha256:1234" {
+		t.Errorf("got triggers %+v, expected both triggers and the last triggered image", found.Spec.Triggers)
+	}
+	if syncDeploymentConfig(desired, found) {
+		t.Error("the DeploymentConfig was updated again")
+	}
+}
+
+func TestPodTemplateDrifted(t *testing.T) {
+	desired := func() corev1.PodTemplateSpec {
+		return corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{podTemplateHashAnnotation: "1"}},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{
+					Name:         "example",
+					Image:        "quay.io/example/tomcat:1.0",
+					Env:          []corev1.EnvVar{{Name: "KUBERNETES_NAMESPACE", Value: "test"}},
+					VolumeMounts: []corev1.VolumeMount{{Name: "webserver-example", MountPath: "/server.xml"}},
+				}},
+				Volumes: []corev1.Volume{{Name: "webserver-example"}},
+			},
+		}
+	}
+	tests := []struct {
+		name    string
+		found   func(*corev1.PodTemplateSpec)
+		drifted bool
+	}{
+		{
+			name: "defaulted",
+			found: func(template *corev1.PodTemplateSpec) {
+				template.Spec.RestartPolicy = corev1.RestartPolicyAlways
+				template.Spec.Containers[0].TerminationMessagePath = "/dev/termination-log"
+			},
+			drifted: false,
+		},
+		{
+			name: "changed image",
+			found: func(template *corev1.PodTemplateSpec) {
+				template.Spec.Containers[0].Image = "quay.io/example/tomcat:2.0"
+			},
+			drifted: true,
+		},
+		{
+			name: "additional variable",
+			found: func(template *corev1.PodTemplateSpec) {
+				template.Spec.Containers[0].Env = append(template.Spec.Containers[0].Env, corev1.EnvVar{Name: "DEBUG", Value: "true"})
+			},
+			drifted: true,
+		},
+		{
+			name: "additional volume",
+			found: func(template *corev1.PodTemplateSpec) {
+				template.Spec.Volumes = append(template.Spec.Volumes, corev1.Volume{Name: "debug"})
+			},
+			drifted: true,
+		},
+		{
+			name: "additional container",
+			found: func(template *corev1.PodTemplateSpec) {
+				template.Spec.Containers = append(template.Spec.Containers, corev1.Container{Name: "debug"})
+			},
+			drifted: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			found := desired()
+			test.found(&found)
+			if drifted := podTemplateDrifted(desired(), found); drifted != test.drifted {
+				t.Errorf("got %t, expected %t", drifted, test.drifted)
+			}
+		})
+	}
+}
+
+func TestBuildPodDrifted(t *testing.T) {
+	desired := &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{
+		Image: "quay.io/example/builder:1.0",
+		Args:  []string{"build"},
+	}}}}
+	found := desired.DeepCopy()
+	found.Spec.Containers[0].Resources = corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")}}
+	if buildPodDrifted(desired, found) {
+		t.Error("the resources left unset in the WebServer made the build pod drift")
+	}
+	found.Spec.Containers[0].Image = "quay.io/example/builder:2.0"
+	if !buildPodDrifted(desired, found) {
+		t.Error("the changed image of the builder wasn't detected")
+	}
+}
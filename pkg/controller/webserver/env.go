@@ -0,0 +1,60 @@
+package webserver
+
+import (
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// catalinaOptsEnv is the variable of the options of Tomcat, the options set by the operator are added to it
+const catalinaOptsEnv = "CATALINA_OPTS"
+
+// userEnvVars returns the environment variables of the WebServer, the variables set by the operator are skipped:
+// the validating webhook rejects them but the WebServer may have been created without it
+func userEnvVars(t *webserversv1alpha1.WebServer) []corev1.EnvVar {
+	env := []corev1.EnvVar{}
+	for _, envVar := range t.Spec.Env {
+		if !webserversv1alpha1.IsReservedEnvVar(envVar.Name) {
+			env = append(env, envVar)
+		}
+	}
+	return env
+}
+
+// ignoredEnvVars returns the names of the environment variables of the WebServer skipped because the operator sets them
+func ignoredEnvVars(t *webserversv1alpha1.WebServer) []string {
+	ignored := []string{}
+	for _, envVar := range t.Spec.Env {
+		if webserversv1alpha1.IsReservedEnvVar(envVar.Name) {
+			ignored = append(ignored, envVar.Name)
+		}
+	}
+	return ignored
+}
+
+// envConfigMapsAndSecrets returns the names of the ConfigMaps and of the Secrets referenced by the env and the
+// envFrom of a container
+func envConfigMapsAndSecrets(env []corev1.EnvVar, envFrom []corev1.EnvFromSource) ([]string, []string) {
+	configMaps := []string{}
+	secrets := []string{}
+	for _, envVar := range env {
+		if envVar.ValueFrom == nil {
+			continue
+		}
+		if ref := envVar.ValueFrom.ConfigMapKeyRef; ref != nil {
+			configMaps = append(configMaps, ref.Name)
+		}
+		if ref := envVar.ValueFrom.SecretKeyRef; ref != nil {
+			secrets = append(secrets, ref.Name)
+		}
+	}
+	for _, source := range envFrom {
+		if source.ConfigMapRef != nil {
+			configMaps = append(configMaps, source.ConfigMapRef.Name)
+		}
+		if source.SecretRef != nil {
+			secrets = append(secrets, source.SecretRef.Name)
+		}
+	}
+	return configMaps, secrets
+}
@@ -0,0 +1,65 @@
+package webserver
+
+import (
+	"reflect"
+	"testing"
+
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCreateEnvVars(t *testing.T) {
+	passwordSecretKeyRef := corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "example-keystore"},
+		Key:                  "password",
+	}
+	webServer := &webserversv1alpha1.WebServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "test"},
+		Spec: webserversv1alpha1.WebServerSpec{
+			TLS: &webserversv1alpha1.TLSSpec{
+				Keystore: &webserversv1alpha1.KeystoreSpec{PasswordSecretKeyRef: passwordSecretKeyRef},
+			},
+			Resources: &corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+			},
+			Env: []corev1.EnvVar{
+				{Name: "EXAMPLE_MODE", Value: "production"},
+				{Name: "JAVA_OPTS", Value: "-Xmx4g"},
+			},
+			CatalinaOpts: "-Dexample.mode=$(EXAMPLE_MODE)",
+			JavaOpts:     "-XX:+UseG1GC",
+		},
+	}
+
+	env := []corev1.EnvVar{
+		{Name: "KUBERNETES_NAMESPACE", Value: "webserver-example"},
+		{Name: tlsKeystorePasswordEnv, ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &passwordSecretKeyRef}},
+		{Name: "EXAMPLE_MODE", Value: "production"},
+		{Name: "CATALINA_OPTS", Value: "-D" + tlsKeystorePasswordProperty + "=$(" + tlsKeystorePasswordEnv + ") -Dexample.mode=$(EXAMPLE_MODE)"},
+		{Name: "JAVA_OPTS", Value: "-XX:MaxRAMPercentage=75.0 -XX:+UseG1GC"},
+	}
+	if createdEnv := createEnvVars(webServer, false); !reflect.DeepEqual(createdEnv, env) {
+		t.Errorf("got %+v, expected %+v", createdEnv, env)
+	}
+}
+
+func TestIgnoredEnvVars(t *testing.T) {
+	webServer := &webserversv1alpha1.WebServer{
+		Spec: webserversv1alpha1.WebServerSpec{
+			Env: []corev1.EnvVar{
+				{Name: "EXAMPLE_MODE", Value: "production"},
+				{Name: "JAVA_OPTS", Value: "-Xmx4g"},
+				{Name: "KUBERNETES_NAMESPACE", Value: "other"},
+			},
+		},
+	}
+	if env := userEnvVars(webServer); !reflect.DeepEqual(env, webServer.Spec.Env[:1]) {
+		t.Errorf("got %+v, expected %+v", env, webServer.Spec.Env[:1])
+	}
+	if ignored := ignoredEnvVars(webServer); !reflect.DeepEqual(ignored, []string{"JAVA_OPTS", "KUBERNETES_NAMESPACE"}) {
+		t.Errorf("got %v, expected JAVA_OPTS and KUBERNETES_NAMESPACE", ignored)
+	}
+}
@@ -0,0 +1,150 @@
+package webserver
+
+import (
+	"reflect"
+	"testing"
+
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestIngressForWebServer(t *testing.T) {
+	tests := []struct {
+		name        string
+		ingress     webserversv1alpha1.IngressSpec
+		path        string
+		tls         []networkingv1beta1.IngressTLS
+		annotations map[string]string
+	}{
+		{
+			name:        "default",
+			path:        "/",
+			annotations: map[string]string{},
+		},
+		{
+			name:        "host and path",
+			ingress:     webserversv1alpha1.IngressSpec{Host: "example.com", Path: "/example"},
+			path:        "/example",
+			annotations: map[string]string{},
+		},
+		{
+			name: "class and annotations",
+			ingress: webserversv1alpha1.IngressSpec{
+				IngressClassName: "nginx",
+				Annotations:      map[string]string{"nginx.ingress.kubernetes.io/affinity": "cookie"},
+			},
+			path: "/",
+			annotations: map[string]string{
+				"kubernetes.io/ingress.class":          "nginx",
+				"nginx.ingress.kubernetes.io/affinity": "cookie",
+			},
+		},
+		{
+			name:        "TLS of the host",
+			ingress:     webserversv1alpha1.IngressSpec{Host: "example.com", TLSSecretName: "example-tls"},
+			path:        "/",
+			tls:         []networkingv1beta1.IngressTLS{{SecretName: "example-tls", Hosts: []string{"example.com"}}},
+			annotations: map[string]string{},
+		},
+		{
+			name:        "TLS of all the hosts",
+			ingress:     webserversv1alpha1.IngressSpec{TLSSecretName: "example-tls"},
+			path:        "/",
+			tls:         []networkingv1beta1.IngressTLS{{SecretName: "example-tls"}},
+			annotations: map[string]string{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := newTestReconciler(t)
+			webServer := &webserversv1alpha1.WebServer{
+				ObjectMeta: metav1.ObjectMeta{Name: "example-webserver", Namespace: "jws"},
+				Spec:       webserversv1alpha1.WebServerSpec{ApplicationName: "example", Ingress: &test.ingress},
+			}
+			ingress := r.ingressForWebServer(webServer)
+
+			rule := ingress.Spec.Rules[0]
+			if rule.Host != test.ingress.Host {
+				t.Errorf("got host %q, expected %q", rule.Host, test.ingress.Host)
+			}
+			expectedPath := networkingv1beta1.HTTPIngressPath{
+				Path:    test.path,
+				Backend: networkingv1beta1.IngressBackend{ServiceName: "example", ServicePort: intstr.FromInt(8080)},
+			}
+			if len(rule.HTTP.Paths) != 1 || !reflect.DeepEqual(rule.HTTP.Paths[0], expectedPath) {
+				t.Errorf("got %+v, expected %+v", rule.HTTP.Paths, expectedPath)
+			}
+			if !reflect.DeepEqual(ingress.Spec.TLS, test.tls) {
+				t.Errorf("got %+v, expected %+v", ingress.Spec.TLS, test.tls)
+			}
+			annotations := map[string]string{}
+			for key, value := range ingress.Annotations {
+				if key != managedAnnotationsAnnotation {
+					annotations[key] = value
+				}
+			}
+			if !reflect.DeepEqual(annotations, test.annotations) {
+				t.Errorf("got %v, expected %v", annotations, test.annotations)
+			}
+		})
+	}
+}
+
+func TestSyncIngressAnnotations(t *testing.T) {
+	r := newTestReconciler(t)
+	webServer := &webserversv1alpha1.WebServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "example-webserver", Namespace: "jws"},
+		Spec: webserversv1alpha1.WebServerSpec{ApplicationName: "example", Ingress: &webserversv1alpha1.IngressSpec{
+			IngressClassName: "nginx",
+			Annotations:      map[string]string{"nginx.ingress.kubernetes.io/affinity": "cookie"},
+		}},
+	}
+	found := r.ingressForWebServer(webServer)
+	found.Annotations["ingress.kubernetes.io/backends"] = "{}"
+
+	webServer.Spec.Ingress = &webserversv1alpha1.IngressSpec{}
+	if !syncIngress(r.ingressForWebServer(webServer), found) {
+		t.Fatal("the annotations removed from the WebServer weren't removed")
+	}
+	for _, key := range []string{"kubernetes.io/ingress.class", "nginx.ingress.kubernetes.io/affinity"} {
+		if _, kept := found.Annotations[key]; kept {
+			t.Errorf("got %v, expected %s to be removed", found.Annotations, key)
+		}
+	}
+	if found.Annotations["ingress.kubernetes.io/backends"] != "{}" {
+		t.Errorf("got %v, expected the annotation of the Ingress controller to be kept", found.Annotations)
+	}
+	if syncIngress(r.ingressForWebServer(webServer), found) {
+		t.Error("the Ingress was updated again")
+	}
+}
+
+func TestIngressHosts(t *testing.T) {
+	tests := []struct {
+		name          string
+		loadBalancers []corev1.LoadBalancerIngress
+		hosts         []string
+	}{
+		{name: "not exposed yet", hosts: []string{}},
+		{name: "IP", loadBalancers: []corev1.LoadBalancerIngress{{IP: "192.0.2.10"}}, hosts: []string{"192.0.2.10"}},
+		{name: "hostname", loadBalancers: []corev1.LoadBalancerIngress{{Hostname: "example.elb.amazonaws.com"}}, hosts: []string{"example.elb.amazonaws.com"}},
+		{
+			name:          "hostname preferred to IP",
+			loadBalancers: []corev1.LoadBalancerIngress{{IP: "192.0.2.20", Hostname: "lb.example.com"}, {IP: "192.0.2.10"}},
+			hosts:         []string{"192.0.2.10", "lb.example.com"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ingress := &networkingv1beta1.Ingress{}
+			ingress.Status.LoadBalancer.Ingress = test.loadBalancers
+			if hosts := ingressHosts(ingress); !reflect.DeepEqual(hosts, test.hosts) {
+				t.Errorf("got %v, expected %v", hosts, test.hosts)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package webserver
+
+import (
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// kubePingName returns the name of the ServiceAccount of the pods using KUBEPing, and of the Role and RoleBinding
+// allowing it to discover the other pods
+func kubePingName(t *webserversv1alpha1.WebServer) string {
+	return "webserver-" + t.Name
+}
+
+// serviceAccountForWebServer returns the ServiceAccount of the pods using KUBEPing
+func (r *ReconcileWebServer) serviceAccountForWebServer(t *webserversv1alpha1.WebServer) *corev1.ServiceAccount {
+	serviceAccount := &corev1.ServiceAccount{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ServiceAccount",
+		},
+		ObjectMeta: objectMetaForWebServer(t, kubePingName(t)),
+	}
+
+	controllerutil.SetControllerReference(t, serviceAccount, r.scheme)
+	return serviceAccount
+}
+
+// roleForWebServer returns the Role allowing KUBEPing to discover the pods of the namespace, it grants nothing else
+func (r *ReconcileWebServer) roleForWebServer(t *webserversv1alpha1.WebServer) *rbac.Role {
+	role := &rbac.Role{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "rbac.authorization.k8s.io/v1",
+			Kind:       "Role",
+		},
+		ObjectMeta: objectMetaForWebServer(t, kubePingName(t)),
+		Rules: []rbac.PolicyRule{{
+			APIGroups: []string{""},
+			Resources: []string{"pods"},
+			Verbs:     []string{"get", "list", "watch"},
+		}},
+	}
+
+	controllerutil.SetControllerReference(t, role, r.scheme)
+	return role
+}
+
+// roleBindingForWebServer returns the RoleBinding granting the Role of KUBEPing to the ServiceAccount of the pods
+func (r *ReconcileWebServer) roleBindingForWebServer(t *webserversv1alpha1.WebServer) *rbac.RoleBinding {
+	rolebinding := &rbac.RoleBinding{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "rbac.authorization.k8s.io/v1",
+			Kind:       "RoleBinding",
+		},
+		ObjectMeta: objectMetaForWebServer(t, kubePingName(t)),
+		RoleRef: rbac.RoleRef{
+			APIGroup: "rbac.authorization.k8s.io",
+			Kind:     "Role",
+			Name:     kubePingName(t),
+		},
+		Subjects: []rbac.Subject{{
+			Kind:      "ServiceAccount",
+			Name:      kubePingName(t),
+			Namespace: t.Namespace,
+		}},
+	}
+
+	controllerutil.SetControllerReference(t, rolebinding, r.scheme)
+	return rolebinding
+}
+
+// kubePingUnavailable handles the failure to create the resources KUBEPing requires, the session clustering falls back
+// to DNSPing unless KUBEPing was explicitly requested.
+func (r *ReconcileWebServer) kubePingUnavailable(t *webserversv1alpha1.WebServer, message string, err error) (reconcile.Result, error) {
+	if sessionClustering := t.Spec.SessionClustering; sessionClustering != nil && sessionClustering.MembershipProvider == "KUBEPing" {
+		// KUBEPing was explicitly requested, don't fall back to DNSPing
+		setDegraded(t, "KUBEPingUnavailable", message+": "+err.Error())
+		return reconcile.Result{}, err
+	}
+	r.recorder.Eventf(t, corev1.EventTypeWarning, "KUBEPingUnavailable", "%s, using DNSPing for session clustering: %v", message, err)
+	// We ignore the error.
+	r.useKUBEPing = false
+	return reconcile.Result{Requeue: true}, nil
+}
@@ -0,0 +1,148 @@
+package webserver
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// clusteredWebServer returns a WebServer with the session clustering discovering the pods with membershipProvider
+func clusteredWebServer(membershipProvider string) *webserversv1alpha1.WebServer {
+	return &webserversv1alpha1.WebServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "example-webserver", Namespace: "jws"},
+		Spec: webserversv1alpha1.WebServerSpec{
+			ApplicationName:   "example",
+			Replicas:          2,
+			WebImage:          &webserversv1alpha1.WebImageSpec{ApplicationImage: "quay.io/example/tomcat:1.0"},
+			SessionClustering: &webserversv1alpha1.SessionClusteringSpec{MembershipProvider: membershipProvider},
+		},
+	}
+}
+
+func TestServiceAccountForWebServer(t *testing.T) {
+	r := newTestReconciler(t)
+	webServer := clusteredWebServer("")
+	serviceAccount := r.serviceAccountForWebServer(webServer)
+	if serviceAccount.Name != "webserver-example-webserver" || serviceAccount.Namespace != "jws" {
+		t.Errorf("got ServiceAccount %s/%s", serviceAccount.Namespace, serviceAccount.Name)
+	}
+	if !metav1.IsControlledBy(serviceAccount, webServer) {
+		t.Error("the ServiceAccount isn't controlled by the WebServer")
+	}
+}
+
+func TestRoleForWebServer(t *testing.T) {
+	r := newTestReconciler(t)
+	webServer := clusteredWebServer("")
+	role := r.roleForWebServer(webServer)
+	if role.Name != kubePingName(webServer) || role.Namespace != "jws" {
+		t.Errorf("got Role %s/%s", role.Namespace, role.Name)
+	}
+	// KUBEPing only reads the pods, the Role must not grant anything else
+	rules := []rbac.PolicyRule{{
+		APIGroups: []string{""},
+		Resources: []string{"pods"},
+		Verbs:     []string{"get", "list", "watch"},
+	}}
+	if !reflect.DeepEqual(role.Rules, rules) {
+		t.Errorf("got rules %+v, expected %+v", role.Rules, rules)
+	}
+	if !metav1.IsControlledBy(role, webServer) {
+		t.Error("the Role isn't controlled by the WebServer")
+	}
+}
+
+func TestRoleBindingForWebServer(t *testing.T) {
+	r := newTestReconciler(t)
+	webServer := clusteredWebServer("")
+	rolebinding := r.roleBindingForWebServer(webServer)
+	roleRef := rbac.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "Role", Name: "webserver-example-webserver"}
+	if rolebinding.RoleRef != roleRef {
+		t.Errorf("got roleRef %+v, expected %+v", rolebinding.RoleRef, roleRef)
+	}
+	subjects := []rbac.Subject{{Kind: "ServiceAccount", Name: "webserver-example-webserver", Namespace: "jws"}}
+	if !reflect.DeepEqual(rolebinding.Subjects, subjects) {
+		t.Errorf("got subjects %+v, expected %+v", rolebinding.Subjects, subjects)
+	}
+	if !metav1.IsControlledBy(rolebinding, webServer) {
+		t.Error("the RoleBinding isn't controlled by the WebServer")
+	}
+}
+
+func TestKubePingUnavailable(t *testing.T) {
+	forbidden := errors.New("rolebindings.rbac.authorization.k8s.io is forbidden")
+	tests := []struct {
+		name               string
+		membershipProvider string
+		fallback           bool
+	}{
+		{name: "default", membershipProvider: "", fallback: true},
+		{name: "KUBEPing", membershipProvider: "KUBEPing", fallback: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := newTestReconciler(t)
+			r.useKUBEPing = true
+			webServer := clusteredWebServer(test.membershipProvider)
+			result, err := r.kubePingUnavailable(webServer, "Failed to create RoleBinding webserver-example-webserver", forbidden)
+
+			degraded := condition(webServer, webserversv1alpha1.WebServerDegraded)
+			if test.fallback {
+				// The session clustering falls back to DNSPing in the next reconciliation
+				if err != nil || !result.Requeue {
+					t.Errorf("got %+v and %v, expected a requeue", result, err)
+				}
+				if r.useKUBEPing {
+					t.Error("KUBEPing is still used")
+				}
+				if degraded != nil {
+					t.Errorf("got Degraded condition %+v", degraded)
+				}
+				if !recordedEvent(r, "KUBEPingUnavailable") {
+					t.Error("no KUBEPingUnavailable event recorded")
+				}
+			} else {
+				if err != forbidden {
+					t.Errorf("got %v, expected %v", err, forbidden)
+				}
+				if !r.useKUBEPing {
+					t.Error("fell back to DNSPing although KUBEPing was requested")
+				}
+				if degraded == nil || degraded.Status != corev1.ConditionTrue || degraded.Reason != "KUBEPingUnavailable" {
+					t.Errorf("got Degraded condition %+v", degraded)
+				}
+			}
+		})
+	}
+}
+
+func TestPodTemplateServiceAccountName(t *testing.T) {
+	tests := []struct {
+		name               string
+		sessionClustering  bool
+		useKUBEPing        bool
+		serviceAccountName string
+	}{
+		{name: "KUBEPing", sessionClustering: true, useKUBEPing: true, serviceAccountName: "webserver-example-webserver"},
+		{name: "DNSPing", sessionClustering: true, useKUBEPing: false, serviceAccountName: ""},
+		{name: "no session clustering", sessionClustering: false, useKUBEPing: true, serviceAccountName: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			webServer := clusteredWebServer("")
+			if !test.sessionClustering {
+				webServer.Spec.SessionClustering = nil
+			}
+			template := podTemplateSpecForWebServer(webServer, "quay.io/example/tomcat:1.0", test.useKUBEPing)
+			if template.Spec.ServiceAccountName != test.serviceAccountName {
+				t.Errorf("got serviceAccountName %q, expected %q", template.Spec.ServiceAccountName, test.serviceAccountName)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package webserver
+
+import (
+	"strings"
+
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// createReadinessProbe returns the readinessProbe of the health check, a probe running the serverReadinessScript,
+// or the default HTTP GET of /health answered by the HealthCheckValve.
+//
+// If defined, serverReadinessScript must be a command line that
+// complies to the Kubernetes probes requirements, for example
+// shell -c "command"
+func createReadinessProbe(health *webserversv1alpha1.WebServerHealthCheckSpec) *corev1.Probe {
+	if health != nil && health.ReadinessProbe != nil {
+		return probeForSpec(health.ReadinessProbe, webserversv1alpha1.DefaultProbeFailureThreshold)
+	}
+	if health != nil && health.ServerReadinessScript != "" {
+		return createScriptProbe(health.ServerReadinessScript)
+	}
+	return probeForSpec(&webserversv1alpha1.ProbeSpec{}, webserversv1alpha1.DefaultProbeFailureThreshold)
+}
+
+// createLivenessProbe returns the livenessProbe of the health check, a probe running the serverLivenessScript,
+// or the default HTTP GET of /health answered by the HealthCheckValve.
+//
+// If defined, serverLivenessScript must be a command line that
+// complies to the Kubernetes probes requirements, for example
+// shell -c "command"
+func createLivenessProbe(health *webserversv1alpha1.WebServerHealthCheckSpec) *corev1.Probe {
+	if health != nil && health.LivenessProbe != nil {
+		return probeForSpec(health.LivenessProbe, webserversv1alpha1.DefaultProbeFailureThreshold)
+	}
+	if health != nil && health.ServerLivenessScript != "" {
+		return createScriptProbe(health.ServerLivenessScript)
+	}
+	return probeForSpec(&webserversv1alpha1.ProbeSpec{}, webserversv1alpha1.DefaultProbeFailureThreshold)
+}
+
+// createStartupProbe returns the startupProbe of the health check, or the liveness probe with a higher failure
+// threshold: the liveness and readiness probes only start once the application is started, a slow application
+// isn't restarted before it is up.
+func createStartupProbe(health *webserversv1alpha1.WebServerHealthCheckSpec) *corev1.Probe {
+	if health != nil && health.StartupProbe != nil {
+		return probeForSpec(health.StartupProbe, webserversv1alpha1.DefaultStartupProbeFailureThreshold)
+	}
+	probe := createLivenessProbe(health)
+	probe.InitialDelaySeconds = 0
+	probe.FailureThreshold = webserversv1alpha1.DefaultStartupProbeFailureThreshold
+	return probe
+}
+
+// createScriptProbe returns a probe running the command line of a script
+func createScriptProbe(script string) *corev1.Probe {
+	return probeForSpec(&webserversv1alpha1.ProbeSpec{
+		Exec: &webserversv1alpha1.ExecProbeSpec{Command: splitScript(script)},
+	}, webserversv1alpha1.DefaultProbeFailureThreshold)
+}
+
+// probeForSpec returns the probe described by the spec, the unset fields have their default values
+func probeForSpec(spec *webserversv1alpha1.ProbeSpec, failureThreshold int32) *corev1.Probe {
+	spec = spec.DeepCopy()
+	webserversv1alpha1.SetProbeDefaults(spec, failureThreshold)
+	probe := &corev1.Probe{
+		InitialDelaySeconds: spec.InitialDelaySeconds,
+		PeriodSeconds:       spec.PeriodSeconds,
+		TimeoutSeconds:      spec.TimeoutSeconds,
+		FailureThreshold:    spec.FailureThreshold,
+	}
+	if httpGet := spec.HTTPGet; httpGet != nil {
+		probe.HTTPGet = &corev1.HTTPGetAction{
+			Path:   httpGet.Path,
+			Port:   *httpGet.Port,
+			Scheme: corev1.URIScheme(httpGet.Scheme),
+		}
+		for _, header := range httpGet.HTTPHeaders {
+			probe.HTTPGet.HTTPHeaders = append(probe.HTTPGet.HTTPHeaders, corev1.HTTPHeader{Name: header.Name, Value: header.Value})
+		}
+	}
+	if tcpSocket := spec.TCPSocket; tcpSocket != nil {
+		probe.TCPSocket = &corev1.TCPSocketAction{Port: *tcpSocket.Port}
+	}
+	if exec := spec.Exec; exec != nil {
+		probe.Exec = &corev1.ExecAction{Command: exec.Command}
+	}
+	return probe
+}
+
+// splitScript splits a command line in its arguments like a shell: the arguments are separated by spaces, the single
+// and double quotes group the words of an argument and are removed. A backslash escapes the next character outside
+// of quotes, and a double quote, a backslash, a dollar or a backquote in double quotes.
+func splitScript(script string) []string {
+	args := []string{}
+	var arg strings.Builder
+	inArg := false
+	quote := rune(0)
+	runes := []rune(script)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case c == '\\' && quote != '\'' && i+1 < len(runes) && (quote == 0 || strings.ContainsRune("\"\\$`", runes[i+1])):
+			i++
+			arg.WriteRune(runes[i])
+			inArg = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				arg.WriteRune(c)
+			}
+		case c == '\'' || c == '"':
+			quote = c
+			inArg = true
+		case c == ' ' || c == '\t' || c == '\n':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(c)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args
+}
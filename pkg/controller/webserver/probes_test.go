@@ -0,0 +1,99 @@
+package webserver
+
+import (
+	"reflect"
+	"testing"
+
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestSplitScript(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		args   []string
+	}{
+		{
+			name:   "command",
+			script: "/bin/busybox wget http://localhost:8080/test -O /dev/null",
+			args:   []string{"/bin/busybox", "wget", "http://localhost:8080/test", "-O", "/dev/null"},
+		},
+		{
+			name:   "shell",
+			script: `/bin/bash -c " /usr/bin/curl --noproxy '*' -s 'http://localhost:8080/health' | /usr/bin/grep -i 'status.*UP'"`,
+			args:   []string{"/bin/bash", "-c", ` /usr/bin/curl --noproxy '*' -s 'http://localhost:8080/health' | /usr/bin/grep -i 'status.*UP'`},
+		},
+		{
+			name:   "nested quotes",
+			script: `sh -c "grep \"status\": /tmp/health" 'it''s'`,
+			args:   []string{"sh", "-c", `grep "status": /tmp/health`, "its"},
+		},
+		{
+			name:   "escapes",
+			script: `test -f /tmp/a\ file "C:\dir"  ''`,
+			args:   []string{"test", "-f", "/tmp/a file", `C:\dir`, ""},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if args := splitScript(test.script); !reflect.DeepEqual(args, test.args) {
+				t.Errorf("got %q, expected %q", args, test.args)
+			}
+		})
+	}
+}
+
+func TestCreateProbes(t *testing.T) {
+	port := intstr.FromString("http")
+	health := &webserversv1alpha1.WebServerHealthCheckSpec{
+		ServerReadinessScript: "/bin/true",
+		LivenessProbe: &webserversv1alpha1.ProbeSpec{
+			HTTPGet: &webserversv1alpha1.HTTPGetProbeSpec{
+				Path:        "/alive",
+				Port:        &port,
+				HTTPHeaders: []webserversv1alpha1.HTTPHeaderSpec{{Name: "X-Probe", Value: "liveness"}},
+			},
+			InitialDelaySeconds: 20,
+			TimeoutSeconds:      2,
+		},
+	}
+
+	readiness := &corev1.Probe{
+		Handler:          corev1.Handler{Exec: &corev1.ExecAction{Command: []string{"/bin/true"}}},
+		PeriodSeconds:    10,
+		TimeoutSeconds:   5,
+		FailureThreshold: 3,
+	}
+	if probe := createReadinessProbe(health); !reflect.DeepEqual(probe, readiness) {
+		t.Errorf("readiness probe: got %+v, expected %+v", probe, readiness)
+	}
+
+	liveness := &corev1.Probe{
+		Handler: corev1.Handler{HTTPGet: &corev1.HTTPGetAction{
+			Path:        "/alive",
+			Port:        port,
+			Scheme:      corev1.URISchemeHTTP,
+			HTTPHeaders: []corev1.HTTPHeader{{Name: "X-Probe", Value: "liveness"}},
+		}},
+		InitialDelaySeconds: 20,
+		PeriodSeconds:       10,
+		TimeoutSeconds:      2,
+		FailureThreshold:    3,
+	}
+	if probe := createLivenessProbe(health); !reflect.DeepEqual(probe, liveness) {
+		t.Errorf("liveness probe: got %+v, expected %+v", probe, liveness)
+	}
+
+	startup := liveness.DeepCopy()
+	startup.InitialDelaySeconds = 0
+	startup.FailureThreshold = 30
+	if probe := createStartupProbe(health); !reflect.DeepEqual(probe, startup) {
+		t.Errorf("startup probe: got %+v, expected %+v", probe, startup)
+	}
+	if health.LivenessProbe.PeriodSeconds != 0 {
+		t.Errorf("the defaults were set on the WebServer")
+	}
+}
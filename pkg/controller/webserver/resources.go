@@ -0,0 +1,52 @@
+package webserver
+
+import (
+	"fmt"
+
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// javaOptsEnv is the variable of the JVM options of Tomcat, the option sizing the heap is added to it
+const javaOptsEnv = "JAVA_OPTS"
+
+// resourceRequirements returns the compute resources of a container, none when they aren't set in the WebServer
+func resourceRequirements(resources *corev1.ResourceRequirements) corev1.ResourceRequirements {
+	if resources == nil {
+		return corev1.ResourceRequirements{}
+	}
+	return *resources.DeepCopy()
+}
+
+// jvmHeapOption returns the JVM option sizing the heap from the memory limit of the Tomcat container, an empty
+// string when the container has no memory limit or when the heap isn't sized by the operator
+func jvmHeapOption(t *webserversv1alpha1.WebServer) string {
+	if t.Spec.Resources == nil {
+		return ""
+	}
+	limit, found := t.Spec.Resources.Limits[corev1.ResourceMemory]
+	if !found || limit.IsZero() {
+		return ""
+	}
+	sizing := webserversv1alpha1.DefaultJVMHeapSizing
+	percentage := int64(webserversv1alpha1.DefaultJVMHeapPercentage)
+	if jvmHeap := t.Spec.JVMHeap; jvmHeap != nil {
+		if jvmHeap.Sizing != "" {
+			sizing = jvmHeap.Sizing
+		}
+		if jvmHeap.Percentage != 0 {
+			percentage = int64(jvmHeap.Percentage)
+		}
+	}
+	switch sizing {
+	case "MaxRAMPercentage":
+		// The JVM reads the limit from the cgroup of the container
+		return fmt.Sprintf("-XX:MaxRAMPercentage=%d.0", percentage)
+	case "Xmx":
+		if heap := limit.Value() * percentage / 100 / (1024 * 1024); heap > 0 {
+			return fmt.Sprintf("-Xmx%dm", heap)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,63 @@
+package webserver
+
+import (
+	"testing"
+
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestJVMHeapOption(t *testing.T) {
+	limits := corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")}
+	tests := []struct {
+		name      string
+		resources *corev1.ResourceRequirements
+		jvmHeap   *webserversv1alpha1.JVMHeapSpec
+		option    string
+	}{
+		{
+			name:   "no resources",
+			option: "",
+		},
+		{
+			name:      "no memory limit",
+			resources: &corev1.ResourceRequirements{Requests: limits},
+			option:    "",
+		},
+		{
+			name:      "default",
+			resources: &corev1.ResourceRequirements{Limits: limits},
+			option:    "-XX:MaxRAMPercentage=75.0",
+		},
+		{
+			name:      "percentage",
+			resources: &corev1.ResourceRequirements{Limits: limits},
+			jvmHeap:   &webserversv1alpha1.JVMHeapSpec{Sizing: "MaxRAMPercentage", Percentage: 50},
+			option:    "-XX:MaxRAMPercentage=50.0",
+		},
+		{
+			name:      "xmx",
+			resources: &corev1.ResourceRequirements{Limits: limits},
+			jvmHeap:   &webserversv1alpha1.JVMHeapSpec{Sizing: "Xmx", Percentage: 60},
+			option:    "-Xmx614m",
+		},
+		{
+			name:      "none",
+			resources: &corev1.ResourceRequirements{Limits: limits},
+			jvmHeap:   &webserversv1alpha1.JVMHeapSpec{Sizing: "None"},
+			option:    "",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			webServer := &webserversv1alpha1.WebServer{
+				Spec: webserversv1alpha1.WebServerSpec{Resources: test.resources, JVMHeap: test.jvmHeap},
+			}
+			if option := jvmHeapOption(webServer); option != test.option {
+				t.Errorf("got %q, expected %q", option, test.option)
+			}
+		})
+	}
+}
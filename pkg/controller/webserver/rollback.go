@@ -0,0 +1,155 @@
+package webserver
+
+import (
+	"fmt"
+	"strings"
+
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+
+	kbappsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// rollbackAnnotation is the annotation of the WebServer requesting the rollback of the Deployment to the last known
+// good image, the operator removes it once it is handled
+const rollbackAnnotation = "web.servers.org/rollback"
+
+// deployedImage returns the image of the pods of the Deployment: the application image, or the last known good image
+// when the application image was rolled back
+func deployedImage(t *webserversv1alpha1.WebServer) string {
+	image := t.Spec.WebImage.ApplicationImage
+	if t.Status.RolledBackImage == image && t.Status.LastKnownGoodImage != "" {
+		return t.Status.LastKnownGoodImage
+	}
+	return image
+}
+
+// pinnedImage returns the image pinned to the digest of the image ID reported by the container runtime, the image as
+// is when the ID doesn't contain the digest of the manifest
+func pinnedImage(image string, imageID string) string {
+	index := strings.Index(imageID, "@sha256:")
+	if index < 0 {
+		return image
+	}
+	repository := image
+	if at := strings.Index(repository, "@"); at >= 0 {
+		repository = repository[:at]
+	}
+	if colon := strings.LastIndex(repository, ":"); colon > strings.LastIndex(repository, "/") {
+		repository = repository[:colon]
+	}
+	return repository + imageID[index:]
+}
+
+// podImage returns the image, pinned to its digest, of the pods of the current pod template of the Deployment,
+// the image of the template while no pod reports it
+func podImage(deployment *kbappsv1.Deployment, pods []corev1.Pod) string {
+	container := deployment.Spec.Template.Spec.Containers[0]
+	hash := podTemplateHash(deployment.Spec.Template)
+	for _, pod := range pods {
+		if pod.Annotations[podTemplateHashAnnotation] != hash {
+			continue
+		}
+		for _, containerStatus := range pod.Status.ContainerStatuses {
+			if containerStatus.Name == container.Name && containerStatus.ImageID != "" {
+				return pinnedImage(container.Image, containerStatus.ImageID)
+			}
+		}
+	}
+	return container.Image
+}
+
+// isRolledOut returns whether all the pods of the Deployment run its current pod template and are ready
+func isRolledOut(deployment *kbappsv1.Deployment) bool {
+	replicas := *deployment.Spec.Replicas
+	return replicas > 0 && deployment.Status.ObservedGeneration >= deployment.Generation && deployment.Status.UpdatedReplicas == replicas &&
+		deployment.Status.Replicas == replicas && deployment.Status.ReadyReplicas == replicas
+}
+
+// progressDeadlineExceeded returns whether the Deployment failed to roll out its pod template in time
+func progressDeadlineExceeded(deployment *kbappsv1.Deployment) bool {
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == kbappsv1.DeploymentProgressing && condition.Status == corev1.ConditionFalse && condition.Reason == "ProgressDeadlineExceeded" {
+			return true
+		}
+	}
+	return false
+}
+
+// deploymentFailure returns the reason and the message of the failure of the current pod template of the Deployment:
+// it doesn't progress or one of its pods is crash looping. The reason is empty while the pod template doesn't fail.
+func deploymentFailure(deployment *kbappsv1.Deployment, pods []corev1.Pod) (string, string) {
+	if progressDeadlineExceeded(deployment) {
+		return "ProgressDeadlineExceeded", "Deployment " + deployment.Name + " exceeded its progress deadline"
+	}
+	hash := podTemplateHash(deployment.Spec.Template)
+	for _, pod := range pods {
+		if pod.Annotations[podTemplateHashAnnotation] != hash {
+			continue
+		}
+		for _, containerStatus := range pod.Status.ContainerStatuses {
+			if containerStatus.State.Waiting != nil && containerStatus.State.Waiting.Reason == "CrashLoopBackOff" {
+				return "CrashLoopBackOff", fmt.Sprintf("container %s of pod %s is crash looping", containerStatus.Name, pod.Name)
+			}
+		}
+	}
+	return "", ""
+}
+
+// reconcileRollback records the last known good image once all the pods of the Deployment are ready, and reverts the
+// Deployment to it when the pods of a new image fail or when the rollback annotation is set. It returns nil when the
+// Deployment is reconciled as usual.
+func (r *ReconcileWebServer) reconcileRollback(t *webserversv1alpha1.WebServer, deployment *kbappsv1.Deployment) (*reconcile.Result, error) {
+	image := t.Spec.WebImage.ApplicationImage
+	if t.Status.RolledBackImage != "" && t.Status.RolledBackImage != image {
+		// The application image changed since the rollback, it is deployed
+		t.Status.RolledBackImage = ""
+		setCondition(t, webserversv1alpha1.WebServerRolledBack, corev1.ConditionFalse, "ApplicationImageChanged", "Deploying the application image "+image)
+	}
+	_, requested := t.Annotations[rollbackAnnotation]
+	if err := r.removeAnnotation(t, rollbackAnnotation); err != nil {
+		return &reconcile.Result{}, err
+	}
+	if t.Status.RolledBackImage != "" {
+		return nil, nil
+	}
+
+	podList, err := GetPodsForWebServer(r, t)
+	if err != nil {
+		reqLogger.Error(err, "Failed to get pod list.")
+		return &reconcile.Result{}, err
+	}
+	current := podImage(deployment, podList.Items)
+	reason, message := "RollbackRequested", "the annotation "+rollbackAnnotation+" was set"
+	if !requested {
+		if isRolledOut(deployment) {
+			if deployment.Spec.Template.Spec.Containers[0].Image == image && t.Status.LastKnownGoodImage != current {
+				reqLogger.Info("Recording the last known good image " + current)
+				t.Status.LastKnownGoodImage = current
+			}
+			return nil, nil
+		}
+		if t.Spec.UpdateStrategy == nil || !t.Spec.UpdateStrategy.AutomaticRollback {
+			return nil, nil
+		}
+		if reason, message = deploymentFailure(deployment, podList.Items); reason == "" {
+			return nil, nil
+		}
+	}
+
+	if t.Status.LastKnownGoodImage == "" || t.Status.LastKnownGoodImage == current {
+		// Reverting to the same image wouldn't fix the pods
+		reqLogger.Info("No other image than " + current + " was ready, the Deployment can't be rolled back: " + message)
+		if requested {
+			r.recorder.Eventf(t, corev1.EventTypeWarning, "RollbackFailed", "Can't roll back Deployment %s, no other image than %s was ready", deployment.Name, current)
+		}
+		return nil, nil
+	}
+	t.Status.RolledBackImage = image
+	setCondition(t, webserversv1alpha1.WebServerRolledBack, corev1.ConditionTrue, reason,
+		fmt.Sprintf("Rolled back from image %s to the last known good image %s: %s", image, t.Status.LastKnownGoodImage, message))
+	r.recorder.Eventf(t, corev1.EventTypeWarning, "RolledBack", "Rolled back Deployment %s from image %s to the last known good image %s: %s",
+		deployment.Name, image, t.Status.LastKnownGoodImage, message)
+	return &reconcile.Result{Requeue: true}, nil
+}
@@ -0,0 +1,300 @@
+package webserver
+
+import (
+	"context"
+	"testing"
+
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+
+	kbappsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	goodImage = "quay.io/example/tomcat@sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	newImage  = "quay.io/example/tomcat:2.0"
+	newDigest = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+)
+
+func TestPinnedImage(t *testing.T) {
+	digest := "sha256:4d2d9e3c1e2f0a5b6c7d8e9f00112233445566778899aabbccddeeff00112233"
+	tests := []struct {
+		name    string
+		image   string
+		imageID string
+		pinned  string
+	}{
+		{
+			name:    "tag",
+			image:   "quay.io/example/tomcat:latest",
+			imageID: "quay.io/example/tomcat@" + digest,
+			pinned:  "quay.io/example/tomcat@" + digest,
+		},
+		{
+			name:    "docker",
+			image:   "quay.io/example/tomcat:1.0",
+			imageID: "docker-pullable://quay.io/example/tomcat@" + digest,
+			pinned:  "quay.io/example/tomcat@" + digest,
+		},
+		{
+			name:    "registry port",
+			image:   "registry.example.com:5000/tomcat",
+			imageID: "registry.example.com:5000/tomcat@" + digest,
+			pinned:  "registry.example.com:5000/tomcat@" + digest,
+		},
+		{
+			name:    "digest",
+			image:   "quay.io/example/tomcat@sha256:0000000000000000000000000000000000000000000000000000000000000000",
+			imageID: "quay.io/example/tomcat@" + digest,
+			pinned:  "quay.io/example/tomcat@" + digest,
+		},
+		{
+			name:    "no repository digest",
+			image:   "tomcat:9",
+			imageID: digest,
+			pinned:  "tomcat:9",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if pinned := pinnedImage(test.image, test.imageID); pinned != test.pinned {
+				t.Errorf("got %s, expected %s", pinned, test.pinned)
+			}
+		})
+	}
+}
+
+// rollbackWebServer returns a WebServer deploying the application image, rolled back automatically when automatic is set
+func rollbackWebServer(image string, automatic bool) *webserversv1alpha1.WebServer {
+	return &webserversv1alpha1.WebServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "example-webserver", Namespace: "jws"},
+		Spec: webserversv1alpha1.WebServerSpec{
+			ApplicationName: "example",
+			Replicas:        2,
+			WebImage:        &webserversv1alpha1.WebImageSpec{ApplicationImage: image},
+			UpdateStrategy:  &webserversv1alpha1.UpdateStrategySpec{AutomaticRollback: automatic},
+		},
+		Status: webserversv1alpha1.WebServerStatus{LastKnownGoodImage: goodImage},
+	}
+}
+
+// rollbackDeployment returns the Deployment of the image, all its pods are ready when rolledOut is set
+func rollbackDeployment(image string, rolledOut bool) *kbappsv1.Deployment {
+	replicas := int32(2)
+	deployment := &kbappsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "jws", Generation: 2},
+		Spec: kbappsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "example", Image: image}}}},
+		},
+		Status: kbappsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: replicas, UpdatedReplicas: replicas, ReadyReplicas: 1},
+	}
+	if rolledOut {
+		deployment.Status.ReadyReplicas = replicas
+	}
+	setPodTemplateHash(&deployment.Spec.Template)
+	return deployment
+}
+
+// rollbackPod returns a pod of the pod template of the Deployment running the image of the digest, crash looping
+// when crashLooping is set
+func rollbackPod(deployment *kbappsv1.Deployment, name string, digest string, crashLooping bool) *corev1.Pod {
+	containerStatus := corev1.ContainerStatus{Name: "example", ImageID: "docker-pullable://quay.io/example/tomcat@" + digest}
+	if crashLooping {
+		containerStatus.State.Waiting = &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "jws",
+			Labels:      map[string]string{"deploymentConfig": "example", "WebServer": "example-webserver"},
+			Annotations: map[string]string{podTemplateHashAnnotation: podTemplateHash(deployment.Spec.Template)},
+		},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{containerStatus}},
+	}
+}
+
+func TestIsRolledOut(t *testing.T) {
+	tests := []struct {
+		name      string
+		update    func(*kbappsv1.Deployment)
+		rolledOut bool
+	}{
+		{name: "rolled out", update: func(*kbappsv1.Deployment) {}, rolledOut: true},
+		{name: "new generation", update: func(deployment *kbappsv1.Deployment) { deployment.Generation = 3 }},
+		{name: "old pods", update: func(deployment *kbappsv1.Deployment) { deployment.Status.UpdatedReplicas = 1 }},
+		{name: "surge", update: func(deployment *kbappsv1.Deployment) { deployment.Status.Replicas = 3 }},
+		{name: "not ready", update: func(deployment *kbappsv1.Deployment) { deployment.Status.ReadyReplicas = 1 }},
+		{
+			name: "scaled to zero",
+			update: func(deployment *kbappsv1.Deployment) {
+				replicas := int32(0)
+				deployment.Spec.Replicas = &replicas
+				deployment.Status = kbappsv1.DeploymentStatus{ObservedGeneration: 2}
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			deployment := rollbackDeployment(newImage, true)
+			test.update(deployment)
+			if rolledOut := isRolledOut(deployment); rolledOut != test.rolledOut {
+				t.Errorf("got %t, expected %t", rolledOut, test.rolledOut)
+			}
+		})
+	}
+}
+
+func TestDeploymentFailure(t *testing.T) {
+	deployment := rollbackDeployment(newImage, false)
+	previous := rollbackDeployment(goodImage, true)
+	tests := []struct {
+		name     string
+		progress corev1.ConditionStatus
+		pods     []corev1.Pod
+		reason   string
+	}{
+		{name: "progressing", progress: corev1.ConditionTrue, pods: []corev1.Pod{*rollbackPod(deployment, "example-1", newDigest, false)}},
+		{name: "progress deadline exceeded", progress: corev1.ConditionFalse, reason: "ProgressDeadlineExceeded"},
+		{name: "crash looping", progress: corev1.ConditionTrue, pods: []corev1.Pod{*rollbackPod(deployment, "example-1", newDigest, true)}, reason: "CrashLoopBackOff"},
+		{name: "previous pod crash looping", progress: corev1.ConditionTrue, pods: []corev1.Pod{*rollbackPod(previous, "example-1", newDigest, true)}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			deployment := deployment.DeepCopy()
+			deployment.Status.Conditions = []kbappsv1.DeploymentCondition{{Type: kbappsv1.DeploymentProgressing, Status: test.progress}}
+			if test.progress == corev1.ConditionFalse {
+				deployment.Status.Conditions[0].Reason = "ProgressDeadlineExceeded"
+			}
+			if reason, message := deploymentFailure(deployment, test.pods); reason != test.reason || (reason != "") != (message != "") {
+				t.Errorf("got %q: %q, expected the reason %q", reason, message, test.reason)
+			}
+		})
+	}
+}
+
+func TestReconcileRollbackRecordsLastKnownGoodImage(t *testing.T) {
+	webServer := rollbackWebServer(newImage, true)
+	deployment := rollbackDeployment(newImage, true)
+	r := newTestReconciler(t, webServer, rollbackPod(deployment, "example-1", newDigest, false))
+
+	result, err := r.reconcileRollback(webServer, deployment)
+	if err != nil || result != nil {
+		t.Fatalf("got %v and %v, expected the Deployment to be reconciled as usual", result, err)
+	}
+	// The image is pinned to its digest, a tag may be moved to a broken image
+	if expected := "quay.io/example/tomcat@" + newDigest; webServer.Status.LastKnownGoodImage != expected {
+		t.Errorf("got %s, expected the last known good image %s", webServer.Status.LastKnownGoodImage, expected)
+	}
+}
+
+func TestReconcileAutomaticRollback(t *testing.T) {
+	tests := []struct {
+		name         string
+		automatic    bool
+		deadline     bool
+		crashLooping bool
+		reason       string
+	}{
+		{name: "progress deadline exceeded", automatic: true, deadline: true, reason: "ProgressDeadlineExceeded"},
+		{name: "crash looping", automatic: true, crashLooping: true, reason: "CrashLoopBackOff"},
+		{name: "deploying", automatic: true},
+		{name: "automatic rollback disabled", crashLooping: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			webServer := rollbackWebServer(newImage, test.automatic)
+			deployment := rollbackDeployment(newImage, false)
+			if test.deadline {
+				deployment.Status.Conditions = []kbappsv1.DeploymentCondition{{Type: kbappsv1.DeploymentProgressing, Status: corev1.ConditionFalse, Reason: "ProgressDeadlineExceeded"}}
+			}
+			r := newTestReconciler(t, webServer, rollbackPod(deployment, "example-1", newDigest, test.crashLooping))
+
+			result, err := r.reconcileRollback(webServer, deployment)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.reason == "" {
+				if result != nil || webServer.Status.RolledBackImage != "" || deployedImage(webServer) != newImage {
+					t.Errorf("got %v and the rolled back image %q, expected no rollback", result, webServer.Status.RolledBackImage)
+				}
+				return
+			}
+			if result == nil || !result.Requeue {
+				t.Errorf("got %v, expected a requeue", result)
+			}
+			if webServer.Status.RolledBackImage != newImage || deployedImage(webServer) != goodImage {
+				t.Errorf("got the rolled back image %q deploying %s, expected %s to be replaced by %s", webServer.Status.RolledBackImage, deployedImage(webServer), newImage, goodImage)
+			}
+			if rolledBack := condition(webServer, webserversv1alpha1.WebServerRolledBack); rolledBack == nil || rolledBack.Status != corev1.ConditionTrue || rolledBack.Reason != test.reason {
+				t.Errorf("got %+v, expected the RolledBack condition with the reason %s", rolledBack, test.reason)
+			}
+			if !recordedEvent(r, "RolledBack") {
+				t.Error("expected a RolledBack event")
+			}
+		})
+	}
+}
+
+func TestReconcileRollbackRequested(t *testing.T) {
+	webServer := rollbackWebServer(newImage, false)
+	webServer.Annotations = map[string]string{rollbackAnnotation: ""}
+	deployment := rollbackDeployment(newImage, true)
+	r := newTestReconciler(t, webServer, rollbackPod(deployment, "example-1", newDigest, false))
+
+	result, err := r.reconcileRollback(webServer, deployment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result == nil || webServer.Status.RolledBackImage != newImage || deployedImage(webServer) != goodImage {
+		t.Errorf("got %v deploying %s, expected the rollback to %s", result, deployedImage(webServer), goodImage)
+	}
+	if rolledBack := condition(webServer, webserversv1alpha1.WebServerRolledBack); rolledBack == nil || rolledBack.Reason != "RollbackRequested" {
+		t.Errorf("got %+v, expected the RolledBack condition with the reason RollbackRequested", rolledBack)
+	}
+	stored := &webserversv1alpha1.WebServer{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Name: webServer.Name, Namespace: webServer.Namespace}, stored); err != nil {
+		t.Fatal(err)
+	}
+	if _, found := stored.Annotations[rollbackAnnotation]; found {
+		t.Errorf("got %v, expected the rollback annotation to be removed", stored.Annotations)
+	}
+}
+
+func TestReconcileRollbackWithoutOtherGoodImage(t *testing.T) {
+	webServer := rollbackWebServer(newImage, true)
+	webServer.Annotations = map[string]string{rollbackAnnotation: ""}
+	deployment := rollbackDeployment(newImage, false)
+	webServer.Status.LastKnownGoodImage = "quay.io/example/tomcat@" + newDigest
+	r := newTestReconciler(t, webServer, rollbackPod(deployment, "example-1", newDigest, true))
+
+	result, err := r.reconcileRollback(webServer, deployment)
+	if err != nil || result != nil {
+		t.Fatalf("got %v and %v, expected the Deployment to be reconciled as usual", result, err)
+	}
+	if webServer.Status.RolledBackImage != "" {
+		t.Errorf("got the rolled back image %s, expected no rollback to the failing image", webServer.Status.RolledBackImage)
+	}
+	if !recordedEvent(r, "RollbackFailed") {
+		t.Error("expected a RollbackFailed event")
+	}
+}
+
+func TestReconcileRollbackClearedOnNewImage(t *testing.T) {
+	webServer := rollbackWebServer("quay.io/example/tomcat:3.0", true)
+	webServer.Status.RolledBackImage = newImage
+	deployment := rollbackDeployment(goodImage, true)
+	r := newTestReconciler(t, webServer)
+
+	if _, err := r.reconcileRollback(webServer, deployment); err != nil {
+		t.Fatal(err)
+	}
+	if webServer.Status.RolledBackImage != "" || deployedImage(webServer) != "quay.io/example/tomcat:3.0" {
+		t.Errorf("got the rolled back image %q deploying %s, expected the new application image", webServer.Status.RolledBackImage, deployedImage(webServer))
+	}
+	if rolledBack := condition(webServer, webserversv1alpha1.WebServerRolledBack); rolledBack == nil || rolledBack.Status != corev1.ConditionFalse || rolledBack.Reason != "ApplicationImageChanged" {
+		t.Errorf("got %+v, expected the RolledBack condition to be cleared", rolledBack)
+	}
+}
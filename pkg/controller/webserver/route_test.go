@@ -0,0 +1,190 @@
+package webserver
+
+import (
+	"testing"
+
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// routeWebServer returns a WebServer exposed with the Route, terminating TLS when tls is set
+func routeWebServer(route *webserversv1alpha1.RouteSpec, tls bool) *webserversv1alpha1.WebServer {
+	webServer := &webserversv1alpha1.WebServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "example-webserver", Namespace: "jws"},
+		Spec: webserversv1alpha1.WebServerSpec{
+			ApplicationName: "example",
+			Replicas:        1,
+			WebImage:        &webserversv1alpha1.WebImageSpec{ApplicationImage: "quay.io/example/tomcat:1.0"},
+			Route:           route,
+		},
+	}
+	if tls {
+		webServer.Spec.TLS = &webserversv1alpha1.TLSSpec{}
+	}
+	return webServer
+}
+
+func TestRouteForWebServer(t *testing.T) {
+	tests := []struct {
+		name        string
+		webServer   *webserversv1alpha1.WebServer
+		host        string
+		path        string
+		termination routev1.TLSTerminationType
+		insecure    routev1.InsecureEdgeTerminationPolicyType
+		targetPort  string
+		annotations map[string]string
+	}{
+		{
+			name:        "default",
+			webServer:   routeWebServer(nil, false),
+			annotations: map[string]string{"description": "Route for application's http service."},
+		},
+		{
+			name: "host and path",
+			webServer: routeWebServer(&webserversv1alpha1.RouteSpec{
+				Host:        "example.apps.cluster",
+				Path:        "/example",
+				Timeout:     "60s",
+				CookieName:  "example-route",
+				Annotations: map[string]string{"haproxy.router.openshift.io/balance": "roundrobin"},
+			}, false),
+			host: "example.apps.cluster",
+			path: "/example",
+			annotations: map[string]string{
+				"description":                         "Route for application's http service.",
+				"haproxy.router.openshift.io/balance": "roundrobin",
+				"haproxy.router.openshift.io/timeout": "60s",
+				"router.openshift.io/cookie_name":     "example-route",
+			},
+		},
+		{
+			name: "edge",
+			webServer: routeWebServer(&webserversv1alpha1.RouteSpec{
+				TLS: &webserversv1alpha1.RouteTLSSpec{Termination: "edge", InsecureEdgeTerminationPolicy: "Redirect"},
+			}, false),
+			termination: routev1.TLSTerminationEdge,
+			insecure:    routev1.InsecureEdgeTerminationPolicyRedirect,
+			annotations: map[string]string{"description": "Route for application's http service."},
+		},
+		{
+			name: "edge to the HTTP port of a TLS application",
+			webServer: routeWebServer(&webserversv1alpha1.RouteSpec{
+				TLS: &webserversv1alpha1.RouteTLSSpec{Termination: "edge"},
+			}, true),
+			termination: routev1.TLSTerminationEdge,
+			targetPort:  "ui",
+			annotations: map[string]string{"description": "Route for application's http service."},
+		},
+		{
+			name: "passthrough",
+			webServer: routeWebServer(&webserversv1alpha1.RouteSpec{
+				TLS: &webserversv1alpha1.RouteTLSSpec{Termination: "passthrough"},
+			}, true),
+			termination: routev1.TLSTerminationPassthrough,
+			targetPort:  "https",
+			annotations: map[string]string{"description": "Route for application's http service."},
+		},
+		{
+			name: "reencrypt",
+			webServer: routeWebServer(&webserversv1alpha1.RouteSpec{
+				TLS: &webserversv1alpha1.RouteTLSSpec{Termination: "reencrypt"},
+			}, true),
+			termination: routev1.TLSTerminationReencrypt,
+			targetPort:  "https",
+			annotations: map[string]string{"description": "Route for application's http service."},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := newTestReconciler(t)
+			route := r.routeForWebServer(test.webServer)
+
+			if route.Spec.To.Name != "example" || route.Spec.Host != test.host || route.Spec.Path != test.path {
+				t.Errorf("got %+v, expected the Service example on host %q and path %q", route.Spec, test.host, test.path)
+			}
+			if test.termination == "" {
+				if route.Spec.TLS != nil {
+					t.Errorf("got %+v, expected no TLS termination", route.Spec.TLS)
+				}
+			} else if route.Spec.TLS == nil || route.Spec.TLS.Termination != test.termination || route.Spec.TLS.InsecureEdgeTerminationPolicy != test.insecure {
+				t.Errorf("got %+v, expected the %s termination with the %q insecure policy", route.Spec.TLS, test.termination, test.insecure)
+			}
+			if test.targetPort == "" {
+				if route.Spec.Port != nil {
+					t.Errorf("got %+v, expected no target port", route.Spec.Port)
+				}
+			} else if route.Spec.Port == nil || route.Spec.Port.TargetPort != intstr.FromString(test.targetPort) {
+				t.Errorf("got %+v, expected the target port %s", route.Spec.Port, test.targetPort)
+			}
+			for key, value := range test.annotations {
+				if route.Annotations[key] != value {
+					t.Errorf("got %s=%q, expected %q", key, route.Annotations[key], value)
+				}
+			}
+			if len(route.Annotations) != len(test.annotations)+1 || route.Annotations[managedAnnotationsAnnotation] == "" {
+				t.Errorf("got %v, expected the annotations %v and the managed annotations", route.Annotations, test.annotations)
+			}
+		})
+	}
+}
+
+func TestSetRouteCertificates(t *testing.T) {
+	certificate := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "example-route-tls", Namespace: "jws"},
+		Data:       map[string][]byte{corev1.TLSCertKey: []byte("certificate"), corev1.TLSPrivateKeyKey: []byte("key"), "ca.crt": []byte("ca")},
+	}
+	destinationCA := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "example-destination-ca", Namespace: "jws"},
+		Data:       map[string][]byte{"ca.crt": []byte("destination-ca")},
+	}
+	r := newTestReconciler(t, certificate, destinationCA)
+
+	webServer := routeWebServer(&webserversv1alpha1.RouteSpec{TLS: &webserversv1alpha1.RouteTLSSpec{
+		Termination:                        "reencrypt",
+		CertificateSecretName:              certificate.Name,
+		DestinationCACertificateSecretName: destinationCA.Name,
+	}}, true)
+	route := r.routeForWebServer(webServer)
+	if err := r.setRouteCertificates(webServer, route); err != nil {
+		t.Fatal(err)
+	}
+	expected := routev1.TLSConfig{
+		Termination:              routev1.TLSTerminationReencrypt,
+		Certificate:              "certificate",
+		Key:                      "key",
+		CACertificate:            "ca",
+		DestinationCACertificate: "destination-ca",
+	}
+	if *route.Spec.TLS != expected {
+		t.Errorf("got %+v, expected %+v", *route.Spec.TLS, expected)
+	}
+
+	// The default certificate of the router is used without certificate Secret
+	webServer = routeWebServer(&webserversv1alpha1.RouteSpec{TLS: &webserversv1alpha1.RouteTLSSpec{Termination: "edge"}}, false)
+	route = r.routeForWebServer(webServer)
+	if err := r.setRouteCertificates(webServer, route); err != nil {
+		t.Fatal(err)
+	}
+	if route.Spec.TLS.Certificate != "" || route.Spec.TLS.Key != "" {
+		t.Errorf("got %+v, expected the default certificate of the router", *route.Spec.TLS)
+	}
+
+	// The Route isn't created until the Secret exists
+	webServer.Spec.Route.TLS.CertificateSecretName = "missing"
+	route = r.routeForWebServer(webServer)
+	if err := r.setRouteCertificates(webServer, route); err == nil {
+		t.Error("got no error, expected the missing Secret to be reported")
+	}
+
+	// Nothing is loaded without TLS termination
+	webServer = routeWebServer(nil, false)
+	route = r.routeForWebServer(webServer)
+	if err := r.setRouteCertificates(webServer, route); err != nil || route.Spec.TLS != nil {
+		t.Errorf("got %v and %+v, expected no TLS configuration", err, route.Spec.TLS)
+	}
+}
@@ -0,0 +1,46 @@
+package webserver
+
+import (
+	"context"
+
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// referencedSecrets returns the names of the Secrets used by the WebServer which it doesn't own, they are indexed
+// under secretsIndexField. The WebServer is reconciled again when one of them changes.
+func referencedSecrets(t *webserversv1alpha1.WebServer) []string {
+	secrets := []string{}
+	if tls := t.Spec.TLS; tls != nil {
+		secrets = append(secrets, tls.CertificateSecretName)
+		if tls.Keystore != nil {
+			secrets = append(secrets, tls.Keystore.PasswordSecretKeyRef.Name)
+		}
+	}
+	if t.Spec.SessionStore != nil {
+		secrets = append(secrets, t.Spec.SessionStore.SecretName)
+	}
+	if route := t.Spec.Route; route != nil && route.TLS != nil {
+		if route.TLS.CertificateSecretName != "" {
+			secrets = append(secrets, route.TLS.CertificateSecretName)
+		}
+		if route.TLS.DestinationCACertificateSecretName != "" {
+			secrets = append(secrets, route.TLS.DestinationCACertificateSecretName)
+		}
+	}
+	_, podSecrets := podConfigMapsAndSecrets(t)
+	return append(secrets, podSecrets...)
+}
+
+// getSecretValue returns the value of a key of a Secret of the namespace of the WebServer.
+// An empty value is returned when the Secret doesn't contain the key.
+func (r *ReconcileWebServer) getSecretValue(t *webserversv1alpha1.WebServer, name string, key string) (string, error) {
+	secret := &corev1.Secret{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: t.Namespace}, secret)
+	if err != nil {
+		return "", err
+	}
+	return string(secret.Data[key]), nil
+}
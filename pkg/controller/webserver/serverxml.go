@@ -0,0 +1,181 @@
+package webserver
+
+import (
+	"hash/fnv"
+	"path"
+	"strconv"
+
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+	"github.com/web-servers/jws-operator/pkg/tomcat"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// serverXmlKey is the key of server.xml in the ConfigMap of the WebServer
+	serverXmlKey = "server.xml"
+	// serverXmlHashAnnotation is the annotation of the pod template holding the hash of server.xml,
+	// the ConfigMap is mounted with a subPath so the pods are rolled out when it changes.
+	serverXmlHashAnnotation = "web.servers.org/server-xml-hash"
+	// tlsKeystorePasswordProperty is the system property holding the password of the keystore of the HTTPS connector,
+	// Tomcat replaces ${tlsKeystorePasswordProperty} in server.xml with its value.
+	tlsKeystorePasswordProperty = "webserver.tls.keystorePassword"
+)
+
+// serverXmlPath returns the path on which server.xml is mounted in the pods
+func serverXmlPath(t *webserversv1alpha1.WebServer) string {
+	return path.Join(t.Spec.CatalinaBase, "conf", serverXmlKey)
+}
+
+// serverXmlForWebServer returns server.xml generated from the WebServer
+func serverXmlForWebServer(t *webserversv1alpha1.WebServer, useKUBEPing bool) (string, error) {
+	return tomcat.Marshal(serverForWebServer(t, useKUBEPing))
+}
+
+// serverXmlHash returns the hash of server.xml
+func serverXmlHash(serverXml string) string {
+	hasher := fnv.New32a()
+	hasher.Write([]byte(serverXml))
+	return strconv.FormatUint(uint64(hasher.Sum32()), 16)
+}
+
+// serverForWebServer returns the model of server.xml: an HTTP connector on port 8080, an HTTPS connector on port 8443
+// when TLS is enabled, the cluster replicating the sessions when session clustering is enabled, and a host
+// answering the health checks of the probes on /health.
+func serverForWebServer(t *webserversv1alpha1.WebServer, useKUBEPing bool) *tomcat.Server {
+	engine := tomcat.Engine{
+		Name:        "Catalina",
+		DefaultHost: "localhost",
+		Realm: &tomcat.Realm{
+			ClassName: "org.apache.catalina.realm.LockOutRealm",
+			Realms: []tomcat.Realm{{
+				ClassName:    "org.apache.catalina.realm.UserDatabaseRealm",
+				ResourceName: "UserDatabase",
+			}},
+		},
+		Cluster: clusterForWebServer(t, useKUBEPing),
+		Hosts: []tomcat.Host{{
+			Name:       "localhost",
+			AppBase:    "webapps",
+			UnpackWARs: true,
+			AutoDeploy: true,
+			Valves: []tomcat.Valve{{
+				ClassName: "org.apache.catalina.valves.HealthCheckValve",
+			}, {
+				ClassName: "org.apache.catalina.valves.AccessLogValve",
+				Directory: "logs",
+				Prefix:    "localhost_access_log",
+				Suffix:    ".txt",
+				Pattern:   "%h %l %u %t \"%r\" %s %b",
+			}},
+		}},
+	}
+
+	connectors := []tomcat.Connector{{
+		Port:              8080,
+		Protocol:          "HTTP/1.1",
+		ConnectionTimeout: 20000,
+		RedirectPort:      8443,
+	}}
+	if t.Spec.TLS != nil {
+		connectors = append(connectors, connectorForWebServer(t.Spec.TLS))
+	}
+
+	// The arguments of the JVM are not logged, they hold the password of the keystore
+	logArgs := false
+	return &tomcat.Server{
+		Port:     -1,
+		Shutdown: "SHUTDOWN",
+		Listeners: []tomcat.Listener{
+			{ClassName: "org.apache.catalina.startup.VersionLoggerListener", LogArgs: &logArgs},
+			{ClassName: "org.apache.catalina.core.JreMemoryLeakPreventionListener"},
+			{ClassName: "org.apache.catalina.mbeans.GlobalResourcesLifecycleListener"},
+			{ClassName: "org.apache.catalina.core.ThreadLocalLeakPreventionListener"},
+		},
+		GlobalNamingResources: &tomcat.GlobalNamingResources{
+			Resources: []tomcat.Resource{{
+				Name:        "UserDatabase",
+				Auth:        "Container",
+				Type:        "org.apache.catalina.UserDatabase",
+				Description: "User database that can be updated and saved",
+				Factory:     "org.apache.catalina.users.MemoryUserDatabaseFactory",
+				Pathname:    "conf/tomcat-users.xml",
+			}},
+		},
+		Services: []tomcat.Service{{
+			Name:       "Catalina",
+			Connectors: connectors,
+			Engine:     engine,
+		}},
+	}
+}
+
+// clusterForWebServer returns the cluster replicating the sessions, nil when session clustering is disabled.
+// The members are discovered with the Kubernetes API (KUBEPing) or with the headless Service of the WebServer (DNSPing).
+func clusterForWebServer(t *webserversv1alpha1.WebServer, useKUBEPing bool) *tomcat.Cluster {
+	sessionClustering := webserversv1alpha1.SessionClusteringFor(t)
+	if sessionClustering == nil {
+		return nil
+	}
+	membership := &tomcat.Membership{
+		ClassName:                   "org.apache.catalina.tribes.membership.cloud.CloudMembershipService",
+		MembershipProviderClassName: "org.apache.catalina.tribes.membership.cloud.DNSMembershipProvider",
+	}
+	if useKUBEPing {
+		membership.MembershipProviderClassName = "org.apache.catalina.tribes.membership.cloud.KubernetesMembershipProvider"
+	}
+	if timeouts := sessionClustering.Membership; timeouts != nil {
+		membership.ConnectTimeout = timeouts.ConnectTimeout
+		membership.ReadTimeout = timeouts.ReadTimeout
+		membership.ExpirationTime = timeouts.ExpirationTime
+	}
+
+	cluster := &tomcat.Cluster{
+		ClassName:          "org.apache.catalina.ha.tcp.SimpleTcpCluster",
+		ChannelSendOptions: int(sessionClustering.ChannelSendOptions),
+		Manager: &tomcat.Manager{
+			ClassName:                    "org.apache.catalina.ha.session." + sessionClustering.ManagerType,
+			ExpireSessionsOnShutdown:     sessionClustering.ExpireSessionsOnShutdown,
+			NotifyListenersOnReplication: sessionClustering.NotifyListenersOnReplication,
+		},
+		Channel: &tomcat.Channel{
+			ClassName:  "org.apache.catalina.tribes.group.GroupChannel",
+			Membership: membership,
+		},
+	}
+	if sessionClustering.ReplicationFilter != "" {
+		// Declaring a Valve replaces the default ones of the cluster, the JvmRouteBinderValve is kept
+		cluster.Valves = []tomcat.Valve{{
+			ClassName: "org.apache.catalina.ha.tcp.ReplicationValve",
+			Filter:    sessionClustering.ReplicationFilter,
+		}, {
+			ClassName: "org.apache.catalina.ha.session.JvmRouteBinderValve",
+		}}
+	}
+	return cluster
+}
+
+// connectorForWebServer returns the HTTPS connector, it reads the certificate from the mounted Secret
+func connectorForWebServer(tls *webserversv1alpha1.TLSSpec) tomcat.Connector {
+	certificate := tomcat.Certificate{
+		CertificateFile:    path.Join(tlsMountPath, corev1.TLSCertKey),
+		CertificateKeyFile: path.Join(tlsMountPath, corev1.TLSPrivateKeyKey),
+	}
+	if keystore := tls.Keystore; keystore != nil {
+		certificate = tomcat.Certificate{
+			CertificateKeystoreFile:     path.Join(tlsMountPath, keystore.Key),
+			CertificateKeystoreType:     keystore.Type,
+			CertificateKeystorePassword: "${" + tlsKeystorePasswordProperty + "}",
+		}
+	}
+	return tomcat.Connector{
+		Port:       8443,
+		Protocol:   "org.apache.coyote.http11.Http11NioProtocol",
+		SSLEnabled: true,
+		Scheme:     "https",
+		Secure:     true,
+		SSLHostConfigs: []tomcat.SSLHostConfig{{
+			Certificates: []tomcat.Certificate{certificate},
+		}},
+	}
+}
@@ -0,0 +1,143 @@
+package webserver
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var update = flag.Bool("update", false, "update the golden files of the testdata directory")
+
+func webServerForServerXml(spec webserversv1alpha1.WebServerSpec) *webserversv1alpha1.WebServer {
+	spec.ApplicationName = "example"
+	spec.Replicas = 2
+	spec.WebImage = &webserversv1alpha1.WebImageSpec{ApplicationImage: "quay.io/example/tomcat:latest"}
+	t := &webserversv1alpha1.WebServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "example-webserver", Namespace: "jws"},
+		Spec:       spec,
+	}
+	webserversv1alpha1.SetDefaults(t)
+	return t
+}
+
+// TestServerXml compares the generated server.xml with the golden files of the testdata directory,
+// run the test with -update to regenerate them.
+func TestServerXml(t *testing.T) {
+	connectTimeout := int32(2000)
+	expirationTime := int32(10000)
+	notifyListenersOnReplication := false
+	tests := []struct {
+		name        string
+		webServer   *webserversv1alpha1.WebServer
+		useKUBEPing bool
+	}{
+		{
+			name:      "default",
+			webServer: webServerForServerXml(webserversv1alpha1.WebServerSpec{}),
+		},
+		{
+			name:        "session-clustering-kubeping",
+			webServer:   webServerForServerXml(webserversv1alpha1.WebServerSpec{UseSessionClustering: true}),
+			useKUBEPing: true,
+		},
+		{
+			name:      "session-clustering-dnsping",
+			webServer: webServerForServerXml(webserversv1alpha1.WebServerSpec{UseSessionClustering: true}),
+		},
+		{
+			name: "session-clustering-backupmanager",
+			webServer: webServerForServerXml(webserversv1alpha1.WebServerSpec{
+				SessionClustering: &webserversv1alpha1.SessionClusteringSpec{
+					ManagerType:                  "BackupManager",
+					ChannelSendOptions:           8,
+					Membership:                   &webserversv1alpha1.MembershipSpec{ConnectTimeout: &connectTimeout, ExpirationTime: &expirationTime},
+					NotifyListenersOnReplication: &notifyListenersOnReplication,
+					ReplicationFilter:            ".*\\.gif|.*\\.js|.*\\.css",
+				},
+			}),
+		},
+		{
+			name: "tls-pem",
+			webServer: webServerForServerXml(webserversv1alpha1.WebServerSpec{
+				TLS: &webserversv1alpha1.TLSSpec{},
+			}),
+		},
+		{
+			name: "tls-keystore",
+			webServer: webServerForServerXml(webserversv1alpha1.WebServerSpec{
+				TLS: &webserversv1alpha1.TLSSpec{
+					Keystore: &webserversv1alpha1.KeystoreSpec{
+						Key:  "keystore.jks",
+						Type: "JKS",
+						PasswordSecretKeyRef: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "example-keystore"},
+							Key:                  "password",
+						},
+					},
+				},
+			}),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			serverXml, err := serverXmlForWebServer(test.webServer, test.useKUBEPing)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", "server-"+test.name+".xml")
+			if *update {
+				if err := ioutil.WriteFile(golden, []byte(serverXml), 0644); err != nil {
+					t.Fatalf("failed to update %s: %v", golden, err)
+				}
+			}
+			expected, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", golden, err)
+			}
+			if serverXml != string(expected) {
+				t.Errorf("server.xml doesn't match %s:\n%s", golden, serverXml)
+			}
+		})
+	}
+}
+
+// TestUseSessionClusteringDefaults checks that useSessionClustering renders the default cluster
+// without adding sessionClustering to the WebServer.
+func TestUseSessionClusteringDefaults(t *testing.T) {
+	webServer := webServerForServerXml(webserversv1alpha1.WebServerSpec{UseSessionClustering: true})
+	if webServer.Spec.SessionClustering != nil {
+		t.Errorf("got %v, expected sessionClustering to be left unset", webServer.Spec.SessionClustering)
+	}
+	cluster := clusterForWebServer(webServer, false)
+	if cluster == nil {
+		t.Fatal("got no cluster, expected the default cluster")
+	}
+	if manager := "org.apache.catalina.ha.session." + webserversv1alpha1.DefaultSessionManagerType; cluster.Manager.ClassName != manager {
+		t.Errorf("got %s, expected %s", cluster.Manager.ClassName, manager)
+	}
+	if cluster.ChannelSendOptions != webserversv1alpha1.DefaultChannelSendOptions {
+		t.Errorf("got %d, expected %d", cluster.ChannelSendOptions, webserversv1alpha1.DefaultChannelSendOptions)
+	}
+}
+
+// TestKeystorePasswordIsNotLogged checks that Tomcat doesn't log the command line holding the password of the keystore
+func TestKeystorePasswordIsNotLogged(t *testing.T) {
+	webServer := webServerForServerXml(webserversv1alpha1.WebServerSpec{
+		TLS: &webserversv1alpha1.TLSSpec{Keystore: &webserversv1alpha1.KeystoreSpec{Key: "keystore.jks"}},
+	})
+	serverXml, err := serverXmlForWebServer(webServer, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener := `<Listener className="org.apache.catalina.startup.VersionLoggerListener" logArgs="false">`
+	if !strings.Contains(serverXml, listener) {
+		t.Errorf("got %s, expected it to contain %s", serverXml, listener)
+	}
+}
@@ -0,0 +1,152 @@
+package webserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"path"
+	"strconv"
+
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+	"github.com/web-servers/jws-operator/pkg/tomcat"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// sessionStoreVolumeName is the name of the volume containing the configuration of the session store
+	sessionStoreVolumeName = "webserver-session-store"
+	// contextXmlKey is the key of context.xml in the Secret of the session store
+	contextXmlKey = "context.xml"
+	// redissonConfigKey is the key of the configuration of the Redisson client in the Secret of the session store
+	redissonConfigKey = "redisson.yaml"
+	// sessionStoreHashAnnotation is the annotation of the pod template holding the hash of the configuration of the
+	// session store, it is mounted with a subPath so the pods are rolled out when it changes.
+	sessionStoreHashAnnotation = "web.servers.org/session-store-hash"
+	// The keys of the Secret containing the connection details of the session store
+	sessionStoreURLKey      = "url"
+	sessionStoreUsernameKey = "username"
+	sessionStorePasswordKey = "password"
+)
+
+// sessionStoreSecretName returns the name of the Secret containing the configuration of the session store
+func sessionStoreSecretName(t *webserversv1alpha1.WebServer) string {
+	return "webserver-" + t.Name + "-session-store"
+}
+
+// sessionStoreSecretForWebServer returns the Secret containing the context.xml configuring the session store, generated
+// from the connection details of the Secret referenced by the WebServer. The connection details may contain credentials
+// so the configuration is stored in a Secret rather than in the ConfigMap of server.xml.
+func (r *ReconcileWebServer) sessionStoreSecretForWebServer(t *webserversv1alpha1.WebServer) (*corev1.Secret, error) {
+	connection := &corev1.Secret{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: t.Spec.SessionStore.SecretName, Namespace: t.Namespace}, connection)
+	if err != nil {
+		return nil, err
+	}
+	data, err := sessionStoreDataForWebServer(t, connection)
+	if err != nil {
+		return nil, err
+	}
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: objectMetaForWebServer(t, sessionStoreSecretName(t)),
+		Data:       data,
+	}
+
+	controllerutil.SetControllerReference(t, secret, r.scheme)
+	return secret, nil
+}
+
+// sessionStoreDataForWebServer returns the configuration files of the session store: context.xml and, for Redis,
+// the configuration of the Redisson client
+func sessionStoreDataForWebServer(t *webserversv1alpha1.WebServer, connection *corev1.Secret) (map[string][]byte, error) {
+	url := string(connection.Data[sessionStoreURLKey])
+	username := string(connection.Data[sessionStoreUsernameKey])
+	password := string(connection.Data[sessionStorePasswordKey])
+
+	var manager *tomcat.Manager
+	data := map[string][]byte{}
+	if redis := t.Spec.SessionStore.Redis; redis != nil {
+		manager = &tomcat.Manager{
+			ClassName:  "org.redisson.tomcat.RedissonSessionManager",
+			ConfigPath: "${catalina.base}/conf/" + redissonConfigKey,
+			KeyPrefix:  redis.KeyPrefix,
+		}
+		data[redissonConfigKey] = redissonConfigForWebServer(url, username, password)
+	}
+	if jdbc := t.Spec.SessionStore.JDBC; jdbc != nil {
+		// The sessions are saved in the database as soon as they are idle so that the other pods find them
+		maxIdleBackup := 0
+		manager = &tomcat.Manager{
+			ClassName:     "org.apache.catalina.session.PersistentManager",
+			MaxIdleBackup: &maxIdleBackup,
+			Store: &tomcat.Store{
+				ClassName:          "org.apache.catalina.session.JDBCStore",
+				DriverName:         jdbc.DriverName,
+				ConnectionURL:      url,
+				ConnectionName:     username,
+				ConnectionPassword: password,
+				SessionTable:       jdbc.SessionTable,
+			},
+		}
+	}
+
+	contextXml, err := tomcat.Marshal(&tomcat.Context{
+		WatchedResources: []string{"WEB-INF/web.xml", "WEB-INF/tomcat-web.xml", "${catalina.base}/conf/web.xml"},
+		Manager:          manager,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate context.xml: %v", err)
+	}
+	data[contextXmlKey] = []byte(contextXml)
+	return data, nil
+}
+
+// redissonConfigForWebServer returns the configuration of the Redisson client connecting to a single Redis server.
+// Redisson reads its configuration as YAML, JSON is used to quote the values.
+func redissonConfigForWebServer(url string, username string, password string) []byte {
+	singleServerConfig := map[string]string{"address": url}
+	if username != "" {
+		singleServerConfig["username"] = username
+	}
+	if password != "" {
+		singleServerConfig["password"] = password
+	}
+	config, err := json.MarshalIndent(map[string]interface{}{"singleServerConfig": singleServerConfig}, "", "  ")
+	if err != nil {
+		log.Error(err, "Failed to generate the configuration of Redisson")
+	}
+	return append(config, '\n')
+}
+
+// sessionStoreDataHash returns the hash of the configuration of the session store
+func sessionStoreDataHash(data map[string][]byte) string {
+	hasher := fnv.New32a()
+	writeData(hasher, data)
+	return strconv.FormatUint(uint64(hasher.Sum32()), 16)
+}
+
+// sessionStoreVolumeMounts returns the mounts of the configuration files of the session store in the conf directory of Tomcat
+func sessionStoreVolumeMounts(t *webserversv1alpha1.WebServer) []corev1.VolumeMount {
+	keys := []string{contextXmlKey}
+	if t.Spec.SessionStore.Redis != nil {
+		keys = append(keys, redissonConfigKey)
+	}
+	mounts := []corev1.VolumeMount{}
+	for _, key := range keys {
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      sessionStoreVolumeName,
+			MountPath: path.Join(t.Spec.CatalinaBase, "conf", key),
+			SubPath:   key,
+			ReadOnly:  true,
+		})
+	}
+	return mounts
+}
@@ -0,0 +1,95 @@
+package webserver
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// TestSessionStore compares the generated configuration of the session store with the golden files of the testdata
+// directory, run the test with -update to regenerate them.
+func TestSessionStore(t *testing.T) {
+	tests := []struct {
+		name       string
+		webServer  *webserversv1alpha1.WebServer
+		connection map[string]string
+	}{
+		{
+			name: "redis",
+			webServer: webServerForServerXml(webserversv1alpha1.WebServerSpec{
+				SessionStore: &webserversv1alpha1.SessionStoreSpec{
+					SecretName: "example-redis",
+					Redis:      &webserversv1alpha1.RedisSessionStoreSpec{},
+				},
+			}),
+			connection: map[string]string{"url": "redis://example-redis:6379"},
+		},
+		{
+			name: "redis-password",
+			webServer: webServerForServerXml(webserversv1alpha1.WebServerSpec{
+				SessionStore: &webserversv1alpha1.SessionStoreSpec{
+					SecretName: "example-redis",
+					Redis:      &webserversv1alpha1.RedisSessionStoreSpec{KeyPrefix: "example:"},
+				},
+			}),
+			connection: map[string]string{"url": "rediss://example-redis:6380", "username": "jws", "password": "s3cr\"t"},
+		},
+		{
+			name: "jdbc",
+			webServer: webServerForServerXml(webserversv1alpha1.WebServerSpec{
+				SessionStore: &webserversv1alpha1.SessionStoreSpec{
+					SecretName: "example-database",
+					JDBC:       &webserversv1alpha1.JDBCSessionStoreSpec{DriverName: "org.postgresql.Driver"},
+				},
+			}),
+			connection: map[string]string{"url": "jdbc:postgresql://example-database:5432/sessions?ssl=true&sslmode=require", "username": "jws", "password": "s3cr<t"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			connection := &corev1.Secret{Data: map[string][]byte{}}
+			for key, value := range test.connection {
+				connection.Data[key] = []byte(value)
+			}
+			data, err := sessionStoreDataForWebServer(test.webServer, connection)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			mounted := []string{}
+			for _, mount := range sessionStoreVolumeMounts(test.webServer) {
+				mounted = append(mounted, mount.SubPath)
+			}
+			keys := []string{}
+			for key := range data {
+				keys = append(keys, key)
+			}
+			sort.Strings(mounted)
+			sort.Strings(keys)
+			if len(mounted) != len(keys) {
+				t.Fatalf("the files %v are mounted, expected %v", mounted, keys)
+			}
+
+			for _, key := range keys {
+				golden := filepath.Join("testdata", "session-store-"+test.name+"-"+key)
+				if *update {
+					if err := ioutil.WriteFile(golden, data[key], 0644); err != nil {
+						t.Fatalf("failed to update %s: %v", golden, err)
+					}
+				}
+				expected, err := ioutil.ReadFile(golden)
+				if err != nil {
+					t.Fatalf("failed to read %s: %v", golden, err)
+				}
+				if string(data[key]) != string(expected) {
+					t.Errorf("%s doesn't match %s:\n%s", key, golden, data[key])
+				}
+			}
+		})
+	}
+}
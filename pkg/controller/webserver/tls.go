@@ -0,0 +1,91 @@
+package webserver
+
+import (
+	"context"
+	"hash/fnv"
+	"strconv"
+
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// tlsVolumeName is the name of the volume containing the certificate of the HTTPS connector
+	tlsVolumeName = "webserver-tls"
+	// tlsMountPath is the directory in which the Secret containing the certificate of the HTTPS connector is mounted
+	tlsMountPath = "/etc/jws-tls"
+	// tlsKeystorePasswordEnv is the environment variable holding the password of the keystore of the HTTPS connector
+	tlsKeystorePasswordEnv = "TLS_KEYSTORE_PASSWORD"
+	// tlsCertificateHashAnnotation is the annotation of the pod template holding the hash of the certificate of the
+	// HTTPS connector, Tomcat reads the certificate when it starts so the pods are rolled out when it changes.
+	tlsCertificateHashAnnotation = "web.servers.org/tls-certificate-hash"
+)
+
+// certificateGVK is the kind of the cert-manager Certificates, cert-manager is optional so they are handled as unstructured objects
+var certificateGVK = schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}
+
+// certificateForWebServer returns the cert-manager Certificate of the HTTPS connector.
+// The certificate is valid for the names of the Service of the application.
+func (r *ReconcileWebServer) certificateForWebServer(t *webserversv1alpha1.WebServer) *unstructured.Unstructured {
+	tls := t.Spec.TLS
+	service := t.Spec.ApplicationName
+	dnsNames := []interface{}{
+		service,
+		service + "." + t.Namespace,
+		service + "." + t.Namespace + ".svc",
+		service + "." + t.Namespace + ".svc.cluster.local",
+	}
+	for _, dnsName := range tls.CertManager.DNSNames {
+		dnsNames = append(dnsNames, dnsName)
+	}
+	spec := map[string]interface{}{
+		"secretName": tls.CertificateSecretName,
+		"commonName": service + "." + t.Namespace + ".svc",
+		"dnsNames":   dnsNames,
+		"issuerRef": map[string]interface{}{
+			"name":  tls.CertManager.IssuerName,
+			"kind":  tls.CertManager.IssuerKind,
+			"group": certificateGVK.Group,
+		},
+	}
+	if tls.CertManager.Duration != "" {
+		spec["duration"] = tls.CertManager.Duration
+	}
+	if tls.CertManager.RenewBefore != "" {
+		spec["renewBefore"] = tls.CertManager.RenewBefore
+	}
+
+	certificate := &unstructured.Unstructured{}
+	certificate.SetGroupVersionKind(certificateGVK)
+	objectMeta := objectMetaForWebServer(t, t.Spec.ApplicationName)
+	certificate.SetName(objectMeta.Name)
+	certificate.SetNamespace(objectMeta.Namespace)
+	certificate.SetLabels(objectMeta.Labels)
+	certificate.Object["spec"] = spec
+
+	controllerutil.SetControllerReference(t, certificate, r.scheme)
+	return certificate
+}
+
+// tlsCertificateHash returns the hash of the Secrets used by the HTTPS connector
+func (r *ReconcileWebServer) tlsCertificateHash(t *webserversv1alpha1.WebServer) (string, error) {
+	names := []string{t.Spec.TLS.CertificateSecretName}
+	if keystore := t.Spec.TLS.Keystore; keystore != nil && keystore.PasswordSecretKeyRef.Name != names[0] {
+		names = append(names, keystore.PasswordSecretKeyRef.Name)
+	}
+	hasher := fnv.New32a()
+	for _, name := range names {
+		secret := &corev1.Secret{}
+		err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: t.Namespace}, secret)
+		if err != nil {
+			return "", err
+		}
+		writeData(hasher, secret.Data)
+	}
+	return strconv.FormatUint(uint64(hasher.Sum32()), 16), nil
+}
@@ -0,0 +1,84 @@
+package webserver
+
+import (
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// userVolumes returns the volumes of the WebServer, the volumes with a reserved name are skipped: the validating
+// webhook rejects them but the WebServer may have been created without it
+func userVolumes(t *webserversv1alpha1.WebServer) []corev1.Volume {
+	volumes := []corev1.Volume{}
+	for _, volume := range t.Spec.Volumes {
+		if !webserversv1alpha1.IsReservedVolumeName(volume.Name) {
+			volumes = append(volumes, volume)
+		}
+	}
+	return volumes
+}
+
+// userVolumeMounts returns the mounts of the volumes of the WebServer, the mounts of the skipped volumes are skipped
+// as well, the pods couldn't be created with them
+func userVolumeMounts(mounts []corev1.VolumeMount) []corev1.VolumeMount {
+	userMounts := []corev1.VolumeMount{}
+	for _, mount := range mounts {
+		if !webserversv1alpha1.IsReservedVolumeName(mount.Name) {
+			userMounts = append(userMounts, mount)
+		}
+	}
+	return userMounts
+}
+
+// userContainers returns the init containers or the sidecars of the WebServer without the mounts of the skipped volumes
+func userContainers(containers []corev1.Container) []corev1.Container {
+	if containers == nil {
+		return nil
+	}
+	userContainers := []corev1.Container{}
+	for _, container := range containers {
+		container := *container.DeepCopy()
+		if container.VolumeMounts != nil {
+			container.VolumeMounts = userVolumeMounts(container.VolumeMounts)
+		}
+		userContainers = append(userContainers, container)
+	}
+	return userContainers
+}
+
+// ignoredVolumes returns the names of the volumes of the WebServer skipped because their name is reserved by the operator
+func ignoredVolumes(t *webserversv1alpha1.WebServer) []string {
+	ignored := []string{}
+	for _, volume := range t.Spec.Volumes {
+		if webserversv1alpha1.IsReservedVolumeName(volume.Name) {
+			ignored = append(ignored, volume.Name)
+		}
+	}
+	return ignored
+}
+
+// volumeConfigMapsAndSecrets returns the names of the ConfigMaps and of the Secrets mounted by the volumes
+func volumeConfigMapsAndSecrets(volumes []corev1.Volume) ([]string, []string) {
+	configMaps := []string{}
+	secrets := []string{}
+	for _, volume := range volumes {
+		if volume.ConfigMap != nil {
+			configMaps = append(configMaps, volume.ConfigMap.Name)
+		}
+		if volume.Secret != nil {
+			secrets = append(secrets, volume.Secret.SecretName)
+		}
+		if volume.Projected == nil {
+			continue
+		}
+		for _, source := range volume.Projected.Sources {
+			if source.ConfigMap != nil {
+				configMaps = append(configMaps, source.ConfigMap.Name)
+			}
+			if source.Secret != nil {
+				secrets = append(secrets, source.Secret.Name)
+			}
+		}
+	}
+	return configMaps, secrets
+}
@@ -0,0 +1,63 @@
+package webserver
+
+import (
+	"reflect"
+	"testing"
+
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReservedVolumesAreIgnored(t *testing.T) {
+	webServer := &webserversv1alpha1.WebServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "test"},
+		Spec: webserversv1alpha1.WebServerSpec{
+			ApplicationName: "example",
+			CatalinaBase:    webserversv1alpha1.DefaultCatalinaBase,
+			WebImage:        &webserversv1alpha1.WebImageSpec{ApplicationImage: "quay.io/example/tomcat:1.0"},
+			Volumes:         []corev1.Volume{{Name: "libs"}, {Name: "app-volume"}, {Name: "webserver-logs"}},
+			VolumeMounts: []corev1.VolumeMount{
+				{Name: "libs", MountPath: "/opt/jws-5.4/tomcat/lib/ext"},
+				{Name: "app-volume", MountPath: "/deployments"},
+			},
+			InitContainers: []corev1.Container{{
+				Name:         "fetch-libs",
+				Image:        "quay.io/example/fetch:1.0",
+				VolumeMounts: []corev1.VolumeMount{{Name: "libs", MountPath: "/libs"}, {Name: "webserver-logs", MountPath: "/logs"}},
+			}},
+			Sidecars: []corev1.Container{{
+				Name:         "log-shipper",
+				Image:        "quay.io/example/shipper:1.0",
+				VolumeMounts: []corev1.VolumeMount{{Name: "webserver-logs", MountPath: "/logs"}},
+			}},
+		},
+	}
+
+	if ignored := ignoredVolumes(webServer); !reflect.DeepEqual(ignored, []string{"app-volume", "webserver-logs"}) {
+		t.Errorf("got %v, expected app-volume and webserver-logs", ignored)
+	}
+	template := podTemplateSpecForWebServer(webServer, "quay.io/example/tomcat:1.0", false)
+	volumes := map[string]bool{}
+	for _, volume := range template.Spec.Volumes {
+		volumes[volume.Name] = true
+	}
+	if !volumes["libs"] || volumes["app-volume"] {
+		t.Errorf("got %v, expected the volume libs without app-volume", template.Spec.Volumes)
+	}
+	containers := append(append([]corev1.Container{}, template.Spec.InitContainers...), template.Spec.Containers...)
+	for _, container := range containers {
+		for _, mount := range container.VolumeMounts {
+			if !volumes[mount.Name] {
+				t.Errorf("the container %s mounts the missing volume %s", container.Name, mount.Name)
+			}
+		}
+	}
+	if mounts := template.Spec.InitContainers[0].VolumeMounts; len(mounts) != 1 || mounts[0].Name != "libs" {
+		t.Errorf("got %v, expected the mount of libs", mounts)
+	}
+	if len(webServer.Spec.Sidecars[0].VolumeMounts) != 1 {
+		t.Error("the mounts of the sidecar of the WebServer were modified")
+	}
+}
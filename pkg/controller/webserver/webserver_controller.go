@@ -2,7 +2,9 @@ package webserver
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"reflect"
 	"sort"
 	"strconv"
@@ -19,15 +21,20 @@ import (
 	imagev1 "github.com/openshift/api/image/v1"
 	routev1 "github.com/openshift/api/route/v1"
 	kbappsv1 "k8s.io/api/apps/v1"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/discovery"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 
 	// rbac "rbac.authorization.k8s.io/v1"
 	rbac "k8s.io/api/rbac/v1"
@@ -59,7 +66,7 @@ func Add(mgr manager.Manager) error {
 
 // newReconciler returns a new reconcile.Reconciler
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileWebServer{client: mgr.GetClient(), scheme: mgr.GetScheme(), isOpenShift: isOpenShift(mgr.GetConfig()), useKUBEPing: true}
+	return &ReconcileWebServer{client: mgr.GetClient(), scheme: mgr.GetScheme(), recorder: mgr.GetEventRecorderFor("webserver-controller"), isOpenShift: isOpenShift(mgr.GetConfig()), hasCertManager: hasAPIGroup(mgr.GetConfig(), certificateGVK.Group), useKUBEPing: true, podProxy: corev1client.NewForConfigOrDie(mgr.GetConfig()).RESTClient()}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
@@ -81,17 +88,42 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		IsController: true,
 		OwnerType:    &webserversv1alpha1.WebServer{},
 	}
-	for _, obj := range []runtime.Object{&kbappsv1.Deployment{}, &corev1.Service{}} {
+	for _, obj := range []runtime.Object{&kbappsv1.Deployment{}, &corev1.Service{}, &corev1.ConfigMap{}, &corev1.Secret{}, &corev1.ServiceAccount{}, &rbac.Role{}, &rbac.RoleBinding{}, &autoscalingv2beta2.HorizontalPodAutoscaler{}} {
 		if err = c.Watch(&source.Kind{Type: obj}, &enqueueRequestForOwner); err != nil {
 			return err
 		}
 	}
 	if isOpenShift(mgr.GetConfig()) {
-		for _, obj := range []runtime.Object{&appsv1.DeploymentConfig{}, &routev1.Route{}} {
+		for _, obj := range []runtime.Object{&appsv1.DeploymentConfig{}, &routev1.Route{}, &buildv1.BuildConfig{}, &imagev1.ImageStream{}} {
 			if err = c.Watch(&source.Kind{Type: obj}, &enqueueRequestForOwner); err != nil {
 				return err
 			}
 		}
+	} else {
+		if err = c.Watch(&source.Kind{Type: &networkingv1beta1.Ingress{}}, &enqueueRequestForOwner); err != nil {
+			return err
+		}
+	}
+
+	if hasAPIGroup(mgr.GetConfig(), certificateGVK.Group) {
+		certificate := &unstructured.Unstructured{}
+		certificate.SetGroupVersionKind(certificateGVK)
+		if err = c.Watch(&source.Kind{Type: certificate}, &enqueueRequestForOwner); err != nil {
+			return err
+		}
+	}
+
+	// Watch for changes to the Secrets and ConfigMaps referenced by the WebServers
+	if err = indexReferences(mgr); err != nil {
+		return err
+	}
+	err = c.Watch(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestsFromMapFunc{ToRequests: referencingWebServers(mgr.GetClient(), "Secret", secretsIndexField)})
+	if err != nil {
+		return err
+	}
+	err = c.Watch(&source.Kind{Type: &corev1.ConfigMap{}}, &handler.EnqueueRequestsFromMapFunc{ToRequests: referencingWebServers(mgr.GetClient(), "ConfigMap", configMapsIndexField)})
+	if err != nil {
+		return err
 	}
 
 	return nil
@@ -103,10 +135,14 @@ var _ reconcile.Reconciler = &ReconcileWebServer{}
 type ReconcileWebServer struct {
 	// This client, initialized using mgr.Client() above, is a split client
 	// that reads objects from the cache and writes to the apiserver
-	client      client.Client
-	scheme      *runtime.Scheme
-	isOpenShift bool
-	useKUBEPing bool
+	client         client.Client
+	scheme         *runtime.Scheme
+	recorder       record.EventRecorder
+	isOpenShift    bool
+	hasCertManager bool
+	useKUBEPing    bool
+	// podProxy is the REST client of the core API, the sessions of the draining pods are read through its pod proxy
+	podProxy rest.Interface
 }
 
 // Reconcile reads that state of the cluster for a WebServer object and makes changes based on the state read
@@ -116,16 +152,16 @@ type ReconcileWebServer struct {
 // Note:
 // The Controller will requeue the Request to be processed again if the returned error is non-nil or
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
-func (r *ReconcileWebServer) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+func (r *ReconcileWebServer) Reconcile(request reconcile.Request) (result reconcile.Result, err error) {
 	reqLogger = log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
 	reqLogger.Info("Reconciling WebServer")
-	updateStatus := false
 	requeue := false
 	updateDeployment := false
+	draining := false
 
 	// Fetch the WebServer
 	webServer := &webserversv1alpha1.WebServer{}
-	err := r.client.Get(context.TODO(), request.NamespacedName, webServer)
+	err = r.client.Get(context.TODO(), request.NamespacedName, webServer)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// Request object not found, could have been deleted after reconcile request.
@@ -139,107 +175,505 @@ func (r *ReconcileWebServer) Reconcile(request reconcile.Request) (reconcile.Res
 		return reconcile.Result{}, err
 	}
 
-	webServer = r.addDefaultValues(webServer)
+	// Whatever branch ends the reconciliation, the conditions it set are persisted in the status
+	originalStatus := webServer.Status.DeepCopy()
+	defer func() {
+		if err != nil {
+			r.recorder.Event(webServer, corev1.EventTypeWarning, "ReconcileFailed", err.Error())
+		}
+		setReconcileResult(webServer, err)
+		if reflect.DeepEqual(originalStatus, &webServer.Status) {
+			return
+		}
+		if statusErr := UpdateWebServerStatus(webServer, r.client); statusErr != nil && err == nil {
+			err = statusErr
+		}
+	}()
+
+	// Persist the default values, the defaulting webhook may be disabled
+	if webserversv1alpha1.SetDefaults(webServer) {
+		reqLogger.Info("Some optional fields of the WebServer are not set, setting their default values")
+		err = r.client.Update(context.TODO(), webServer)
+		if err != nil {
+			reqLogger.Error(err, "Failed to set the default values of the WebServer.")
+			return reconcile.Result{}, err
+		}
+		r.recorder.Event(webServer, corev1.EventTypeNormal, "Defaulted", "Set the default values of the optional fields")
+		return reconcile.Result{Requeue: true}, nil
+	}
+
+	// The requests of the canary releases are split by the Route, the WebServer isn't deployed without one
+	if isCanary(webServer) && !r.isOpenShift {
+		reqLogger.Info("Canary releases are only supported on OpenShift")
+		setDegraded(webServer, "CanaryNotSupported", canaryNotSupportedMessage)
+		r.recorder.Event(webServer, corev1.EventTypeWarning, "CanaryNotSupported", canaryNotSupportedMessage)
+		return reconcile.Result{}, nil
+	}
+
+	// The Service selects the pods with the serving label, label the pods deployed before it was introduced
+	err = r.labelServingPods(webServer)
+	if err != nil {
+		reqLogger.Error(err, "Failed to label the pods.")
+		return reconcile.Result{}, err
+	}
+
+	// The Deployments of the previous update strategy serve until the Deployment replacing them is rolled out,
+	// the Service selects the pods of all the colors meanwhile
+	var previousDeployments []*kbappsv1.Deployment
+	previousDeployments, err = r.previousDeployments(webServer)
+	if err != nil {
+		reqLogger.Error(err, "Failed to get the Deployments of the previous update strategy.")
+		return reconcile.Result{}, err
+	}
 
 	ser := r.serviceForWebServer(webServer)
+	if len(previousDeployments) > 0 {
+		delete(ser.Spec.Selector, colorLabel)
+	}
 	// Check if the Service for the Route exists
-	err = r.client.Get(context.TODO(), types.NamespacedName{Name: ser.Name, Namespace: ser.Namespace}, &corev1.Service{})
+	foundService := &corev1.Service{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: ser.Name, Namespace: ser.Namespace}, foundService)
 	if err != nil && errors.IsNotFound(err) {
 		// Define a new Service
 		reqLogger.Info("Creating a new Service for the Route.", "Service.Namespace", ser.Namespace, "Service.Name", ser.Name)
+		setProgressing(webServer, "CreatingService", "Creating Service "+ser.Name)
 		err = r.client.Create(context.TODO(), ser)
 		if err != nil && !errors.IsAlreadyExists(err) {
 			reqLogger.Error(err, "Failed to create a new Service.", "Service.Namespace", ser.Namespace, "Service.Name", ser.Name)
 			return reconcile.Result{}, err
 		}
+		r.recorder.Eventf(webServer, corev1.EventTypeNormal, "Created", "Created Service %s", ser.Name)
 		// Service created successfully - return and requeue
 		return reconcile.Result{Requeue: true}, nil
 	} else if err != nil {
 		reqLogger.Error(err, "Failed to get Service.")
 		return reconcile.Result{}, err
 	}
+	if syncService(ser, foundService) {
+		return r.updateOwnedObject(webServer, "Service", foundService)
+	}
+
+	if isCanaryInProgress(webServer) {
+		// Check if the Service of the canary pods exists, the Route sends it a share of the requests
+		canaryService := r.canaryServiceForWebServer(webServer)
+		foundCanaryService := &corev1.Service{}
+		err = r.client.Get(context.TODO(), types.NamespacedName{Name: canaryService.Name, Namespace: canaryService.Namespace}, foundCanaryService)
+		if err != nil && errors.IsNotFound(err) {
+			reqLogger.Info("Creating a new Service for the canary.", "Service.Namespace", canaryService.Namespace, "Service.Name", canaryService.Name)
+			setProgressing(webServer, "CreatingService", "Creating Service "+canaryService.Name)
+			err = r.client.Create(context.TODO(), canaryService)
+			if err != nil && !errors.IsAlreadyExists(err) {
+				reqLogger.Error(err, "Failed to create a new Service.", "Service.Namespace", canaryService.Namespace, "Service.Name", canaryService.Name)
+				return reconcile.Result{}, err
+			}
+			r.recorder.Eventf(webServer, corev1.EventTypeNormal, "Created", "Created Service %s", canaryService.Name)
+			return reconcile.Result{Requeue: true}, nil
+		} else if err != nil {
+			reqLogger.Error(err, "Failed to get Service.")
+			return reconcile.Result{}, err
+		}
+		if syncService(canaryService, foundCanaryService) {
+			return r.updateOwnedObject(webServer, "Service", foundCanaryService)
+		}
+	} else {
+		// Delete the Service of the canary pods once the release is over
+		foundCanaryService := &corev1.Service{}
+		err = r.client.Get(context.TODO(), types.NamespacedName{Name: canaryServiceName(webServer), Namespace: webServer.Namespace}, foundCanaryService)
+		if err == nil && metav1.IsControlledBy(foundCanaryService, webServer) {
+			return r.deleteOwnedObject(webServer, "Service", foundCanaryService)
+		} else if err != nil && !errors.IsNotFound(err) {
+			reqLogger.Error(err, "Failed to get Service.")
+			return reconcile.Result{}, err
+		}
+	}
+
+	useKUBEPing := r.useKUBEPingFor(webServer)
+	if webserversv1alpha1.IsSessionClusteringEnabled(webServer) {
+		// Create a ServiceAccount for the pods, allowed to list the pods by a Role, for the KUBEPing
+		if useKUBEPing {
+			serviceAccount := r.serviceAccountForWebServer(webServer)
+			foundServiceAccount := &corev1.ServiceAccount{}
+			err = r.client.Get(context.TODO(), types.NamespacedName{Name: serviceAccount.Name, Namespace: serviceAccount.Namespace}, foundServiceAccount)
+			if err != nil && errors.IsNotFound(err) {
+				// Define a new ServiceAccount
+				reqLogger.Info("Creating a new ServiceAccount.", "ServiceAccount.Namespace", serviceAccount.Namespace, "ServiceAccount.Name", serviceAccount.Name)
+				setProgressing(webServer, "CreatingServiceAccount", "Creating ServiceAccount "+serviceAccount.Name)
+				err = r.client.Create(context.TODO(), serviceAccount)
+				if err != nil && !errors.IsAlreadyExists(err) {
+					reqLogger.Error(err, "Failed to create a new ServiceAccount.", "ServiceAccount.Namespace", serviceAccount.Namespace, "ServiceAccount.Name", serviceAccount.Name)
+					return r.kubePingUnavailable(webServer, "Failed to create ServiceAccount "+serviceAccount.Name, err)
+				}
+				r.recorder.Eventf(webServer, corev1.EventTypeNormal, "Created", "Created ServiceAccount %s", serviceAccount.Name)
+				// ServiceAccount created successfully - return and requeue
+				return reconcile.Result{Requeue: true}, nil
+			} else if err != nil {
+				reqLogger.Error(err, "Failed to get ServiceAccount.")
+				return r.kubePingUnavailable(webServer, "Failed to get ServiceAccount "+serviceAccount.Name, err)
+			}
+			if syncLabels(serviceAccount, foundServiceAccount) {
+				return r.updateOwnedObject(webServer, "ServiceAccount", foundServiceAccount)
+			}
+
+			role := r.roleForWebServer(webServer)
+			foundRole := &rbac.Role{}
+			err = r.client.Get(context.TODO(), types.NamespacedName{Name: role.Name, Namespace: role.Namespace}, foundRole)
+			if err != nil && errors.IsNotFound(err) {
+				// Define a new Role
+				reqLogger.Info("Creating a new Role.", "Role.Namespace", role.Namespace, "Role.Name", role.Name)
+				setProgressing(webServer, "CreatingRole", "Creating Role "+role.Name)
+				err = r.client.Create(context.TODO(), role)
+				if err != nil && !errors.IsAlreadyExists(err) {
+					reqLogger.Error(err, "Failed to create a new Role.", "Role.Namespace", role.Namespace, "Role.Name", role.Name)
+					return r.kubePingUnavailable(webServer, "Failed to create Role "+role.Name, err)
+				}
+				r.recorder.Eventf(webServer, corev1.EventTypeNormal, "Created", "Created Role %s", role.Name)
+				// Role created successfully - return and requeue
+				return reconcile.Result{Requeue: true}, nil
+			} else if err != nil {
+				reqLogger.Error(err, "Failed to get Role.")
+				return r.kubePingUnavailable(webServer, "Failed to get Role "+role.Name, err)
+			}
+			if syncRole(role, foundRole) {
+				return r.updateOwnedObject(webServer, "Role", foundRole)
+			}
 
-	if webServer.Spec.UseSessionClustering {
-		// Create a RoleBinding for the KUBEPing
-		if r.useKUBEPing {
 			rolebinding := r.roleBindingForWebServer(webServer)
-			err = r.client.Get(context.TODO(), types.NamespacedName{Name: rolebinding.Name, Namespace: rolebinding.Namespace}, &rbac.RoleBinding{})
+			foundRoleBinding := &rbac.RoleBinding{}
+			err = r.client.Get(context.TODO(), types.NamespacedName{Name: rolebinding.Name, Namespace: rolebinding.Namespace}, foundRoleBinding)
 			if err != nil && errors.IsNotFound(err) {
 				// Define a new RoleBinding
 				reqLogger.Info("Creating a new RoleBinding.", "RoleBinding.Namespace", rolebinding.Namespace, "RoleBinding.Name", rolebinding.Name)
+				setProgressing(webServer, "CreatingRoleBinding", "Creating RoleBinding "+rolebinding.Name)
 				err = r.client.Create(context.TODO(), rolebinding)
 				if err != nil && !errors.IsAlreadyExists(err) {
 					reqLogger.Error(err, "Failed to create a new RoleBinding.", "RoleBinding.Namespace", rolebinding.Namespace, "RoleBinding.Name", rolebinding.Name)
-					// We ignore the error.
-					return reconcile.Result{Requeue: true}, nil
+					return r.kubePingUnavailable(webServer, "Failed to create RoleBinding "+rolebinding.Name, err)
 				}
-				// Service created successfully - return and requeue
+				r.recorder.Eventf(webServer, corev1.EventTypeNormal, "Created", "Created RoleBinding %s", rolebinding.Name)
+				// RoleBinding created successfully - return and requeue
 				return reconcile.Result{Requeue: true}, nil
 			} else if err != nil {
 				reqLogger.Error(err, "Failed to get RoleBinding.")
-				// We ignore the error.
-				r.useKUBEPing = false
-				return reconcile.Result{Requeue: true}, nil
+				return r.kubePingUnavailable(webServer, "Failed to get RoleBinding "+rolebinding.Name, err)
+			}
+			if !reflect.DeepEqual(rolebinding.RoleRef, foundRoleBinding.RoleRef) {
+				// The RoleRef of a RoleBinding can't be changed, the RoleBindings of the previous versions use the ClusterRole view
+				return r.deleteOwnedObject(webServer, "RoleBinding", foundRoleBinding)
+			}
+			if syncRoleBinding(rolebinding, foundRoleBinding) {
+				return r.updateOwnedObject(webServer, "RoleBinding", foundRoleBinding)
 			}
 		}
 
-		if !r.useKUBEPing {
+		if !useKUBEPing {
 			ser1 := r.serviceForWebServerDNS(webServer)
 			// Check if the Service for DNSPing exists
-			err = r.client.Get(context.TODO(), types.NamespacedName{Name: ser1.Name, Namespace: ser1.Namespace}, &corev1.Service{})
+			foundService := &corev1.Service{}
+			err = r.client.Get(context.TODO(), types.NamespacedName{Name: ser1.Name, Namespace: ser1.Namespace}, foundService)
 			if err != nil && errors.IsNotFound(err) {
 				// Define a new Service
 				reqLogger.Info("Creating a new Service for DNSPing.", "Service.Namespace", ser1.Namespace, "Service.Name", ser1.Name)
+				setProgressing(webServer, "CreatingService", "Creating Service "+ser1.Name)
 				err = r.client.Create(context.TODO(), ser1)
 				if err != nil && !errors.IsAlreadyExists(err) {
 					reqLogger.Error(err, "Failed to create a new Service.", "Service.Namespace", ser1.Namespace, "Service.Name", ser1.Name)
 					return reconcile.Result{}, err
 				}
+				r.recorder.Eventf(webServer, corev1.EventTypeNormal, "Created", "Created Service %s", ser1.Name)
 				// Service created successfully - return and requeue
 				return reconcile.Result{Requeue: true}, nil
 			} else if err != nil {
 				reqLogger.Error(err, "Failed to get Service.")
 				return reconcile.Result{}, err
 			}
+			if syncService(ser1, foundService) {
+				return r.updateOwnedObject(webServer, "Service", foundService)
+			}
+		}
+	}
+
+	if (!webserversv1alpha1.IsSessionClusteringEnabled(webServer) || !useKUBEPing) && r.useKUBEPing {
+		// Delete the resources of the KUBEPing when the pods no longer use it
+		for _, found := range []struct {
+			kind string
+			obj  ownedObject
+		}{
+			{"RoleBinding", &rbac.RoleBinding{}},
+			{"Role", &rbac.Role{}},
+			{"ServiceAccount", &corev1.ServiceAccount{}},
+		} {
+			err = r.client.Get(context.TODO(), types.NamespacedName{Name: kubePingName(webServer), Namespace: webServer.Namespace}, found.obj)
+			if err == nil && metav1.IsControlledBy(found.obj, webServer) {
+				return r.deleteOwnedObject(webServer, found.kind, found.obj)
+			} else if err != nil && !errors.IsNotFound(err) {
+				reqLogger.Error(err, "Failed to get "+found.kind+".")
+				return reconcile.Result{}, err
+			}
+		}
+	}
+
+	// Check if the ConfigMap containing server.xml already exists, if not create a new one
+	var cmap *corev1.ConfigMap
+	cmap, err = r.cmapForWebServer(webServer, useKUBEPing)
+	if err != nil {
+		// The pods are not rolled out with a server.xml Tomcat can't start with
+		reqLogger.Error(err, "Failed to generate server.xml.")
+		setDegraded(webServer, "ServerXmlFailed", "Failed to generate server.xml: "+err.Error())
+		return reconcile.Result{}, err
+	}
+	foundConfigMap := &corev1.ConfigMap{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: cmap.Name, Namespace: cmap.Namespace}, foundConfigMap)
+	if err != nil && errors.IsNotFound(err) {
+		// Define a new ConfigMap
+		reqLogger.Info("Creating a new ConfigMap.", "ConfigMap.Namespace", cmap.Namespace, "ConfigMap.Name", cmap.Name)
+		setProgressing(webServer, "CreatingConfigMap", "Creating ConfigMap "+cmap.Name)
+		err = r.client.Create(context.TODO(), cmap)
+		if err != nil && !errors.IsAlreadyExists(err) {
+			reqLogger.Error(err, "Failed to create a new ConfigMap.", "ConfigMap.Namespace", cmap.Namespace, "ConfigMap.Name", cmap.Name)
+			return reconcile.Result{}, err
 		}
-		cmap := r.cmapForWebServerDNS(webServer, r.useKUBEPing)
-		// Check if the ConfigMap for DNSPing exists
-		err = r.client.Get(context.TODO(), types.NamespacedName{Name: cmap.Name, Namespace: cmap.Namespace}, &corev1.ConfigMap{})
+		r.recorder.Eventf(webServer, corev1.EventTypeNormal, "Created", "Created ConfigMap %s", cmap.Name)
+		// ConfigMap created successfully - return and requeue
+		return reconcile.Result{Requeue: true}, nil
+	} else if err != nil {
+		reqLogger.Error(err, "Failed to get ConfigMap.")
+		return reconcile.Result{}, err
+	}
+	if syncConfigMap(cmap, foundConfigMap) {
+		return r.updateOwnedObject(webServer, "ConfigMap", foundConfigMap)
+	}
+
+	// Check if the Secret containing the configuration of the session store already exists, if not create a new one
+	sessionStoreHash := ""
+	if webServer.Spec.SessionStore != nil {
+		var secret *corev1.Secret
+		secret, err = r.sessionStoreSecretForWebServer(webServer)
 		if err != nil && errors.IsNotFound(err) {
-			// Define a new ConfigMap
-			reqLogger.Info("Creating a new ConfigMap.", "ConfigMap.Namespace", cmap.Namespace, "ConfigMap.Name", cmap.Name)
-			err = r.client.Create(context.TODO(), cmap)
+			reqLogger.Info("The Secret containing the connection details of the session store doesn't exist, waiting for it.")
+			setDegraded(webServer, "SessionStoreSecretNotFound", err.Error())
+			r.recorder.Eventf(webServer, corev1.EventTypeWarning, "SessionStoreSecretNotFound", "Failed to get the connection details of the session store: %v", err)
+			// The Secret watch requeues the WebServer when the Secret is created
+			return reconcile.Result{}, nil
+		} else if err != nil {
+			// The pods are not rolled out with a context.xml Tomcat can't start with
+			reqLogger.Error(err, "Failed to get the configuration of the session store.")
+			setDegraded(webServer, "SessionStoreFailed", "Failed to get the configuration of the session store: "+err.Error())
+			return reconcile.Result{}, err
+		}
+		foundSecret := &corev1.Secret{}
+		err = r.client.Get(context.TODO(), types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace}, foundSecret)
+		if err != nil && errors.IsNotFound(err) {
+			// Define a new Secret
+			reqLogger.Info("Creating a new Secret.", "Secret.Namespace", secret.Namespace, "Secret.Name", secret.Name)
+			setProgressing(webServer, "CreatingSecret", "Creating Secret "+secret.Name)
+			err = r.client.Create(context.TODO(), secret)
 			if err != nil && !errors.IsAlreadyExists(err) {
-				reqLogger.Error(err, "Failed to create a new ConfigMap.", "ConfigMap.Namespace", cmap.Namespace, "ConfigMap.Name", cmap.Name)
+				reqLogger.Error(err, "Failed to create a new Secret.", "Secret.Namespace", secret.Namespace, "Secret.Name", secret.Name)
 				return reconcile.Result{}, err
 			}
-			// Service created successfully - return and requeue
+			r.recorder.Eventf(webServer, corev1.EventTypeNormal, "Created", "Created Secret %s", secret.Name)
+			// Secret created successfully - return and requeue
 			return reconcile.Result{Requeue: true}, nil
 		} else if err != nil {
-			reqLogger.Error(err, "Failed to get ConfigMap.")
+			reqLogger.Error(err, "Failed to get Secret.")
+			return reconcile.Result{}, err
+		}
+		if syncSecret(secret, foundSecret) {
+			return r.updateOwnedObject(webServer, "Secret", foundSecret)
+		}
+		sessionStoreHash = sessionStoreDataHash(secret.Data)
+	} else {
+		// Delete the Secret of the session store when the sessions are no longer stored
+		foundSecret := &corev1.Secret{}
+		err = r.client.Get(context.TODO(), types.NamespacedName{Name: sessionStoreSecretName(webServer), Namespace: webServer.Namespace}, foundSecret)
+		if err == nil && metav1.IsControlledBy(foundSecret, webServer) {
+			return r.deleteOwnedObject(webServer, "Secret", foundSecret)
+		} else if err != nil && !errors.IsNotFound(err) {
+			reqLogger.Error(err, "Failed to get Secret.")
 			return reconcile.Result{}, err
 		}
-
 	}
 
 	// Check if the Route already exists, if not create a new one
 	if r.isOpenShift {
-		err = r.client.Get(context.TODO(), types.NamespacedName{Name: webServer.Spec.ApplicationName, Namespace: webServer.Namespace}, &routev1.Route{})
+		rou := r.routeForWebServer(webServer)
+		err = r.setRouteCertificates(webServer, rou)
+		if err != nil && errors.IsNotFound(err) {
+			reqLogger.Info("The Secret containing the certificates of the Route doesn't exist, waiting for it.", "Route.Name", rou.Name)
+			setDegraded(webServer, "RouteCertificateNotFound", err.Error())
+			r.recorder.Eventf(webServer, corev1.EventTypeWarning, "RouteCertificateNotFound", "Failed to get the certificates of Route %s: %v", rou.Name, err)
+			// The Secret watch requeues the WebServer when the Secret is created
+			return reconcile.Result{}, nil
+		} else if err != nil {
+			reqLogger.Error(err, "Failed to get the certificates of the Route.")
+			return reconcile.Result{}, err
+		}
+		foundRoute := &routev1.Route{}
+		err = r.client.Get(context.TODO(), types.NamespacedName{Name: rou.Name, Namespace: rou.Namespace}, foundRoute)
 		if err != nil && errors.IsNotFound(err) {
 			// Define a new Route
-			rou := r.routeForWebServer(webServer)
 			reqLogger.Info("Creating a new Route.", "Route.Namespace", rou.Namespace, "Route.Name", rou.Name)
+			setProgressing(webServer, "CreatingRoute", "Creating Route "+rou.Name)
 			err = r.client.Create(context.TODO(), rou)
 			if err != nil && !errors.IsAlreadyExists(err) {
 				reqLogger.Error(err, "Failed to create a new Route.", "Route.Namespace", rou.Namespace, "Route.Name", rou.Name)
 				return reconcile.Result{}, err
 			}
+			r.recorder.Eventf(webServer, corev1.EventTypeNormal, "Created", "Created Route %s", rou.Name)
 			// Route created successfully - return and requeue
 			return reconcile.Result{Requeue: true}, nil
 		} else if err != nil {
 			reqLogger.Error(err, "Failed to get Route.")
 			return reconcile.Result{}, err
 		}
+		if syncRoute(rou, foundRoute) {
+			return r.updateOwnedObject(webServer, "Route", foundRoute)
+		}
+	}
+
+	// Check if the Ingress already exists, if not create a new one
+	if !r.isOpenShift {
+		if webServer.Spec.Ingress != nil {
+			ing := r.ingressForWebServer(webServer)
+			foundIngress := &networkingv1beta1.Ingress{}
+			err = r.client.Get(context.TODO(), types.NamespacedName{Name: ing.Name, Namespace: ing.Namespace}, foundIngress)
+			if err != nil && errors.IsNotFound(err) {
+				// Define a new Ingress
+				reqLogger.Info("Creating a new Ingress.", "Ingress.Namespace", ing.Namespace, "Ingress.Name", ing.Name)
+				setProgressing(webServer, "CreatingIngress", "Creating Ingress "+ing.Name)
+				err = r.client.Create(context.TODO(), ing)
+				if err != nil && !errors.IsAlreadyExists(err) {
+					reqLogger.Error(err, "Failed to create a new Ingress.", "Ingress.Namespace", ing.Namespace, "Ingress.Name", ing.Name)
+					return reconcile.Result{}, err
+				}
+				r.recorder.Eventf(webServer, corev1.EventTypeNormal, "Created", "Created Ingress %s", ing.Name)
+				// Ingress created successfully - return and requeue
+				return reconcile.Result{Requeue: true}, nil
+			} else if err != nil {
+				reqLogger.Error(err, "Failed to get Ingress.")
+				return reconcile.Result{}, err
+			}
+			if syncIngress(ing, foundIngress) {
+				return r.updateOwnedObject(webServer, "Ingress", foundIngress)
+			}
+		} else {
+			// Delete the Ingress when the application is no longer exposed
+			foundIngress := &networkingv1beta1.Ingress{}
+			err = r.client.Get(context.TODO(), types.NamespacedName{Name: webServer.Spec.ApplicationName, Namespace: webServer.Namespace}, foundIngress)
+			if err == nil && metav1.IsControlledBy(foundIngress, webServer) {
+				return r.deleteOwnedObject(webServer, "Ingress", foundIngress)
+			} else if err != nil && !errors.IsNotFound(err) {
+				reqLogger.Error(err, "Failed to get Ingress.")
+				return reconcile.Result{}, err
+			}
+		}
+	}
+
+	// Check if the HorizontalPodAutoscaler already exists, if not create a new one
+	if webServer.Spec.Autoscaling != nil {
+		hpa := r.horizontalPodAutoscalerForWebServer(webServer)
+		foundHpa := &autoscalingv2beta2.HorizontalPodAutoscaler{}
+		err = r.client.Get(context.TODO(), types.NamespacedName{Name: hpa.Name, Namespace: hpa.Namespace}, foundHpa)
+		if err != nil && errors.IsNotFound(err) {
+			// Define a new HorizontalPodAutoscaler
+			reqLogger.Info("Creating a new HorizontalPodAutoscaler.", "HorizontalPodAutoscaler.Namespace", hpa.Namespace, "HorizontalPodAutoscaler.Name", hpa.Name)
+			setProgressing(webServer, "CreatingHorizontalPodAutoscaler", "Creating HorizontalPodAutoscaler "+hpa.Name)
+			err = r.client.Create(context.TODO(), hpa)
+			if err != nil && !errors.IsAlreadyExists(err) {
+				reqLogger.Error(err, "Failed to create a new HorizontalPodAutoscaler.", "HorizontalPodAutoscaler.Namespace", hpa.Namespace, "HorizontalPodAutoscaler.Name", hpa.Name)
+				return reconcile.Result{}, err
+			}
+			r.recorder.Eventf(webServer, corev1.EventTypeNormal, "Created", "Created HorizontalPodAutoscaler %s", hpa.Name)
+			// HorizontalPodAutoscaler created successfully - return and requeue
+			return reconcile.Result{Requeue: true}, nil
+		} else if err != nil {
+			reqLogger.Error(err, "Failed to get HorizontalPodAutoscaler.")
+			return reconcile.Result{}, err
+		}
+		if syncHorizontalPodAutoscaler(hpa, foundHpa) {
+			return r.updateOwnedObject(webServer, "HorizontalPodAutoscaler", foundHpa)
+		}
+	} else {
+		// Delete the HorizontalPodAutoscaler when the autoscaling is disabled
+		foundHpa := &autoscalingv2beta2.HorizontalPodAutoscaler{}
+		err = r.client.Get(context.TODO(), types.NamespacedName{Name: webServer.Spec.ApplicationName, Namespace: webServer.Namespace}, foundHpa)
+		if err == nil && metav1.IsControlledBy(foundHpa, webServer) {
+			return r.deleteOwnedObject(webServer, "HorizontalPodAutoscaler", foundHpa)
+		} else if err != nil && !errors.IsNotFound(err) {
+			reqLogger.Error(err, "Failed to get HorizontalPodAutoscaler.")
+			return reconcile.Result{}, err
+		}
+	}
+
+	// Check if the cert-manager Certificate of the HTTPS connector already exists, if not create a new one
+	if webServer.Spec.TLS != nil && webServer.Spec.TLS.CertManager != nil {
+		if !r.hasCertManager {
+			reqLogger.Info("cert-manager is not installed, the certificate of the HTTPS connector can't be requested")
+			setDegraded(webServer, "CertManagerNotInstalled", "cert-manager is required to request the certificate of the HTTPS connector")
+			r.recorder.Event(webServer, corev1.EventTypeWarning, "CertManagerNotInstalled", "cert-manager is required to request the certificate of the HTTPS connector")
+			return reconcile.Result{}, nil
+		}
+		certificate := r.certificateForWebServer(webServer)
+		foundCertificate := &unstructured.Unstructured{}
+		foundCertificate.SetGroupVersionKind(certificateGVK)
+		err = r.client.Get(context.TODO(), types.NamespacedName{Name: certificate.GetName(), Namespace: certificate.GetNamespace()}, foundCertificate)
+		if err != nil && errors.IsNotFound(err) {
+			// Define a new Certificate
+			reqLogger.Info("Creating a new Certificate.", "Certificate.Namespace", certificate.GetNamespace(), "Certificate.Name", certificate.GetName())
+			setProgressing(webServer, "CreatingCertificate", "Creating Certificate "+certificate.GetName())
+			err = r.client.Create(context.TODO(), certificate)
+			if err != nil && !errors.IsAlreadyExists(err) {
+				reqLogger.Error(err, "Failed to create a new Certificate.", "Certificate.Namespace", certificate.GetNamespace(), "Certificate.Name", certificate.GetName())
+				return reconcile.Result{}, err
+			}
+			r.recorder.Eventf(webServer, corev1.EventTypeNormal, "Created", "Created Certificate %s", certificate.GetName())
+			// Certificate created successfully - return and requeue
+			return reconcile.Result{Requeue: true}, nil
+		} else if err != nil {
+			reqLogger.Error(err, "Failed to get Certificate.")
+			return reconcile.Result{}, err
+		}
+		if syncCertificate(certificate, foundCertificate) {
+			return r.updateOwnedObject(webServer, "Certificate", foundCertificate)
+		}
+	} else if r.hasCertManager {
+		// Delete the Certificate when it is no longer requested
+		foundCertificate := &unstructured.Unstructured{}
+		foundCertificate.SetGroupVersionKind(certificateGVK)
+		err = r.client.Get(context.TODO(), types.NamespacedName{Name: webServer.Spec.ApplicationName, Namespace: webServer.Namespace}, foundCertificate)
+		if err == nil && metav1.IsControlledBy(foundCertificate, webServer) {
+			return r.deleteOwnedObject(webServer, "Certificate", foundCertificate)
+		} else if err != nil && !errors.IsNotFound(err) {
+			reqLogger.Error(err, "Failed to get Certificate.")
+			return reconcile.Result{}, err
+		}
+	}
+
+	// The pods are rolled out when the certificate of the HTTPS connector changes
+	tlsCertificateHash := ""
+	if webServer.Spec.TLS != nil {
+		tlsCertificateHash, err = r.tlsCertificateHash(webServer)
+		if err != nil && errors.IsNotFound(err) {
+			if webServer.Spec.TLS.CertManager != nil {
+				reqLogger.Info("Waiting for cert-manager to issue the certificate of the HTTPS connector.")
+				setProgressing(webServer, "WaitingForCertificate", "Waiting for cert-manager to issue the certificate in Secret "+webServer.Spec.TLS.CertificateSecretName)
+			} else {
+				reqLogger.Info("The Secret containing the certificate of the HTTPS connector doesn't exist, waiting for it.")
+				setDegraded(webServer, "TLSCertificateNotFound", err.Error())
+				r.recorder.Eventf(webServer, corev1.EventTypeWarning, "TLSCertificateNotFound", "Failed to get the certificate of the HTTPS connector: %v", err)
+			}
+			// The Secret watch requeues the WebServer when the Secret is created
+			return reconcile.Result{}, nil
+		} else if err != nil {
+			reqLogger.Error(err, "Failed to get the certificate of the HTTPS connector.")
+			return reconcile.Result{}, err
+		}
+	}
+
+	configHash, err := r.configHash(webServer)
+	if err != nil {
+		reqLogger.Error(err, "Failed to get the ConfigMaps and Secrets read by the pods.")
+		return reconcile.Result{}, err
 	}
 
 	foundReplicas := int32(-1) // we need the foundDeployment.Spec.Replicas which is &appsv1.DeploymentConfig{} or &kbappsv1.Deployment{}
@@ -253,6 +687,8 @@ func (r *ReconcileWebServer) Reconcile(request reconcile.Request) (reconcile.Res
 
 		if webServer.Spec.WebImageStream == nil {
 			reqLogger.Info("WebImageStream or WebImage required")
+			setDegraded(webServer, "MissingImage", "WebImageStream or WebImage required")
+			r.recorder.Event(webServer, corev1.EventTypeWarning, "MissingImage", "WebImageStream or WebImage required")
 			return reconcile.Result{}, nil
 		}
 
@@ -261,47 +697,57 @@ func (r *ReconcileWebServer) Reconcile(request reconcile.Request) (reconcile.Res
 
 		if webServer.Spec.WebImageStream.WebSources != nil {
 			// Check if the ImageStream already exists, if not create a new one
-			img := &imagev1.ImageStream{}
-			err = r.client.Get(context.TODO(), types.NamespacedName{Name: webServer.Spec.ApplicationName, Namespace: webServer.Namespace}, img)
+			img := r.imageStreamForWebServer(webServer)
+			foundImageStream := &imagev1.ImageStream{}
+			err = r.client.Get(context.TODO(), types.NamespacedName{Name: img.Name, Namespace: img.Namespace}, foundImageStream)
 			if err != nil && errors.IsNotFound(err) {
 				// Define a new ImageStream
-				img = r.imageStreamForWebServer(webServer)
 				reqLogger.Info("Creating a new ImageStream.", "ImageStream.Namespace", img.Namespace, "ImageStream.Name", img.Name)
+				setProgressing(webServer, "CreatingImageStream", "Creating ImageStream "+img.Name)
 				err = r.client.Create(context.TODO(), img)
 				if err != nil && !errors.IsAlreadyExists(err) {
 					reqLogger.Error(err, "Failed to create a new ImageStream.", "ImageStream.Namespace", img.Namespace, "ImageStream.Name", img.Name)
 					return reconcile.Result{}, err
 				}
+				r.recorder.Eventf(webServer, corev1.EventTypeNormal, "Created", "Created ImageStream %s", img.Name)
 				// ImageStream created successfully - return and requeue
 				return reconcile.Result{Requeue: true}, nil
 			} else if err != nil {
 				reqLogger.Error(err, "Failed to get ImageStream.")
 				return reconcile.Result{}, err
 			}
+			if syncImageStream(img, foundImageStream) {
+				return r.updateOwnedObject(webServer, "ImageStream", foundImageStream)
+			}
 			myImageName = img.Name
 			myImageNameSpace = img.Namespace
 
-			buildConfig := &buildv1.BuildConfig{}
+			buildConfig := r.buildConfigForWebServer(webServer)
+			foundBuildConfig := &buildv1.BuildConfig{}
 			// Check if the BuildConfig already exists, if not create a new one
-			err = r.client.Get(context.TODO(), types.NamespacedName{Name: webServer.Spec.ApplicationName, Namespace: webServer.Namespace}, buildConfig)
+			err = r.client.Get(context.TODO(), types.NamespacedName{Name: buildConfig.Name, Namespace: buildConfig.Namespace}, foundBuildConfig)
 			if err != nil && errors.IsNotFound(err) {
 				// Define a new BuildConfig
-				buildConfig = r.buildConfigForWebServer(webServer)
 				reqLogger.Info("Creating a new BuildConfig.", "BuildConfig.Namespace", buildConfig.Namespace, "BuildConfig.Name", buildConfig.Name)
+				setProgressing(webServer, "CreatingBuildConfig", "Creating BuildConfig "+buildConfig.Name)
 				err = r.client.Create(context.TODO(), buildConfig)
 				if err != nil && !errors.IsAlreadyExists(err) {
 					reqLogger.Error(err, "Failed to create a new BuildConfig.", "BuildConfig.Namespace", buildConfig.Namespace, "BuildConfig.Name", buildConfig.Name)
 					return reconcile.Result{}, err
 				}
+				r.recorder.Eventf(webServer, corev1.EventTypeNormal, "Created", "Created BuildConfig %s", buildConfig.Name)
 				// BuildConfig created successfully - return and requeue
 				return reconcile.Result{Requeue: true}, nil
 			} else if err != nil {
 				reqLogger.Error(err, "Failed to get BuildConfig.")
 				return reconcile.Result{}, err
 			}
+			if syncBuildConfig(buildConfig, foundBuildConfig) {
+				return r.updateOwnedObject(webServer, "BuildConfig", foundBuildConfig)
+			}
 
 			build := &buildv1.Build{}
-			err = r.client.Get(context.TODO(), types.NamespacedName{Name: webServer.Spec.ApplicationName + "-" + strconv.FormatInt(buildConfig.Status.LastVersion, 10), Namespace: webServer.Namespace}, build)
+			err = r.client.Get(context.TODO(), types.NamespacedName{Name: webServer.Spec.ApplicationName + "-" + strconv.FormatInt(foundBuildConfig.Status.LastVersion, 10), Namespace: webServer.Namespace}, build)
 			if err != nil && !errors.IsNotFound(err) {
 				reqLogger.Error(err, "Failed to get Build")
 			}
@@ -309,50 +755,113 @@ func (r *ReconcileWebServer) Reconcile(request reconcile.Request) (reconcile.Res
 			switch build.Status.Phase {
 			case buildv1.BuildPhaseFailed:
 				reqLogger.Info("Application build failed: " + build.Status.Message)
+				r.setBuildCondition(webServer, corev1.ConditionFalse, "BuildFailed", build.Status.Message)
+				setDegraded(webServer, "BuildFailed", "Application build failed: "+build.Status.Message)
 				return reconcile.Result{}, nil
 			case buildv1.BuildPhaseError:
 				reqLogger.Info("Application build failed: " + build.Status.Message)
+				r.setBuildCondition(webServer, corev1.ConditionFalse, "BuildError", build.Status.Message)
+				setDegraded(webServer, "BuildError", "Application build failed: "+build.Status.Message)
 				return reconcile.Result{}, nil
 			case buildv1.BuildPhaseCancelled:
 				reqLogger.Info("Application build canceled")
+				r.setBuildCondition(webServer, corev1.ConditionFalse, "BuildCancelled", "Application build canceled")
+				setDegraded(webServer, "BuildCancelled", "Application build canceled")
 				return reconcile.Result{}, nil
+			case buildv1.BuildPhaseComplete:
+				r.setBuildCondition(webServer, corev1.ConditionTrue, "BuildComplete", "Application build "+build.Name+" complete")
+			case "":
+			default:
+				r.setBuildCondition(webServer, corev1.ConditionUnknown, "Build"+string(build.Status.Phase), "Application build "+build.Name+" is "+strings.ToLower(string(build.Status.Phase)))
 			}
 		}
 
 		// Check if the DeploymentConfig already exists, if not create a new one
+		dep := r.deploymentConfigForWebServer(webServer, myImageName, myImageNameSpace, useKUBEPing)
+		if tlsCertificateHash != "" {
+			setPodTemplateAnnotation(dep.Spec.Template, tlsCertificateHashAnnotation, tlsCertificateHash)
+		}
+		if sessionStoreHash != "" {
+			setPodTemplateAnnotation(dep.Spec.Template, sessionStoreHashAnnotation, sessionStoreHash)
+		}
+		if configHash != "" {
+			setPodTemplateAnnotation(dep.Spec.Template, configHashAnnotation, configHash)
+		}
 		foundDeployment := &appsv1.DeploymentConfig{}
-		err = r.client.Get(context.TODO(), types.NamespacedName{Name: webServer.Spec.ApplicationName, Namespace: webServer.Namespace}, foundDeployment)
+		err = r.client.Get(context.TODO(), types.NamespacedName{Name: dep.Name, Namespace: dep.Namespace}, foundDeployment)
 		if err != nil && errors.IsNotFound(err) {
 			// Define a new DeploymentConfig
-			dep := r.deploymentConfigForWebServer(webServer, myImageName, myImageNameSpace, r.useKUBEPing)
 			reqLogger.Info("Creating a new DeploymentConfig.", "DeploymentConfig.Namespace", dep.Namespace, "DeploymentConfig.Name", dep.Name)
+			setProgressing(webServer, "CreatingDeploymentConfig", "Creating DeploymentConfig "+dep.Name)
 			err = r.client.Create(context.TODO(), dep)
 			if err != nil && !errors.IsAlreadyExists(err) {
 				reqLogger.Error(err, "Failed to create a new DeploymentConfig.", "DeploymentConfig.Namespace", dep.Namespace, "DeploymentConfig.Name", dep.Name)
 				return reconcile.Result{}, err
 			}
+			r.recorder.Eventf(webServer, corev1.EventTypeNormal, "Created", "Created DeploymentConfig %s", dep.Name)
 			// DeploymentConfig created successfully - return and requeue
 			return reconcile.Result{Requeue: true}, nil
 		} else if err != nil {
 			reqLogger.Error(err, "Failed to get DeploymentConfig.")
 			return reconcile.Result{}, err
 		}
+		if syncDeploymentConfig(dep, foundDeployment) {
+			return r.updateOwnedObject(webServer, "DeploymentConfig", foundDeployment)
+		}
 
 		if int(foundDeployment.Status.LatestVersion) == 0 {
 			reqLogger.Info("The DeploymentConfig has not finished deploying the pods yet")
 		}
 
+		// The image is resolved from the ImageStream by the image change trigger
+		dep.Spec.Template.Spec.Containers[0].Image = foundDeployment.Spec.Template.Spec.Containers[0].Image
+		// The changes made to the pod template outside of the WebServer are reverted
+		templateDrifted := podTemplateHash(*foundDeployment.Spec.Template) == podTemplateHash(*dep.Spec.Template) && podTemplateDrifted(*dep.Spec.Template, *foundDeployment.Spec.Template)
+		if podTemplateHash(*foundDeployment.Spec.Template) != podTemplateHash(*dep.Spec.Template) || templateDrifted {
+			reqLogger.Info("WebServer pod template change detected. DeploymentConfig update scheduled")
+			setProgressing(webServer, "UpdatingPodTemplate", "Rolling out a new pod template for DeploymentConfig "+foundDeployment.Name)
+			change := podTemplateChange(*foundDeployment.Spec.Template, *dep.Spec.Template)
+			if templateDrifted {
+				change = podTemplateDriftChange
+			}
+			foundDeployment.Spec.Template = dep.Spec.Template
+			err = r.client.Update(context.TODO(), foundDeployment)
+			if err != nil {
+				reqLogger.Error(err, "Failed to update DeploymentConfig.", "DeploymentConfig.Namespace", foundDeployment.Namespace, "DeploymentConfig.Name", foundDeployment.Name)
+				return reconcile.Result{}, err
+			}
+			r.recorder.Eventf(webServer, corev1.EventTypeNormal, "Updated", "Updated DeploymentConfig %s: %s", foundDeployment.Name, change)
+			// Spec updated - return and requeue
+			return reconcile.Result{Requeue: true}, nil
+		}
+		if syncDeploymentConfigStrategy(dep, foundDeployment) {
+			return r.updateOwnedObject(webServer, "DeploymentConfig", foundDeployment)
+		}
+
 		// Handle Scaling
 		foundReplicas = foundDeployment.Spec.Replicas
 		replicas := webServer.Spec.Replicas
+		var drained bool
+		drained, err = r.drainPods(webServer, foundReplicas-replicas)
+		if err != nil {
+			reqLogger.Error(err, "Failed to drain the pods.")
+			return reconcile.Result{}, err
+		}
+		if !drained {
+			// The replicas are lowered once the sessions of the draining pods have expired
+			replicas = foundReplicas
+			draining = true
+		}
 		if foundReplicas != replicas {
 			reqLogger.Info("DeploymentConfig replicas number does not match the WebServer specification")
+			setProgressing(webServer, "Scaling", fmt.Sprintf("Scaling DeploymentConfig %s from %d to %d replicas", foundDeployment.Name, foundReplicas, replicas))
 			foundDeployment.Spec.Replicas = replicas
 			err = r.client.Update(context.TODO(), foundDeployment)
 			if err != nil {
 				reqLogger.Error(err, "Failed to update DeploymentConfig.", "DeploymentConfig.Namespace", foundDeployment.Namespace, "DeploymentConfig.Name", foundDeployment.Name)
 				return reconcile.Result{}, err
 			}
+			r.recorder.Eventf(webServer, corev1.EventTypeNormal, "Scaled", "Scaled DeploymentConfig %s from %d to %d replicas", foundDeployment.Name, foundReplicas, replicas)
 			// Spec updated - return and requeue
 			return reconcile.Result{Requeue: true}, nil
 		}
@@ -361,16 +870,25 @@ func (r *ReconcileWebServer) Reconcile(request reconcile.Request) (reconcile.Res
 		// Check if a webapp needs to be built
 		if webServer.Spec.WebImage.WebApp != nil && webServer.Spec.WebImage.WebApp.SourceRepositoryURL != "" && webServer.Spec.WebImage.WebApp.Builder != nil && webServer.Spec.WebImage.WebApp.Builder.Image != "" {
 
+			if _, err := resource.ParseQuantity(webServer.Spec.WebImage.WebApp.ApplicationSizeLimit); err != nil {
+				reqLogger.Info("Invalid WebApp.ApplicationSizeLimit: " + err.Error())
+				setDegraded(webServer, "InvalidApplicationSizeLimit", "Invalid WebApp.ApplicationSizeLimit: "+err.Error())
+				r.recorder.Event(webServer, corev1.EventTypeWarning, "InvalidApplicationSizeLimit", "Invalid WebApp.ApplicationSizeLimit: "+err.Error())
+				return reconcile.Result{}, nil
+			}
+
 			// Check if a Persistent Volume Claim already exists, if not create a new one
 			pvc := r.persistentVolumeClaimForWebServer(webServer)
 			err = r.client.Get(context.TODO(), types.NamespacedName{Name: pvc.Name, Namespace: pvc.Namespace}, pvc)
 			if err != nil && errors.IsNotFound(err) {
 				reqLogger.Info("Creating a new PersistentVolumeClaim.", "PersistentVolumeClaim.Namespace", pvc.Namespace, "PersistentVolumeClaim.Name", pvc.Name)
+				setProgressing(webServer, "CreatingPersistentVolumeClaim", "Creating PersistentVolumeClaim "+pvc.Name)
 				err = r.client.Create(context.TODO(), pvc)
 				if err != nil && !errors.IsAlreadyExists(err) {
 					reqLogger.Error(err, "Failed to create a new PersistentVolumeClaim.", "PersistentVolumeClaim.Namespace", pvc.Namespace, "PersistentVolumeClaim.Name", pvc.Name)
 					return reconcile.Result{}, err
 				}
+				r.recorder.Eventf(webServer, corev1.EventTypeNormal, "Created", "Created PersistentVolumeClaim %s", pvc.Name)
 				// Persistent Volume Claim created successfully - return and requeue
 				return reconcile.Result{Requeue: true}, nil
 			} else if err != nil {
@@ -380,49 +898,75 @@ func (r *ReconcileWebServer) Reconcile(request reconcile.Request) (reconcile.Res
 
 			// Check if the build pod already exists, if not create a new one
 			buildPod := r.buildPodForWebServer(webServer)
-			err = r.client.Get(context.TODO(), types.NamespacedName{Name: buildPod.Name, Namespace: buildPod.Namespace}, buildPod)
+			foundBuildPod := &corev1.Pod{}
+			err = r.client.Get(context.TODO(), types.NamespacedName{Name: buildPod.Name, Namespace: buildPod.Namespace}, foundBuildPod)
 			if err != nil && errors.IsNotFound(err) {
 				reqLogger.Info("Creating a new Build Pod.", "BuildPod.Namespace", buildPod.Namespace, "BuildPod.Name", buildPod.Name)
+				setProgressing(webServer, "CreatingBuildPod", "Creating Build Pod "+buildPod.Name)
 				err = r.client.Create(context.TODO(), buildPod)
 				if err != nil && !errors.IsAlreadyExists(err) {
 					reqLogger.Error(err, "Failed to create a new Build Pod.", "BuildPod.Namespace", buildPod.Namespace, "BuildPod.Name", buildPod.Name)
 					return reconcile.Result{}, err
 				}
+				r.recorder.Eventf(webServer, corev1.EventTypeNormal, "Created", "Created Pod %s", buildPod.Name)
 				// Build pod created successfully - return and requeue
 				return reconcile.Result{Requeue: true}, nil
 			} else if err != nil {
 				reqLogger.Error(err, "Failed to get the Build Pod.")
 				return reconcile.Result{}, err
 			}
+			if buildPodDrifted(buildPod, foundBuildPod) {
+				return r.deleteOwnedObject(webServer, "Pod", foundBuildPod)
+			}
+			buildPod = foundBuildPod
 
 			if buildPod.Status.Phase != corev1.PodSucceeded {
 				switch buildPod.Status.Phase {
 				case corev1.PodFailed:
 					reqLogger.Info("Application build failed: " + buildPod.Status.Message)
+					r.setBuildCondition(webServer, corev1.ConditionFalse, "BuildFailed", buildPod.Status.Message)
+					setDegraded(webServer, "BuildFailed", "Application build failed: "+buildPod.Status.Message)
 				case corev1.PodPending:
 					reqLogger.Info("Application build pending")
+					r.setBuildCondition(webServer, corev1.ConditionUnknown, "BuildPending", "Application build pending")
+					setProgressing(webServer, "BuildPending", "Application build pending")
 				case corev1.PodRunning:
 					reqLogger.Info("Application is still being built")
+					r.setBuildCondition(webServer, corev1.ConditionUnknown, "BuildRunning", "Application is still being built")
+					setProgressing(webServer, "BuildRunning", "Application is still being built")
 				default:
 					reqLogger.Info("Unknown build pod status")
+					r.setBuildCondition(webServer, corev1.ConditionUnknown, "BuildUnknown", "Unknown build pod status")
 				}
 				return reconcile.Result{RequeueAfter: (5 * time.Second)}, nil
 			}
+			r.setBuildCondition(webServer, corev1.ConditionTrue, "BuildComplete", "Application build pod "+buildPod.Name+" succeeded")
 
 		}
 
 		// Check if the Deployment already exists, if not create a new one
+		dep := r.deploymentForWebServer(webServer, useKUBEPing)
+		if tlsCertificateHash != "" {
+			setPodTemplateAnnotation(&dep.Spec.Template, tlsCertificateHashAnnotation, tlsCertificateHash)
+		}
+		if sessionStoreHash != "" {
+			setPodTemplateAnnotation(&dep.Spec.Template, sessionStoreHashAnnotation, sessionStoreHash)
+		}
+		if configHash != "" {
+			setPodTemplateAnnotation(&dep.Spec.Template, configHashAnnotation, configHash)
+		}
 		foundDeployment := &kbappsv1.Deployment{}
-		err = r.client.Get(context.TODO(), types.NamespacedName{Name: webServer.Spec.ApplicationName, Namespace: webServer.Namespace}, foundDeployment)
+		err = r.client.Get(context.TODO(), types.NamespacedName{Name: dep.Name, Namespace: dep.Namespace}, foundDeployment)
 		if err != nil && errors.IsNotFound(err) {
 			// Define a new Deployment
-			dep := r.deploymentForWebServer(webServer, r.useKUBEPing)
 			reqLogger.Info("Creating a new Deployment.", "Deployment.Namespace", dep.Namespace, "Deployment.Name", dep.Name)
+			setProgressing(webServer, "CreatingDeployment", "Creating Deployment "+dep.Name)
 			err = r.client.Create(context.TODO(), dep)
 			if err != nil && !errors.IsAlreadyExists(err) {
 				reqLogger.Error(err, "Failed to create a new Deployment.", "Deployment.Namespace", dep.Namespace, "Deployment.Name", dep.Name)
 				return reconcile.Result{}, err
 			}
+			r.recorder.Eventf(webServer, corev1.EventTypeNormal, "Created", "Created Deployment %s", dep.Name)
 			// Deployment created successfully - return and requeue
 			return reconcile.Result{Requeue: true}, nil
 		} else if err != nil {
@@ -430,19 +974,86 @@ func (r *ReconcileWebServer) Reconcile(request reconcile.Request) (reconcile.Res
 			return reconcile.Result{}, err
 		}
 
-		foundImage := foundDeployment.Spec.Template.Spec.Containers[0].Image
-		if foundImage != applicationImage {
-			reqLogger.Info("WebServer application image change detected. Deployment update scheduled")
-			foundDeployment.Spec.Template.Spec.Containers[0].Image = applicationImage
+		// The Deployments named after the previous update strategy are deleted once the Deployment is rolled out
+		var replaceResult *reconcile.Result
+		replaceResult, err = r.retirePreviousDeployments(webServer, foundDeployment, previousDeployments)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if replaceResult != nil {
+			return *replaceResult, nil
+		}
+
+		if !webserversv1alpha1.HasColors(webServer) {
+			// The Deployment is reverted to the last known good image when the pods of a new image fail
+			var rollbackResult *reconcile.Result
+			rollbackResult, err = r.reconcileRollback(webServer, foundDeployment)
+			if err != nil {
+				return reconcile.Result{}, err
+			}
+			if rollbackResult != nil {
+				return *rollbackResult, nil
+			}
+		}
+
+		if webserversv1alpha1.HasColors(webServer) {
+			// The pod template changes are deployed next to the active Deployment, which serves until the new pods are promoted
+			var blueGreenResult *reconcile.Result
+			blueGreenResult, err = r.reconcileBlueGreen(webServer, dep, foundDeployment)
+			if err != nil {
+				return reconcile.Result{}, err
+			}
+			if blueGreenResult != nil {
+				return *blueGreenResult, nil
+			}
+		}
+
+		updateMessages := []string{}
+		if !webserversv1alpha1.HasColors(webServer) && podTemplateHash(foundDeployment.Spec.Template) != podTemplateHash(dep.Spec.Template) {
+			reqLogger.Info("WebServer pod template change detected. Deployment update scheduled")
+			setProgressing(webServer, "UpdatingPodTemplate", "Rolling out a new pod template for Deployment "+foundDeployment.Name)
+			foundImage := foundDeployment.Spec.Template.Spec.Containers[0].Image
+			if image := deployedImage(webServer); foundImage != image {
+				updateMessages = append(updateMessages, "image "+foundImage+" to "+image)
+			} else {
+				updateMessages = append(updateMessages, podTemplateChange(foundDeployment.Spec.Template, dep.Spec.Template))
+			}
+			foundDeployment.Spec.Template = dep.Spec.Template
+			updateDeployment = true
+		}
+		if podTemplateHash(foundDeployment.Spec.Template) == podTemplateHash(dep.Spec.Template) && podTemplateDrifted(dep.Spec.Template, foundDeployment.Spec.Template) {
+			// The changes made to the pod template outside of the WebServer are reverted
+			reqLogger.Info("WebServer pod template drift detected. Deployment update scheduled")
+			setProgressing(webServer, "UpdatingPodTemplate", "Reverting the pod template of Deployment "+foundDeployment.Name)
+			updateMessages = append(updateMessages, podTemplateDriftChange)
+			foundDeployment.Spec.Template = dep.Spec.Template
+			updateDeployment = true
+		}
+		if syncDeploymentStrategy(dep, foundDeployment) {
+			reqLogger.Info("WebServer update strategy change detected. Deployment update scheduled")
+			updateMessages = append(updateMessages, "strategy "+string(foundDeployment.Spec.Strategy.Type))
 			updateDeployment = true
 		}
 
 		// Handle Scaling
 		foundReplicas = *foundDeployment.Spec.Replicas
 		replicas := webServer.Spec.Replicas
+		var drained bool
+		drained, err = r.drainPods(webServer, foundReplicas-replicas)
+		if err != nil {
+			reqLogger.Error(err, "Failed to drain the pods.")
+			return reconcile.Result{}, err
+		}
+		if !drained {
+			// The replicas are lowered once the sessions of the draining pods have expired
+			replicas = foundReplicas
+			draining = true
+		}
 		if foundReplicas != replicas {
 			reqLogger.Info("Deployment replicas number does not match the WebServer specification")
+			setProgressing(webServer, "Scaling", fmt.Sprintf("Scaling Deployment %s from %d to %d replicas", foundDeployment.Name, foundReplicas, replicas))
 			foundDeployment.Spec.Replicas = &replicas
+			updateMessages = append(updateMessages, fmt.Sprintf("replicas %d to %d", foundReplicas, replicas))
 			updateDeployment = true
 		}
 
@@ -452,6 +1063,7 @@ func (r *ReconcileWebServer) Reconcile(request reconcile.Request) (reconcile.Res
 				reqLogger.Error(err, "Failed to update Deployment.", "Deployment.Namespace", foundDeployment.Namespace, "Deployment.Name", foundDeployment.Name)
 				return reconcile.Result{}, err
 			}
+			r.recorder.Eventf(webServer, corev1.EventTypeNormal, "Updated", "Updated Deployment %s: %s", foundDeployment.Name, strings.Join(updateMessages, ", "))
 			// Spec updated - return and requeue
 			return reconcile.Result{Requeue: true}, nil
 		}
@@ -475,7 +1087,6 @@ func (r *ReconcileWebServer) Reconcile(request reconcile.Request) (reconcile.Res
 		// reqLogger.Info("Will update the WebServer pod status", "Existing pod status list", webServer.Status.Pods)
 		reqLogger.Info("Status.Pods update scheduled")
 		webServer.Status.Pods = podsStatus
-		updateStatus = true
 	}
 
 	if r.isOpenShift {
@@ -492,10 +1103,25 @@ func (r *ReconcileWebServer) Reconcile(request reconcile.Request) (reconcile.Res
 		}
 		sort.Strings(hosts)
 		if !reflect.DeepEqual(hosts, webServer.Status.Hosts) {
-			updateStatus = true
 			webServer.Status.Hosts = hosts
 			reqLogger.Info("Status.Hosts update scheduled")
 		}
+	} else if webServer.Spec.Ingress != nil {
+		ingress := &networkingv1beta1.Ingress{}
+		err = r.client.Get(context.TODO(), types.NamespacedName{Name: webServer.Spec.ApplicationName, Namespace: webServer.Namespace}, ingress)
+		if err != nil {
+			reqLogger.Error(err, "Failed to get Ingress.", "Ingress.Namespace", webServer.Namespace, "Ingress.Name", webServer.Spec.ApplicationName)
+			return reconcile.Result{}, err
+		}
+
+		hosts := ingressHosts(ingress)
+		if !reflect.DeepEqual(hosts, webServer.Status.Hosts) {
+			webServer.Status.Hosts = hosts
+			reqLogger.Info("Status.Hosts update scheduled")
+		}
+	} else if webServer.Status.Hosts != nil {
+		webServer.Status.Hosts = nil
+		reqLogger.Info("Status.Hosts update scheduled")
 	}
 
 	// Make sure the number of active pods is the desired replica size.
@@ -505,25 +1131,65 @@ func (r *ReconcileWebServer) Reconcile(request reconcile.Request) (reconcile.Res
 		requeue = true
 	}
 
+	// Update the conditions from the state of the pods
+	numberOfReadyPods := countReadyPods(podList.Items)
+	if numberOfReadyPods >= webServer.Spec.Replicas {
+		setCondition(webServer, webserversv1alpha1.WebServerAvailable, corev1.ConditionTrue, "ReplicasReady", fmt.Sprintf("%d of %d replicas are ready", numberOfReadyPods, webServer.Spec.Replicas))
+	} else {
+		setCondition(webServer, webserversv1alpha1.WebServerAvailable, corev1.ConditionFalse, "ReplicasNotReady", fmt.Sprintf("%d of %d replicas are ready", numberOfReadyPods, webServer.Spec.Replicas))
+	}
+	if draining {
+		setProgressing(webServer, "DrainingPods", fmt.Sprintf("Waiting for the sessions of %d pods to expire before scaling down", foundReplicas-webServer.Spec.Replicas))
+	} else if requeue {
+		setProgressing(webServer, "WaitingForPods", fmt.Sprintf("%d of %d pods are deployed", numberOfDeployedPods, webServer.Spec.Replicas))
+	} else {
+		setCondition(webServer, webserversv1alpha1.WebServerProgressing, corev1.ConditionFalse, "ReconciliationComplete", "All the resources of the application are up to date")
+	}
+	numberOfFailedPods := 0
+	for _, podStatus := range podsStatus {
+		if podStatus.State == webserversv1alpha1.PodStateFailed {
+			numberOfFailedPods++
+		}
+	}
+	if numberOfFailedPods > 0 {
+		setCondition(webServer, webserversv1alpha1.WebServerDegraded, corev1.ConditionTrue, "PodsFailed", fmt.Sprintf("%d pods have failed", numberOfFailedPods))
+	} else if ignored := ignoredEnvVars(webServer); len(ignored) > 0 {
+		// The validating webhook rejects the variables set by the operator, the WebServer may have been created without it
+		message := "The environment variables " + strings.Join(ignored, ", ") + " of spec.env are set by the operator, they are ignored"
+		if setCondition(webServer, webserversv1alpha1.WebServerDegraded, corev1.ConditionTrue, "ReservedEnvVars", message) {
+			r.recorder.Event(webServer, corev1.EventTypeWarning, "ReservedEnvVars", message)
+		}
+	} else if ignored := ignoredVolumes(webServer); len(ignored) > 0 {
+		// The validating webhook rejects the reserved volume names, the WebServer may have been created without it
+		message := "The volumes " + strings.Join(ignored, ", ") + " of spec.volumes use names reserved by the operator, they are ignored with their mounts"
+		if setCondition(webServer, webserversv1alpha1.WebServerDegraded, corev1.ConditionTrue, "ReservedVolumeNames", message) {
+			r.recorder.Event(webServer, corev1.EventTypeWarning, "ReservedVolumeNames", message)
+		}
+	} else {
+		setCondition(webServer, webserversv1alpha1.WebServerDegraded, corev1.ConditionFalse, "AsExpected", "")
+	}
+
+	// Update the selector of the scale subresource
+	selector := scaleSelectorForWebServer(webServer)
+	if webServer.Status.Selector != selector {
+		reqLogger.Info("Status.Selector update scheduled")
+		webServer.Status.Selector = selector
+	}
+
 	// Update the replicas
 	if webServer.Status.Replicas != foundReplicas {
 		reqLogger.Info("Status.Replicas update scheduled")
 		webServer.Status.Replicas = foundReplicas
-		updateStatus = true
 	}
 	// Update the scaledown
 	numberOfPodsToScaleDown := foundReplicas - webServer.Spec.Replicas
 	if webServer.Status.ScalingdownPods != numberOfPodsToScaleDown {
 		reqLogger.Info("Status.ScalingdownPods update scheduled")
 		webServer.Status.ScalingdownPods = numberOfPodsToScaleDown
-		updateStatus = true
 	}
-	// Update if needed.
-	if updateStatus {
-		err := UpdateWebServerStatus(webServer, r.client)
-		if err != nil {
-			return reconcile.Result{}, err
-		}
+	if draining {
+		reqLogger.Info("Waiting for the sessions of the draining pods to expire, reconciliation requeue scheduled")
+		return reconcile.Result{RequeueAfter: drainingRequeueDelay}, nil
 	}
 	if requeue {
 		reqLogger.Info("Requeuing reconciliation")
@@ -533,33 +1199,6 @@ func (r *ReconcileWebServer) Reconcile(request reconcile.Request) (reconcile.Res
 	return reconcile.Result{}, nil
 }
 
-func (r *ReconcileWebServer) addDefaultValues(t *webserversv1alpha1.WebServer) *webserversv1alpha1.WebServer {
-
-	if t.Spec.WebImage != nil && t.Spec.WebImage.WebApp != nil {
-		webApp := t.Spec.WebImage.WebApp
-		if webApp.Name == "" {
-			reqLogger.Info("WebServer.Spec.Image.WebApp.Name is not set, setting value to 'ROOT'")
-			webApp.Name = "ROOT"
-		}
-		if webApp.DeployPath == "" {
-			reqLogger.Info("WebServer.Spec.Image.WebApp.DeployPath is not set, setting value to '/deployments/'")
-			webApp.DeployPath = "/deployments/"
-		}
-		if webApp.ApplicationSizeLimit == "" {
-			reqLogger.Info("WebServer.Spec.Image.WebApp.ApplicationSizeLimit is not set, setting value to '1Gi'")
-			webApp.ApplicationSizeLimit = "1Gi"
-		}
-
-		if webApp.Builder.ApplicationBuildScript == "" {
-			reqLogger.Info("WebServer.Spec.Image.WebApp.Builder.ApplicationBuildScript is not set, generating default build script")
-			webApp.Builder.ApplicationBuildScript = generateWebAppBuildScript(t)
-		}
-	}
-
-	return t
-
-}
-
 func (r *ReconcileWebServer) serviceForWebServer(t *webserversv1alpha1.WebServer) *corev1.Service {
 
 	service := &corev1.Service{
@@ -577,9 +1216,20 @@ func (r *ReconcileWebServer) serviceForWebServer(t *webserversv1alpha1.WebServer
 			Selector: map[string]string{
 				"deploymentConfig": t.Spec.ApplicationName,
 				"WebServer":        t.Name,
+				servingLabel:       "true",
 			},
 		},
 	}
+	if webserversv1alpha1.HasColors(t) {
+		service.Spec.Selector[colorLabel] = activeColor(t)
+	}
+	if t.Spec.TLS != nil {
+		service.Spec.Ports = append(service.Spec.Ports, corev1.ServicePort{
+			Name:       "https",
+			Port:       8443,
+			TargetPort: intstr.FromInt(8443),
+		})
+	}
 
 	controllerutil.SetControllerReference(t, service, r.scheme)
 	return service
@@ -610,41 +1260,39 @@ func (r *ReconcileWebServer) serviceForWebServerDNS(t *webserversv1alpha1.WebSer
 	return service
 }
 
-func (r *ReconcileWebServer) roleBindingForWebServer(t *webserversv1alpha1.WebServer) *rbac.RoleBinding {
-	rolebinding := &rbac.RoleBinding{
-		TypeMeta: metav1.TypeMeta{
-			APIVersion: "rbac.authorization.k8s.io/v1beta",
-			Kind:       "RoleBinding",
-		},
-		ObjectMeta: objectMetaForWebServer(t, "webserver-"+t.Name),
-		RoleRef: rbac.RoleRef{
-			APIGroup: "rbac.authorization.k8s.io",
-			Kind:     "ClusterRole",
-			Name:     "view",
-		},
-		Subjects: []rbac.Subject{{
-			Kind: "ServiceAccount",
-			Name: "default",
-		}},
+// useKUBEPingFor returns true if the pods of the WebServer discover each other with KUBEPing, false for DNSPing.
+// Without an explicit membership provider KUBEPing is used unless the operator failed to create its RoleBinding.
+func (r *ReconcileWebServer) useKUBEPingFor(t *webserversv1alpha1.WebServer) bool {
+	if t.Spec.SessionClustering != nil {
+		switch t.Spec.SessionClustering.MembershipProvider {
+		case "KUBEPing":
+			return true
+		case "DNSPing":
+			return false
+		}
 	}
-
-	controllerutil.SetControllerReference(t, rolebinding, r.scheme)
-	return rolebinding
+	return r.useKUBEPing
 }
 
-func (r *ReconcileWebServer) cmapForWebServerDNS(t *webserversv1alpha1.WebServer, useKUBEPing bool) *corev1.ConfigMap {
-
+// cmapForWebServer returns the ConfigMap containing the server.xml generated for the WebServer
+func (r *ReconcileWebServer) cmapForWebServer(t *webserversv1alpha1.WebServer, useKUBEPing bool) (*corev1.ConfigMap, error) {
+	serverXml, err := serverXmlForWebServer(t, useKUBEPing)
+	if err != nil {
+		return nil, err
+	}
 	cmap := &corev1.ConfigMap{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "apps/v1",
 			Kind:       "ConfigMap",
 		},
 		ObjectMeta: objectMetaForWebServer(t, "webserver-"+t.Name),
-		Data:       commandForServerXml(useKUBEPing),
+		Data: map[string]string{
+			serverXmlKey: serverXml,
+		},
 	}
 
 	controllerutil.SetControllerReference(t, cmap, r.scheme)
-	return cmap
+	return cmap, nil
 }
 
 func (r *ReconcileWebServer) deploymentConfigForWebServer(t *webserversv1alpha1.WebServer, image string, namespace string, useKUBEPing bool) *appsv1.DeploymentConfig {
@@ -658,9 +1306,8 @@ func (r *ReconcileWebServer) deploymentConfigForWebServer(t *webserversv1alpha1.
 		},
 		ObjectMeta: objectMetaForWebServer(t, t.Spec.ApplicationName),
 		Spec: appsv1.DeploymentConfigSpec{
-			Strategy: appsv1.DeploymentStrategy{
-				Type: appsv1.DeploymentStrategyTypeRecreate,
-			},
+			Strategy:        deploymentConfigStrategyForWebServer(t),
+			MinReadySeconds: minReadySecondsForWebServer(t),
 			Triggers: []appsv1.DeploymentTriggerPolicy{{
 				Type: appsv1.DeploymentTriggerOnImageChange,
 				ImageChangeParams: &appsv1.DeploymentTriggerImageChangeParams{
@@ -677,10 +1324,7 @@ func (r *ReconcileWebServer) deploymentConfigForWebServer(t *webserversv1alpha1.
 					Type: appsv1.DeploymentTriggerOnConfigChange,
 				}},
 			Replicas: replicas,
-			Selector: map[string]string{
-				"deploymentConfig": t.Spec.ApplicationName,
-				"WebServer":        t.Name,
-			},
+			Selector: podSelectorForWebServer(t),
 			Template: &podTemplateSpec,
 		},
 	}
@@ -741,6 +1385,26 @@ func (r *ReconcileWebServer) buildPodForWebServer(t *webserversv1alpha1.WebServe
 					Args: []string{
 						t.Spec.WebImage.WebApp.Builder.ApplicationBuildScript,
 					},
+					// The build script reads the application to build from its environment
+					Env: []corev1.EnvVar{
+						{
+							Name:  "webAppWarFileName",
+							Value: t.Spec.WebImage.WebApp.Name + ".war",
+						},
+						{
+							Name:  "webAppSourceRepositoryURL",
+							Value: t.Spec.WebImage.WebApp.SourceRepositoryURL,
+						},
+						{
+							Name:  "webAppSourceRepositoryRef",
+							Value: t.Spec.WebImage.WebApp.SourceRepositoryRef,
+						},
+						{
+							Name:  "webAppSourceRepositoryContextDir",
+							Value: t.Spec.WebImage.WebApp.SourceRepositoryContextDir,
+						},
+					},
+					Resources: resourceRequirements(t.Spec.WebImage.WebApp.Builder.Resources),
 					VolumeMounts: []corev1.VolumeMount{
 						{
 							Name:      "app-volume",
@@ -756,76 +1420,10 @@ func (r *ReconcileWebServer) buildPodForWebServer(t *webserversv1alpha1.WebServe
 	return pod
 }
 
-func generateWebAppBuildScript(t *webserversv1alpha1.WebServer) string {
-	webApp := t.Spec.WebImage.WebApp
-	webAppWarFileName := webApp.Name + ".war"
-	webAppSourceRepositoryURL := webApp.SourceRepositoryURL
-	webAppSourceRepositoryRef := webApp.SourceRepositoryRef
-	webAppSourceRepositoryContextDir := webApp.SourceRepositoryContextDir
-
-	return fmt.Sprintf(`
-		webAppWarFileName=%s;
-		webAppSourceRepositoryURL=%s;
-		webAppSourceRepositoryRef=%s;
-		webAppSourceRepositoryContextDir=%s;
-
-		# Some pods don't have root privileges, so the build takes place in /tmp
-		cd tmp;
-
-		# Create a custom .m2 repo in a location where no root privileges are required
-		mkdir -p /tmp/.m2/repo;
-
-		# Create custom maven settings that change the location of the .m2 repo
-		echo '<settings xmlns="http://maven.apache.org/SETTINGS/1.0.0" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"' >> /tmp/.m2/settings.xml
-		echo 'xsi:schemaLocation="http://maven.apache.org/SETTINGS/1.0.0 https://maven.apache.org/xsd/settings-1.0.0.xsd">' >> /tmp/.m2/settings.xml
-		echo '<localRepository>/tmp/.m2/repo</localRepository>' >> /tmp/.m2/settings.xml
-		echo '</settings>' >> /tmp/.m2/settings.xml
-
-		if [ -z ${webAppSourceRepositoryURL} ]; then
-			echo "Need an URL like https://github.com/jfclere/demo-webapp.git";
-			exit 1;
-		fi;
-
-		git clone ${webAppSourceRepositoryURL};
-		if [ $? -ne 0 ]; then
-			echo "Can't clone ${webAppSourceRepositoryURL}";
-			exit 1;
-		fi;
-
-		# Get the name of the source code directory
-		DIR=$(echo ${webAppSourceRepositoryURL##*/});
-		DIR=$(echo ${DIR%%.*});
-
-		cd ${DIR};
-
-		if [ ! -z ${webAppSourceRepositoryRef} ]; then
-			git checkout ${webAppSourceRepositoryRef};
-		fi;
-
-		if [ ! -z ${webAppSourceRepositoryContextDir} ]; then
-			cd ${webAppSourceRepositoryContextDir};
-		fi;
-
-		# Builds the webapp using the custom maven settings
-		mvn clean install -gs /tmp/.m2/settings.xml;
-		if [ $? -ne 0 ]; then
-			echo "mvn install failed please check the pom.xml in ${webAppSourceRepositoryURL}";
-			exit 1;
-		fi
-
-		# Copies the resulting war to the mounted persistent volume
-		cp target/*.war /mnt/${webAppWarFileName};`,
-		webAppWarFileName,
-		webAppSourceRepositoryURL,
-		webAppSourceRepositoryRef,
-		webAppSourceRepositoryContextDir,
-	)
-}
-
 func (r *ReconcileWebServer) deploymentForWebServer(t *webserversv1alpha1.WebServer, useKUBEPing bool) *kbappsv1.Deployment {
 
 	replicas := int32(1)
-	podTemplateSpec := podTemplateSpecForWebServer(t, t.Spec.WebImage.ApplicationImage, useKUBEPing)
+	podTemplateSpec := podTemplateSpecForWebServer(t, deployedImage(t), useKUBEPing)
 	deployment := &kbappsv1.Deployment{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "k8s.io/api/apps/v1",
@@ -833,24 +1431,71 @@ func (r *ReconcileWebServer) deploymentForWebServer(t *webserversv1alpha1.WebSer
 		},
 		ObjectMeta: objectMetaForWebServer(t, t.Spec.ApplicationName),
 		Spec: kbappsv1.DeploymentSpec{
-			Strategy: kbappsv1.DeploymentStrategy{
-				Type: kbappsv1.RecreateDeploymentStrategyType,
-			},
-			Replicas: &replicas,
+			Strategy:        deploymentStrategyForWebServer(t),
+			MinReadySeconds: minReadySecondsForWebServer(t),
+			Replicas:        &replicas,
 			Selector: &metav1.LabelSelector{
-				MatchLabels: map[string]string{
-					"deploymentConfig": t.Spec.ApplicationName,
-					"WebServer":        t.Name,
-				},
+				MatchLabels: podSelectorForWebServer(t),
 			},
 			Template: podTemplateSpec,
 		},
 	}
 
+	if webserversv1alpha1.HasColors(t) {
+		setDeploymentColor(t, deployment, activeColor(t))
+	}
+
 	controllerutil.SetControllerReference(t, deployment, r.scheme)
 	return deployment
 }
 
+// isRollingUpdate returns whether the pods are replaced progressively when the pod template changes
+func isRollingUpdate(t *webserversv1alpha1.WebServer) bool {
+	return t.Spec.UpdateStrategy != nil && t.Spec.UpdateStrategy.Type == "RollingUpdate"
+}
+
+// deploymentStrategyForWebServer returns the strategy of the Deployment, the readiness probe of the pods gates
+// the rolling updates
+func deploymentStrategyForWebServer(t *webserversv1alpha1.WebServer) kbappsv1.DeploymentStrategy {
+	if !isRollingUpdate(t) {
+		return kbappsv1.DeploymentStrategy{
+			Type: kbappsv1.RecreateDeploymentStrategyType,
+		}
+	}
+	return kbappsv1.DeploymentStrategy{
+		Type: kbappsv1.RollingUpdateDeploymentStrategyType,
+		RollingUpdate: &kbappsv1.RollingUpdateDeployment{
+			MaxSurge:       t.Spec.UpdateStrategy.MaxSurge,
+			MaxUnavailable: t.Spec.UpdateStrategy.MaxUnavailable,
+		},
+	}
+}
+
+// deploymentConfigStrategyForWebServer returns the strategy of the DeploymentConfig, the readiness probe of the pods
+// gates the rolling updates
+func deploymentConfigStrategyForWebServer(t *webserversv1alpha1.WebServer) appsv1.DeploymentStrategy {
+	if !isRollingUpdate(t) {
+		return appsv1.DeploymentStrategy{
+			Type: appsv1.DeploymentStrategyTypeRecreate,
+		}
+	}
+	return appsv1.DeploymentStrategy{
+		Type: appsv1.DeploymentStrategyTypeRolling,
+		RollingParams: &appsv1.RollingDeploymentStrategyParams{
+			MaxSurge:       t.Spec.UpdateStrategy.MaxSurge,
+			MaxUnavailable: t.Spec.UpdateStrategy.MaxUnavailable,
+		},
+	}
+}
+
+// minReadySecondsForWebServer returns the number of seconds a new pod has to be ready during a rolling update
+func minReadySecondsForWebServer(t *webserversv1alpha1.WebServer) int32 {
+	if !isRollingUpdate(t) {
+		return 0
+	}
+	return t.Spec.UpdateStrategy.MinReadySeconds
+}
+
 func objectMetaForWebServer(t *webserversv1alpha1.WebServer, name string) metav1.ObjectMeta {
 	return metav1.ObjectMeta{
 		Name:      name,
@@ -861,42 +1506,106 @@ func objectMetaForWebServer(t *webserversv1alpha1.WebServer, name string) metav1
 	}
 }
 
+// podTemplateHashAnnotation is the annotation of the pod template holding the hash of the template generated
+// from the WebServer, a Deployment or DeploymentConfig is rolled out when the hash changes.
+const podTemplateHashAnnotation = "web.servers.org/pod-template-hash"
+
+// podTemplateHash returns the hash of a pod template stored in its annotations
+func podTemplateHash(template corev1.PodTemplateSpec) string {
+	return template.Annotations[podTemplateHashAnnotation]
+}
+
+// setPodTemplateHash computes the hash of the pod template and stores it in the annotations of the template
+func setPodTemplateHash(template *corev1.PodTemplateSpec) {
+	delete(template.Annotations, podTemplateHashAnnotation)
+	// The JSON encoding of the template is stable: struct fields and map keys are always written in the same order
+	data, err := json.Marshal(template)
+	if err != nil {
+		log.Error(err, "Failed to compute the hash of the pod template")
+		return
+	}
+	hasher := fnv.New32a()
+	hasher.Write(data)
+	if template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+	template.Annotations[podTemplateHashAnnotation] = strconv.FormatUint(uint64(hasher.Sum32()), 16)
+}
+
+// setPodTemplateAnnotation sets an annotation of the pod template and updates the hash of the template
+func setPodTemplateAnnotation(template *corev1.PodTemplateSpec, key string, value string) {
+	if template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+	template.Annotations[key] = value
+	setPodTemplateHash(template)
+}
+
 func podTemplateSpecForWebServer(t *webserversv1alpha1.WebServer, image string, useKUBEPing bool) corev1.PodTemplateSpec {
 	objectMeta := objectMetaForWebServer(t, t.Spec.ApplicationName)
 	objectMeta.Labels["deploymentConfig"] = t.Spec.ApplicationName
 	objectMeta.Labels["WebServer"] = t.Name
+	objectMeta.Labels[servingLabel] = "true"
 	var health *webserversv1alpha1.WebServerHealthCheckSpec = &webserversv1alpha1.WebServerHealthCheckSpec{}
 	if t.Spec.WebImage != nil {
 		health = t.Spec.WebImage.WebServerHealthCheck
 	} else {
 		health = t.Spec.WebImageStream.WebServerHealthCheck
 	}
+	ports := []corev1.ContainerPort{{
+		Name:          "jolokia",
+		ContainerPort: 8778,
+		Protocol:      corev1.ProtocolTCP,
+	}, {
+		Name:          "http",
+		ContainerPort: 8080,
+		Protocol:      corev1.ProtocolTCP,
+	}}
+	if t.Spec.TLS != nil {
+		ports = append(ports, corev1.ContainerPort{
+			Name:          "https",
+			ContainerPort: 8443,
+			Protocol:      corev1.ProtocolTCP,
+		})
+	}
+	// server.xml is mounted with a subPath, the pods don't see the changes of the ConfigMap. Reconcile stops at the
+	// ConfigMap when server.xml can't be generated, the pod template is not deployed then.
+	serverXml, _ := serverXmlForWebServer(t, useKUBEPing)
+	objectMeta.Annotations = map[string]string{
+		serverXmlHashAnnotation: serverXmlHash(serverXml),
+	}
+	// KUBEPing lists the pods with the ServiceAccount allowed to do so, the other pods use the default ServiceAccount
+	serviceAccountName := ""
+	if useKUBEPing && webserversv1alpha1.IsSessionClusteringEnabled(t) {
+		serviceAccountName = kubePingName(t)
+	}
 	terminationGracePeriodSeconds := int64(60)
-	return corev1.PodTemplateSpec{
+	template := corev1.PodTemplateSpec{
 		ObjectMeta: objectMeta,
 		Spec: corev1.PodSpec{
 			TerminationGracePeriodSeconds: &terminationGracePeriodSeconds,
+			ServiceAccountName:            serviceAccountName,
 			Containers: []corev1.Container{{
 				Name:            t.Spec.ApplicationName,
 				Image:           image,
 				ImagePullPolicy: "Always",
-				ReadinessProbe:  createReadinessProbe(t, health),
-				LivenessProbe:   createLivenessProbe(t, health),
-				Ports: []corev1.ContainerPort{{
-					Name:          "jolokia",
-					ContainerPort: 8778,
-					Protocol:      corev1.ProtocolTCP,
-				}, {
-					Name:          "http",
-					ContainerPort: 8080,
-					Protocol:      corev1.ProtocolTCP,
-				}},
-				Env:          createEnvVars(t, useKUBEPing),
-				VolumeMounts: createVolumeMounts(t),
+				ReadinessProbe:  createReadinessProbe(health),
+				LivenessProbe:   createLivenessProbe(health),
+				StartupProbe:    createStartupProbe(health),
+				Ports:           ports,
+				Env:             createEnvVars(t, useKUBEPing),
+				EnvFrom:         t.Spec.EnvFrom,
+				Resources:       resourceRequirements(t.Spec.Resources),
+				VolumeMounts:    createVolumeMounts(t),
 			}},
-			Volumes: createVolumes(t),
+			InitContainers: userContainers(t.Spec.InitContainers),
+			Volumes:        createVolumes(t),
 		},
 	}
+	// The Tomcat container stays the first container of the pods
+	template.Spec.Containers = append(template.Spec.Containers, userContainers(t.Spec.Sidecars)...)
+	setPodTemplateHash(&template)
+	return template
 }
 
 func (r *ReconcileWebServer) routeForWebServer(t *webserversv1alpha1.WebServer) *routev1.Route {
@@ -916,11 +1625,204 @@ func (r *ReconcileWebServer) routeForWebServer(t *webserversv1alpha1.WebServer)
 			},
 		},
 	}
+	if isCanary(t) {
+		setCanaryBackends(t, route)
+	}
+
+	if spec := t.Spec.Route; spec != nil {
+		for key, value := range spec.Annotations {
+			route.Annotations[key] = value
+		}
+		if spec.Timeout != "" {
+			route.Annotations["haproxy.router.openshift.io/timeout"] = spec.Timeout
+		}
+		if spec.CookieName != "" {
+			route.Annotations["router.openshift.io/cookie_name"] = spec.CookieName
+		}
+		route.Spec.Host = spec.Host
+		route.Spec.Path = spec.Path
+		if spec.TLS != nil {
+			route.Spec.TLS = &routev1.TLSConfig{
+				Termination:                   routev1.TLSTerminationType(spec.TLS.Termination),
+				InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyType(spec.TLS.InsecureEdgeTerminationPolicy),
+			}
+		}
+	}
+	if t.Spec.TLS != nil {
+		// The Service has two ports, the router connects to the HTTPS one when the application terminates TLS
+		route.Spec.Port = &routev1.RoutePort{TargetPort: intstr.FromString("ui")}
+		if route.Spec.TLS != nil && route.Spec.TLS.Termination != routev1.TLSTerminationEdge {
+			route.Spec.Port.TargetPort = intstr.FromString("https")
+		}
+	}
+	setManagedAnnotations(route)
 
 	controllerutil.SetControllerReference(t, route, r.scheme)
 	return route
 }
 
+// setRouteCertificates copies in the Route the certificates of the Secrets referenced in the TLS configuration of the WebServer route,
+// a Route can't reference a Secret.
+func (r *ReconcileWebServer) setRouteCertificates(t *webserversv1alpha1.WebServer, route *routev1.Route) error {
+	if route.Spec.TLS == nil {
+		return nil
+	}
+	var err error
+	if name := t.Spec.Route.TLS.CertificateSecretName; name != "" {
+		if route.Spec.TLS.Certificate, err = r.getSecretValue(t, name, corev1.TLSCertKey); err != nil {
+			return err
+		}
+		if route.Spec.TLS.Key, err = r.getSecretValue(t, name, corev1.TLSPrivateKeyKey); err != nil {
+			return err
+		}
+		if route.Spec.TLS.CACertificate, err = r.getSecretValue(t, name, "ca.crt"); err != nil {
+			return err
+		}
+	}
+	if name := t.Spec.Route.TLS.DestinationCACertificateSecretName; name != "" {
+		if route.Spec.TLS.DestinationCACertificate, err = r.getSecretValue(t, name, "ca.crt"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *ReconcileWebServer) ingressForWebServer(t *webserversv1alpha1.WebServer) *networkingv1beta1.Ingress {
+	spec := t.Spec.Ingress
+	objectMeta := objectMetaForWebServer(t, t.Spec.ApplicationName)
+	objectMeta.Annotations = map[string]string{}
+	for key, value := range spec.Annotations {
+		objectMeta.Annotations[key] = value
+	}
+	if spec.IngressClassName != "" {
+		objectMeta.Annotations["kubernetes.io/ingress.class"] = spec.IngressClassName
+	}
+	path := spec.Path
+	if path == "" {
+		path = "/"
+	}
+
+	ingress := &networkingv1beta1.Ingress{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "networking.k8s.io/v1beta1",
+			Kind:       "Ingress",
+		},
+		ObjectMeta: objectMeta,
+		Spec: networkingv1beta1.IngressSpec{
+			Rules: []networkingv1beta1.IngressRule{{
+				Host: spec.Host,
+				IngressRuleValue: networkingv1beta1.IngressRuleValue{
+					HTTP: &networkingv1beta1.HTTPIngressRuleValue{
+						Paths: []networkingv1beta1.HTTPIngressPath{{
+							Path: path,
+							Backend: networkingv1beta1.IngressBackend{
+								ServiceName: t.Spec.ApplicationName,
+								ServicePort: intstr.FromInt(8080),
+							},
+						}},
+					},
+				},
+			}},
+		},
+	}
+	if spec.TLSSecretName != "" {
+		tls := networkingv1beta1.IngressTLS{
+			SecretName: spec.TLSSecretName,
+		}
+		if spec.Host != "" {
+			tls.Hosts = []string{spec.Host}
+		}
+		ingress.Spec.TLS = []networkingv1beta1.IngressTLS{tls}
+	}
+
+	setManagedAnnotations(ingress)
+
+	controllerutil.SetControllerReference(t, ingress, r.scheme)
+	return ingress
+}
+
+// ingressHosts returns the sorted hosts of the load balancer exposing the Ingress, either host names or IP addresses
+func ingressHosts(ingress *networkingv1beta1.Ingress) []string {
+	hosts := make([]string, len(ingress.Status.LoadBalancer.Ingress))
+	for i, loadBalancerIngress := range ingress.Status.LoadBalancer.Ingress {
+		if loadBalancerIngress.Hostname != "" {
+			hosts[i] = loadBalancerIngress.Hostname
+		} else {
+			hosts[i] = loadBalancerIngress.IP
+		}
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+func (r *ReconcileWebServer) horizontalPodAutoscalerForWebServer(t *webserversv1alpha1.WebServer) *autoscalingv2beta2.HorizontalPodAutoscaler {
+	autoscaling := t.Spec.Autoscaling
+	metrics := []autoscalingv2beta2.MetricSpec{}
+	if autoscaling.TargetCPUUtilizationPercentage != nil {
+		metrics = append(metrics, resourceMetricForWebServer(corev1.ResourceCPU, autoscaling.TargetCPUUtilizationPercentage))
+	}
+	if autoscaling.TargetMemoryUtilizationPercentage != nil {
+		metrics = append(metrics, resourceMetricForWebServer(corev1.ResourceMemory, autoscaling.TargetMemoryUtilizationPercentage))
+	}
+	for _, customMetric := range autoscaling.CustomMetrics {
+		targetAverageValue := customMetric.TargetAverageValue.DeepCopy()
+		metrics = append(metrics, autoscalingv2beta2.MetricSpec{
+			Type: autoscalingv2beta2.PodsMetricSourceType,
+			Pods: &autoscalingv2beta2.PodsMetricSource{
+				Metric: autoscalingv2beta2.MetricIdentifier{
+					Name: customMetric.Name,
+				},
+				Target: autoscalingv2beta2.MetricTarget{
+					Type:         autoscalingv2beta2.AverageValueMetricType,
+					AverageValue: &targetAverageValue,
+				},
+			},
+		})
+	}
+	if len(metrics) == 0 {
+		// Make the default target of the HorizontalPodAutoscaler explicit, it is set by the API server otherwise
+		defaultCPUUtilization := int32(80)
+		metrics = append(metrics, resourceMetricForWebServer(corev1.ResourceCPU, &defaultCPUUtilization))
+	}
+
+	hpa := &autoscalingv2beta2.HorizontalPodAutoscaler{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "autoscaling/v2beta2",
+			Kind:       "HorizontalPodAutoscaler",
+		},
+		ObjectMeta: objectMetaForWebServer(t, t.Spec.ApplicationName),
+		Spec: autoscalingv2beta2.HorizontalPodAutoscalerSpec{
+			// The WebServer is scaled through its scale subresource
+			ScaleTargetRef: autoscalingv2beta2.CrossVersionObjectReference{
+				APIVersion: webserversv1alpha1.SchemeGroupVersion.String(),
+				Kind:       "WebServer",
+				Name:       t.Name,
+			},
+			MinReplicas: autoscaling.MinReplicas,
+			MaxReplicas: autoscaling.MaxReplicas,
+			Metrics:     metrics,
+		},
+	}
+
+	controllerutil.SetControllerReference(t, hpa, r.scheme)
+	return hpa
+}
+
+// resourceMetricForWebServer returns a metric targeting the average utilization of a resource of the pods
+func resourceMetricForWebServer(name corev1.ResourceName, averageUtilization *int32) autoscalingv2beta2.MetricSpec {
+	utilization := *averageUtilization
+	return autoscalingv2beta2.MetricSpec{
+		Type: autoscalingv2beta2.ResourceMetricSourceType,
+		Resource: &autoscalingv2beta2.ResourceMetricSource{
+			Name: name,
+			Target: autoscalingv2beta2.MetricTarget{
+				Type:               autoscalingv2beta2.UtilizationMetricType,
+				AverageUtilization: &utilization,
+			},
+		},
+	}
+}
+
 func (r *ReconcileWebServer) imageStreamForWebServer(t *webserversv1alpha1.WebServer) *imagev1.ImageStream {
 
 	imageStream := &imagev1.ImageStream{
@@ -975,126 +1877,39 @@ func (r *ReconcileWebServer) buildConfigForWebServer(t *webserversv1alpha1.WebSe
 			Triggers: createBuildTriggerPolicy(t),
 		},
 	}
+	if params := t.Spec.WebImageStream.WebSources.WebSourcesParams; params != nil {
+		buildConfig.Spec.Resources = resourceRequirements(params.Resources)
+	}
 
 	controllerutil.SetControllerReference(t, buildConfig, r.scheme)
 	return buildConfig
 }
 
-// create the shell script to modify server.xml
-//
-func commandForServerXml(useKUBEPing bool) map[string]string {
-	cmd := make(map[string]string)
-	if useKUBEPing {
-		cmd["test.sh"] = "FILE=`find /opt -name server.xml`\n" +
-			"grep -q MembershipProvider ${FILE}\n" +
-			"if [ $? -ne 0 ]; then\n" +
-			"  sed -i '/cluster.html/a        <Cluster className=\"org.apache.catalina.ha.tcp.SimpleTcpCluster\" channelSendOptions=\"6\">\\n <Channel className=\"org.apache.catalina.tribes.group.GroupChannel\">\\n <Membership className=\"org.apache.catalina.tribes.membership.cloud.CloudMembershipService\" membershipProviderClassName=\"org.apache.catalina.tribes.membership.cloud.KubernetesMembershipProvider\"/>\\n </Channel>\\n </Cluster>\\n' ${FILE}\n" +
-			"fi\n"
-	} else {
-		cmd["test.sh"] = "FILE=`find /opt -name server.xml`\n" +
-			"grep -q MembershipProvider ${FILE}\n" +
-			"if [ $? -ne 0 ]; then\n" +
-			"  sed -i '/cluster.html/a        <Cluster className=\"org.apache.catalina.ha.tcp.SimpleTcpCluster\" channelSendOptions=\"6\">\\n <Channel className=\"org.apache.catalina.tribes.group.GroupChannel\">\\n <Membership className=\"org.apache.catalina.tribes.membership.cloud.CloudMembershipService\" membershipProviderClassName=\"org.apache.catalina.tribes.membership.cloud.DNSMembershipProvider\"/>\\n </Channel>\\n </Cluster>\\n' ${FILE}\n" +
-			"fi\n"
-	}
-	return cmd
-}
-
-// createLivenessProbe returns a custom probe if the serverLivenessScript string is defined and not empty in the Custom Resource.
-// Otherwise, it uses the default /health Valve via curl.
-//
-// If defined, serverLivenessScript must be a shell script that
-// complies to the Kubernetes probes requirements and use the following format
-// shell -c "command"
-func createLivenessProbe(t *webserversv1alpha1.WebServer, health *webserversv1alpha1.WebServerHealthCheckSpec) *corev1.Probe {
-	livenessProbeScript := ""
-	if health != nil {
-		livenessProbeScript = health.ServerLivenessScript
-	}
-	if livenessProbeScript != "" {
-		return createCustomProbe(t, livenessProbeScript)
-	} else {
-		/* Use the default one */
-		return &corev1.Probe{
-			Handler: corev1.Handler{
-				HTTPGet: &corev1.HTTPGetAction{
-					Path: "/health",
-					Port: intstr.FromInt(8080),
-				},
-			},
-		}
-	}
-}
-
-// createReadinessProbe returns a custom probe if the serverReadinessScript string is defined and not empty in the Custom Resource.
-// Otherwise, it uses the default /health Valve via curl.
-//
-// If defined, serverReadinessScript must be a shell script that
-// complies to the Kubernetes probes requirements and use the following format
-// shell -c "command"
-func createReadinessProbe(t *webserversv1alpha1.WebServer, health *webserversv1alpha1.WebServerHealthCheckSpec) *corev1.Probe {
-	readinessProbeScript := ""
-	if health != nil {
-		readinessProbeScript = health.ServerReadinessScript
-	}
-	if readinessProbeScript != "" {
-		return createCustomProbe(t, readinessProbeScript)
-	} else {
-		/* Use the default one */
-		return &corev1.Probe{
-			Handler: corev1.Handler{
-				HTTPGet: &corev1.HTTPGetAction{
-					Path: "/health",
-					Port: intstr.FromInt(8080),
-				},
-			},
-		}
-	}
-}
-
-func createCustomProbe(t *webserversv1alpha1.WebServer, probeScript string) *corev1.Probe {
-	// If the script has the following format: shell -c "command"
-	// we create the slice ["shell", "-c", "command"]
-	probeScriptSlice := make([]string, 0)
-	pos := strings.Index(probeScript, "\"")
-	if pos != -1 {
-		probeScriptSlice = append(strings.Split(probeScript[0:pos], " "), probeScript[pos:])
-	} else {
-		probeScriptSlice = strings.Split(probeScript, " ")
-	}
-	return &corev1.Probe{
-		Handler: corev1.Handler{
-			Exec: &corev1.ExecAction{
-				Command: probeScriptSlice,
-			},
-		},
-	}
-}
-
-func isOpenShift(c *rest.Config) bool {
-	var err error
-	var dcclient *discovery.DiscoveryClient
-	dcclient, err = discovery.NewDiscoveryClientForConfig(c)
+// hasAPIGroup returns true when the API server serves the given API group
+func hasAPIGroup(c *rest.Config, name string) bool {
+	dcclient, err := discovery.NewDiscoveryClientForConfig(c)
 	if err != nil {
-		log.Info("isOpenShift discovery.NewDiscoveryClientForConfig has encountered a problem")
+		log.Info("hasAPIGroup discovery.NewDiscoveryClientForConfig has encountered a problem")
 		return false
 	}
 	apiList, err := dcclient.ServerGroups()
 	if err != nil {
-		log.Info("isOpenShift client.ServerGroups has encountered a problem")
+		log.Info("hasAPIGroup client.ServerGroups has encountered a problem")
 		return false
 	}
 	for _, v := range apiList.Groups {
-		log.Info(v.Name)
-		if v.Name == "route.openshift.io" {
-
-			log.Info("route.openshift.io was found in apis, platform is OpenShift")
+		if v.Name == name {
+			log.Info(name + " was found in apis")
 			return true
 		}
 	}
 	return false
 }
 
+func isOpenShift(c *rest.Config) bool {
+	return hasAPIGroup(c, "route.openshift.io")
+}
+
 // GetPodsForWebServer lists pods which belongs to the Web server
 // the pods are differentiated based on the selectors
 func GetPodsForWebServer(r *ReconcileWebServer, j *webserversv1alpha1.WebServer) (*corev1.PodList, error) {
@@ -1113,6 +1928,21 @@ func GetPodsForWebServer(r *ReconcileWebServer, j *webserversv1alpha1.WebServer)
 	return podList, err
 }
 
+// podSelectorForWebServer returns the labels selecting the pods of the WebServer, the labels of
+//  the WebServer itself are not set on the pods
+func podSelectorForWebServer(t *webserversv1alpha1.WebServer) map[string]string {
+	return map[string]string{
+		"deploymentConfig": t.Spec.ApplicationName,
+		"WebServer":        t.Name,
+	}
+}
+
+// scaleSelectorForWebServer returns the selector of the pods of the WebServer published by the scale subresource,
+// the HorizontalPodAutoscaler reads the metrics of the pods it selects
+func scaleSelectorForWebServer(t *webserversv1alpha1.WebServer) string {
+	return metav1.FormatLabelSelector(&metav1.LabelSelector{MatchLabels: podSelectorForWebServer(t)})
+}
+
 // LabelsForWeb return a map of labels that are used for identification
 //  of objects belonging to the particular WebServer instance
 func LabelsForWeb(j *webserversv1alpha1.WebServer) map[string]string {
@@ -1168,6 +1998,19 @@ func UpdateStatus(j *webserversv1alpha1.WebServer, client client.Client, objectD
 	return nil
 }
 
+// countReadyPods returns the number of pods having the Ready condition
+func countReadyPods(pods []corev1.Pod) int32 {
+	ready := int32(0)
+	for _, pod := range pods {
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+				ready++
+			}
+		}
+	}
+	return ready
+}
+
 // getPodStatus returns the pod names of the array of pods passed in
 func getPodStatus(pods []corev1.Pod) (bool, []webserversv1alpha1.PodStatus) {
 	var requeue = false
@@ -1180,6 +2023,9 @@ func getPodStatus(pods []corev1.Pod) (bool, []webserversv1alpha1.PodStatus) {
 			podState = webserversv1alpha1.PodStatePending
 		case corev1.PodRunning:
 			podState = webserversv1alpha1.PodStateActive
+			if !isServing(&pod) {
+				podState = webserversv1alpha1.PodStateDraining
+			}
 		}
 
 		podStatuses = append(podStatuses, webserversv1alpha1.PodStatus{
@@ -1197,7 +2043,7 @@ func getPodStatus(pods []corev1.Pod) (bool, []webserversv1alpha1.PodStatus) {
 // Create the env for the pods we are starting.
 func createEnvVars(t *webserversv1alpha1.WebServer, useKUBEPing bool) []corev1.EnvVar {
 	value := "webserver-" + t.Name
-	if useKUBEPing && t.Spec.UseSessionClustering {
+	if useKUBEPing && webserversv1alpha1.IsSessionClusteringEnabled(t) {
 		value = t.Namespace
 	}
 	env := []corev1.EnvVar{
@@ -1206,11 +2052,41 @@ func createEnvVars(t *webserversv1alpha1.WebServer, useKUBEPing bool) []corev1.E
 			Value: value,
 		},
 	}
-	if t.Spec.UseSessionClustering {
-		// Add parameter USE_SESSION_CLUSTERING
+	catalinaOpts := []string{}
+	if t.Spec.TLS != nil && t.Spec.TLS.Keystore != nil {
+		passwordSecretKeyRef := t.Spec.TLS.Keystore.PasswordSecretKeyRef
+		env = append(env, corev1.EnvVar{
+			Name: tlsKeystorePasswordEnv,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &passwordSecretKeyRef,
+			},
+		})
+		// The password is passed to Tomcat as a system property, the variable is expanded by Kubernetes
+		catalinaOpts = append(catalinaOpts, "-D"+tlsKeystorePasswordProperty+"=$("+tlsKeystorePasswordEnv+")")
+	}
+	// The variables of the WebServer are defined before the options, the options can reference them
+	env = append(env, userEnvVars(t)...)
+	if t.Spec.CatalinaOpts != "" {
+		catalinaOpts = append(catalinaOpts, t.Spec.CatalinaOpts)
+	}
+	if len(catalinaOpts) > 0 {
+		env = append(env, corev1.EnvVar{
+			Name:  catalinaOptsEnv,
+			Value: strings.Join(catalinaOpts, " "),
+		})
+	}
+	// The last option of the JVM wins, the options of the WebServer override the heap sizing
+	javaOpts := []string{}
+	if option := jvmHeapOption(t); option != "" {
+		javaOpts = append(javaOpts, option)
+	}
+	if t.Spec.JavaOpts != "" {
+		javaOpts = append(javaOpts, t.Spec.JavaOpts)
+	}
+	if len(javaOpts) > 0 {
 		env = append(env, corev1.EnvVar{
-			Name:  "ENV_FILES",
-			Value: "/test/my-files/test.sh",
+			Name:  javaOptsEnv,
+			Value: strings.Join(javaOpts, " "),
 		})
 	}
 	return env
@@ -1279,12 +2155,22 @@ func createBuildTriggerPolicy(t *webserversv1alpha1.WebServer) []buildv1.BuildTr
 // Create the VolumeMounts
 func createVolumeMounts(t *webserversv1alpha1.WebServer) []corev1.VolumeMount {
 	var volm []corev1.VolumeMount
-	if t.Spec.UseSessionClustering {
+	volm = append(volm, corev1.VolumeMount{
+		Name:      "webserver-" + t.Name,
+		MountPath: serverXmlPath(t),
+		SubPath:   serverXmlKey,
+		ReadOnly:  true,
+	})
+	if t.Spec.TLS != nil {
 		volm = append(volm, corev1.VolumeMount{
-			Name:      "webserver-" + t.Name,
-			MountPath: "/test/my-files",
+			Name:      tlsVolumeName,
+			MountPath: tlsMountPath,
+			ReadOnly:  true,
 		})
 	}
+	if t.Spec.SessionStore != nil {
+		volm = append(volm, sessionStoreVolumeMounts(t)...)
+	}
 	if t.Spec.WebImage != nil && t.Spec.WebImage.WebApp != nil {
 		webAppWarFileName := t.Spec.WebImage.WebApp.Name + ".war"
 			volm = append(volm, corev1.VolumeMount{
@@ -1293,20 +2179,38 @@ func createVolumeMounts(t *webserversv1alpha1.WebServer) []corev1.VolumeMount {
 				SubPath:   webAppWarFileName,
 			})
 	}
-	return volm
+	return append(volm, userVolumeMounts(t.Spec.VolumeMounts)...)
 }
 
 // Create the Volumes
 func createVolumes(t *webserversv1alpha1.WebServer) []corev1.Volume {
 	var vol []corev1.Volume
-	if t.Spec.UseSessionClustering {
+	vol = append(vol, corev1.Volume{
+		Name: "webserver-" + t.Name,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: "webserver-" + t.Name,
+				},
+			},
+		},
+	})
+	if t.Spec.TLS != nil {
 		vol = append(vol, corev1.Volume{
-			Name: "webserver-" + t.Name,
+			Name: tlsVolumeName,
 			VolumeSource: corev1.VolumeSource{
-				ConfigMap: &corev1.ConfigMapVolumeSource{
-					LocalObjectReference: corev1.LocalObjectReference{
-						Name: "webserver-" + t.Name,
-					},
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: t.Spec.TLS.CertificateSecretName,
+				},
+			},
+		})
+	}
+	if t.Spec.SessionStore != nil {
+		vol = append(vol, corev1.Volume{
+			Name: sessionStoreVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: sessionStoreSecretName(t),
 				},
 			},
 		})
@@ -1322,5 +2226,5 @@ func createVolumes(t *webserversv1alpha1.WebServer) []corev1.Volume {
 			},
 		})
 	}
-	return vol
+	return append(vol, userVolumes(t)...)
 }
@@ -0,0 +1,12 @@
+package tomcat
+
+import (
+	"encoding/xml"
+)
+
+// Context is the root element of context.xml, the default configuration of the web applications
+type Context struct {
+	XMLName          xml.Name `xml:"Context"`
+	WatchedResources []string `xml:"WatchedResource"`
+	Manager          *Manager `xml:"Manager,omitempty"`
+}
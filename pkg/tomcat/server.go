@@ -0,0 +1,159 @@
+// Package tomcat models the configuration files of Tomcat generated by the operator
+package tomcat
+
+import (
+	"encoding/xml"
+)
+
+// Server is the root element of server.xml
+type Server struct {
+	XMLName               xml.Name               `xml:"Server"`
+	Port                  int                    `xml:"port,attr"`
+	Shutdown              string                 `xml:"shutdown,attr"`
+	Listeners             []Listener             `xml:"Listener"`
+	GlobalNamingResources *GlobalNamingResources `xml:"GlobalNamingResources,omitempty"`
+	Services              []Service              `xml:"Service"`
+}
+
+// Listener is a lifecycle listener of the Server
+type Listener struct {
+	ClassName string `xml:"className,attr"`
+	LogArgs   *bool  `xml:"logArgs,attr,omitempty"`
+}
+
+// GlobalNamingResources are the JNDI resources shared by the Server
+type GlobalNamingResources struct {
+	Resources []Resource `xml:"Resource"`
+}
+
+// Resource is a JNDI resource
+type Resource struct {
+	Name        string `xml:"name,attr"`
+	Auth        string `xml:"auth,attr,omitempty"`
+	Type        string `xml:"type,attr"`
+	Description string `xml:"description,attr,omitempty"`
+	Factory     string `xml:"factory,attr,omitempty"`
+	Pathname    string `xml:"pathname,attr,omitempty"`
+}
+
+// Service groups the Connectors sharing an Engine
+type Service struct {
+	Name       string      `xml:"name,attr"`
+	Connectors []Connector `xml:"Connector"`
+	Engine     Engine      `xml:"Engine"`
+}
+
+// Connector receives the requests on a port
+type Connector struct {
+	Port              int             `xml:"port,attr"`
+	Protocol          string          `xml:"protocol,attr"`
+	ConnectionTimeout int             `xml:"connectionTimeout,attr,omitempty"`
+	RedirectPort      int             `xml:"redirectPort,attr,omitempty"`
+	SSLEnabled        bool            `xml:"SSLEnabled,attr,omitempty"`
+	Scheme            string          `xml:"scheme,attr,omitempty"`
+	Secure            bool            `xml:"secure,attr,omitempty"`
+	SSLHostConfigs    []SSLHostConfig `xml:"SSLHostConfig"`
+}
+
+// SSLHostConfig is the TLS configuration of a Connector
+type SSLHostConfig struct {
+	Certificates []Certificate `xml:"Certificate"`
+}
+
+// Certificate is the certificate of an SSLHostConfig, either in PEM files or in a keystore
+type Certificate struct {
+	CertificateFile             string `xml:"certificateFile,attr,omitempty"`
+	CertificateKeyFile          string `xml:"certificateKeyFile,attr,omitempty"`
+	CertificateKeystoreFile     string `xml:"certificateKeystoreFile,attr,omitempty"`
+	CertificateKeystoreType     string `xml:"certificateKeystoreType,attr,omitempty"`
+	CertificateKeystorePassword string `xml:"certificateKeystorePassword,attr,omitempty"`
+}
+
+// Engine processes the requests of the Connectors of its Service
+type Engine struct {
+	Name        string   `xml:"name,attr"`
+	DefaultHost string   `xml:"defaultHost,attr"`
+	Realm       *Realm   `xml:"Realm,omitempty"`
+	Cluster     *Cluster `xml:"Cluster,omitempty"`
+	Hosts       []Host   `xml:"Host"`
+}
+
+// Realm authenticates the users, a combined Realm contains other Realms
+type Realm struct {
+	ClassName    string  `xml:"className,attr"`
+	ResourceName string  `xml:"resourceName,attr,omitempty"`
+	Realms       []Realm `xml:"Realm"`
+}
+
+// Cluster replicates the sessions between the Tomcat instances
+type Cluster struct {
+	ClassName          string   `xml:"className,attr"`
+	ChannelSendOptions int      `xml:"channelSendOptions,attr,omitempty"`
+	Manager            *Manager `xml:"Manager,omitempty"`
+	Channel            *Channel `xml:"Channel,omitempty"`
+	Valves             []Valve  `xml:"Valve"`
+}
+
+// Manager stores the sessions, a cluster Manager replicates them and a persistent Manager saves them in a Store
+type Manager struct {
+	ClassName                    string `xml:"className,attr"`
+	ExpireSessionsOnShutdown     bool   `xml:"expireSessionsOnShutdown,attr,omitempty"`
+	NotifyListenersOnReplication *bool  `xml:"notifyListenersOnReplication,attr,omitempty"`
+	ConfigPath                   string `xml:"configPath,attr,omitempty"`
+	KeyPrefix                    string `xml:"keyPrefix,attr,omitempty"`
+	MaxIdleBackup                *int   `xml:"maxIdleBackup,attr,omitempty"`
+	Store                        *Store `xml:"Store,omitempty"`
+}
+
+// Store saves the sessions of a persistent Manager
+type Store struct {
+	ClassName          string `xml:"className,attr"`
+	DriverName         string `xml:"driverName,attr,omitempty"`
+	ConnectionURL      string `xml:"connectionURL,attr,omitempty"`
+	ConnectionName     string `xml:"connectionName,attr,omitempty"`
+	ConnectionPassword string `xml:"connectionPassword,attr,omitempty"`
+	SessionTable       string `xml:"sessionTable,attr,omitempty"`
+}
+
+// Channel is the group communication channel of a Cluster
+type Channel struct {
+	ClassName  string      `xml:"className,attr"`
+	Membership *Membership `xml:"Membership,omitempty"`
+}
+
+// Membership discovers the members of a Channel
+type Membership struct {
+	ClassName                   string `xml:"className,attr"`
+	MembershipProviderClassName string `xml:"membershipProviderClassName,attr,omitempty"`
+	ConnectTimeout              *int32 `xml:"connectTimeout,attr,omitempty"`
+	ReadTimeout                 *int32 `xml:"readTimeout,attr,omitempty"`
+	ExpirationTime              *int32 `xml:"expirationTime,attr,omitempty"`
+}
+
+// Host is a virtual host of an Engine
+type Host struct {
+	Name       string  `xml:"name,attr"`
+	AppBase    string  `xml:"appBase,attr"`
+	UnpackWARs bool    `xml:"unpackWARs,attr"`
+	AutoDeploy bool    `xml:"autoDeploy,attr"`
+	Valves     []Valve `xml:"Valve"`
+}
+
+// Valve processes the requests of its container
+type Valve struct {
+	ClassName string `xml:"className,attr"`
+	Directory string `xml:"directory,attr,omitempty"`
+	Prefix    string `xml:"prefix,attr,omitempty"`
+	Suffix    string `xml:"suffix,attr,omitempty"`
+	Pattern   string `xml:"pattern,attr,omitempty"`
+	Filter    string `xml:"filter,attr,omitempty"`
+}
+
+// Marshal returns the XML document of a configuration element
+func Marshal(v interface{}) (string, error) {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(data) + "\n", nil
+}
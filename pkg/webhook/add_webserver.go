@@ -0,0 +1,10 @@
+package webhook
+
+import (
+	"github.com/web-servers/jws-operator/pkg/webhook/webserver"
+)
+
+func init() {
+	// AddToManagerFuncs is a list of functions to create webhooks and add them to a manager.
+	AddToManagerFuncs = append(AddToManagerFuncs, webserver.Add)
+}
@@ -0,0 +1,50 @@
+package webserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// webServerDefaulter persists the default values of the optional fields of the WebServers
+type webServerDefaulter struct {
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &webServerDefaulter{}
+var _ admission.DecoderInjector = &webServerDefaulter{}
+
+// InjectDecoder injects the decoder of the webhook server
+func (d *webServerDefaulter) InjectDecoder(decoder *admission.Decoder) error {
+	d.decoder = decoder
+	return nil
+}
+
+// Handle sets the default values of the WebServer of the admission request
+func (d *webServerDefaulter) Handle(ctx context.Context, req admission.Request) admission.Response {
+	webServer := &webserversv1alpha1.WebServer{}
+	if err := d.decoder.Decode(req, webServer); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if !webserversv1alpha1.SetDefaults(webServer) {
+		return admission.Allowed("")
+	}
+
+	marshaled, err := json.Marshal(webServer)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	log.Info("Defaulted WebServer", "WebServer.Namespace", webServer.Namespace, "WebServer.Name", webServer.Name)
+	resp := admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+	// The operations are generated from maps, sort them to return the same patch for the same WebServer
+	sort.SliceStable(resp.Patches, func(i, j int) bool {
+		return resp.Patches[i].Path < resp.Patches[j].Path
+	})
+	return resp
+}
@@ -0,0 +1,82 @@
+package webserver
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// newDefaulter returns a defaulter decoding the WebServers of the admission requests
+func newDefaulter(t *testing.T) *webServerDefaulter {
+	scheme := runtime.NewScheme()
+	if err := webserversv1alpha1.SchemeBuilder.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	decoder, err := admission.NewDecoder(scheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaulter := &webServerDefaulter{}
+	if err := defaulter.InjectDecoder(decoder); err != nil {
+		t.Fatal(err)
+	}
+	return defaulter
+}
+
+// createRequest returns the admission request creating the WebServer
+func createRequest(t *testing.T, webServer *webserversv1alpha1.WebServer) (admission.Request, []byte) {
+	webServer.APIVersion = webserversv1alpha1.SchemeGroupVersion.String()
+	webServer.Kind = "WebServer"
+	raw, err := json.Marshal(webServer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+		Operation: admissionv1beta1.Create,
+		Object:    runtime.RawExtension{Raw: raw},
+	}}, raw
+}
+
+func TestDefaulterPatchIsStable(t *testing.T) {
+	defaulter := newDefaulter(t)
+	webServer := validWebServer()
+	webServer.Spec.UpdateStrategy = &webserversv1alpha1.UpdateStrategySpec{
+		BlueGreen: &webserversv1alpha1.BlueGreenSpec{SmokeTests: []webserversv1alpha1.SmokeTestSpec{{Path: "/health"}}},
+	}
+	req, raw := createRequest(t, webServer)
+
+	resp := defaulter.Handle(context.TODO(), req)
+	if !resp.Allowed || len(resp.Patches) == 0 {
+		t.Fatalf("got %v, expected the defaults to be patched", resp)
+	}
+	if again := defaulter.Handle(context.TODO(), req); !reflect.DeepEqual(again.Patches, resp.Patches) {
+		t.Errorf("got %v, expected the same patch %v", again.Patches, resp.Patches)
+	}
+
+	// The defaulted WebServer must not be patched again
+	operations, err := json.Marshal(resp.Patches)
+	if err != nil {
+		t.Fatal(err)
+	}
+	patch, err := jsonpatch.DecodePatch(operations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaulted, err := patch.Apply(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Object.Raw = defaulted
+	resp = defaulter.Handle(context.TODO(), req)
+	if !resp.Allowed || len(resp.Patches) > 0 {
+		t.Errorf("got %v, expected the defaulted WebServer to be left unchanged", resp.Patches)
+	}
+}
@@ -0,0 +1,515 @@
+package webserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// webServerValidator rejects the WebServers that the operator can't deploy
+type webServerValidator struct {
+	client  client.Client
+	decoder *admission.Decoder
+	// isOpenShift tells whether the cluster serves the Routes splitting the requests of the canary releases
+	isOpenShift bool
+}
+
+var _ admission.Handler = &webServerValidator{}
+var _ admission.DecoderInjector = &webServerValidator{}
+
+// InjectDecoder injects the decoder of the webhook server
+func (v *webServerValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// Handle validates the WebServer of the admission request
+func (v *webServerValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	webServer := &webserversv1alpha1.WebServer{}
+	if err := v.decoder.Decode(req, webServer); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	errs := validateWebServer(webServer, v.isOpenShift)
+
+	duplicates, err := v.validateApplicationNameIsUnique(ctx, webServer)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	errs = append(errs, duplicates...)
+
+	if req.Operation == admissionv1beta1.Update {
+		oldWebServer := &webserversv1alpha1.WebServer{}
+		if err := v.decoder.DecodeRaw(req.OldObject, oldWebServer); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		errs = append(errs, validateWebServerUpdate(webServer, oldWebServer)...)
+	}
+
+	if len(errs) > 0 {
+		log.Info("Rejected WebServer", "WebServer.Namespace", webServer.Namespace, "WebServer.Name", webServer.Name, "Errors", errs.ToAggregate().Error())
+		return admission.Denied(errs.ToAggregate().Error())
+	}
+	return admission.Allowed("")
+}
+
+// validateApplicationNameIsUnique checks that no other WebServer of the namespace uses the same applicationName,
+// the resources created by the operator are named after it.
+func (v *webServerValidator) validateApplicationNameIsUnique(ctx context.Context, t *webserversv1alpha1.WebServer) (field.ErrorList, error) {
+	errs := field.ErrorList{}
+	webServers := &webserversv1alpha1.WebServerList{}
+	if err := v.client.List(ctx, webServers, client.InNamespace(t.Namespace)); err != nil {
+		return errs, err
+	}
+	for _, other := range webServers.Items {
+		if other.Name != t.Name && other.Spec.ApplicationName == t.Spec.ApplicationName {
+			errs = append(errs, field.Duplicate(field.NewPath("spec", "applicationName"), t.Spec.ApplicationName+" (used by WebServer "+other.Name+")"))
+		}
+	}
+	return errs, nil
+}
+
+// validateWebServer checks that the WebServer describes an application the operator can deploy on the cluster
+func validateWebServer(t *webserversv1alpha1.WebServer, isOpenShift bool) field.ErrorList {
+	errs := field.ErrorList{}
+	specPath := field.NewPath("spec")
+
+	if t.Spec.WebImage == nil && t.Spec.WebImageStream == nil {
+		errs = append(errs, field.Required(specPath, "one of webImage or webImageStream is required"))
+	}
+	if t.Spec.WebImage != nil && t.Spec.WebImageStream != nil {
+		errs = append(errs, field.Forbidden(specPath.Child("webImageStream"), "webImage and webImageStream are mutually exclusive"))
+	}
+
+	if webImage := t.Spec.WebImage; webImage != nil {
+		webImagePath := specPath.Child("webImage")
+		if webImage.ApplicationImage == "" {
+			errs = append(errs, field.Required(webImagePath.Child("applicationImage"), "the application image is required"))
+		}
+		errs = append(errs, validateHealthCheck(webImage.WebServerHealthCheck, webImagePath.Child("webServerHealthCheck"))...)
+		if webApp := webImage.WebApp; webApp != nil {
+			webAppPath := webImagePath.Child("webApp")
+			if webApp.SourceRepositoryURL == "" {
+				errs = append(errs, field.Required(webAppPath.Child("sourceRepositoryURL"), "the URL of the application sources is required to build the application"))
+			}
+			if webApp.ApplicationSizeLimit != "" {
+				if _, err := resource.ParseQuantity(webApp.ApplicationSizeLimit); err != nil {
+					errs = append(errs, field.Invalid(webAppPath.Child("applicationSizeLimit"), webApp.ApplicationSizeLimit, "must be a quantity like 1Gi: "+err.Error()))
+				}
+			}
+			if webApp.Builder == nil {
+				errs = append(errs, field.Required(webAppPath.Child("builder"), "the builder is required to build the application"))
+			} else {
+				if webApp.Builder.Image == "" {
+					errs = append(errs, field.Required(webAppPath.Child("builder", "image"), "the builder image is required to build the application"))
+				}
+				errs = append(errs, validateResources(webApp.Builder.Resources, webAppPath.Child("builder", "resources"))...)
+			}
+		}
+	}
+
+	if webImageStream := t.Spec.WebImageStream; webImageStream != nil {
+		webImageStreamPath := specPath.Child("webImageStream")
+		if webImageStream.ImageStreamName == "" {
+			errs = append(errs, field.Required(webImageStreamPath.Child("imageStreamName"), "the name of the image stream is required"))
+		}
+		if webImageStream.ImageStreamNamespace == "" {
+			errs = append(errs, field.Required(webImageStreamPath.Child("imageStreamNamespace"), "the namespace of the image stream is required"))
+		}
+		errs = append(errs, validateHealthCheck(webImageStream.WebServerHealthCheck, webImageStreamPath.Child("webServerHealthCheck"))...)
+		if webSources := webImageStream.WebSources; webSources != nil {
+			if webSources.SourceRepositoryURL == "" {
+				errs = append(errs, field.Required(webImageStreamPath.Child("webSources", "sourceRepositoryUrl"), "the URL of the application sources is required to build the application"))
+			}
+			if params := webSources.WebSourcesParams; params != nil {
+				errs = append(errs, validateResources(params.Resources, webImageStreamPath.Child("webSources", "webSourcesParams", "resources"))...)
+			}
+		}
+	}
+
+	errs = append(errs, validateResources(t.Spec.Resources, specPath.Child("resources"))...)
+	errs = append(errs, validateEnv(t.Spec.Env, t.Spec.EnvFrom, specPath)...)
+	errs = append(errs, validateVolumes(t, specPath)...)
+	if jvmHeap := t.Spec.JVMHeap; jvmHeap != nil && jvmHeap.Sizing == "None" && jvmHeap.Percentage != 0 {
+		errs = append(errs, field.Forbidden(specPath.Child("jvmHeap", "percentage"), "the heap isn't sized by the operator when sizing is None"))
+	}
+
+	if t.Spec.CatalinaBase != "" && !strings.HasPrefix(t.Spec.CatalinaBase, "/") {
+		errs = append(errs, field.Invalid(specPath.Child("catalinaBase"), t.Spec.CatalinaBase, "must be an absolute path"))
+	}
+
+	if sessionClustering := t.Spec.SessionClustering; sessionClustering != nil {
+		sessionClusteringPath := specPath.Child("sessionClustering")
+		if sessionClustering.ExpireSessionsOnShutdown && sessionClustering.ManagerType == "BackupManager" {
+			errs = append(errs, field.Forbidden(sessionClusteringPath.Child("expireSessionsOnShutdown"), "the BackupManager keeps the sessions on the backup pod"))
+		}
+		if sessionClustering.ReplicationFilter != "" {
+			if _, err := regexp.Compile(sessionClustering.ReplicationFilter); err != nil {
+				errs = append(errs, field.Invalid(sessionClusteringPath.Child("replicationFilter"), sessionClustering.ReplicationFilter, "must be a regular expression: "+err.Error()))
+			}
+		}
+	}
+
+	if sessionStore := t.Spec.SessionStore; sessionStore != nil {
+		sessionStorePath := specPath.Child("sessionStore")
+		if webserversv1alpha1.IsSessionClusteringEnabled(t) {
+			errs = append(errs, field.Forbidden(sessionStorePath, "the sessions are either replicated or stored, sessionStore and sessionClustering are mutually exclusive"))
+		}
+		if sessionStore.SecretName == "" {
+			errs = append(errs, field.Required(sessionStorePath.Child("secretName"), "the Secret containing the connection details of the store is required"))
+		}
+		if sessionStore.Redis == nil && sessionStore.JDBC == nil {
+			errs = append(errs, field.Required(sessionStorePath, "one of redis or jdbc is required"))
+		}
+		if sessionStore.Redis != nil && sessionStore.JDBC != nil {
+			errs = append(errs, field.Forbidden(sessionStorePath.Child("jdbc"), "redis and jdbc are mutually exclusive"))
+		}
+		if sessionStore.JDBC != nil && sessionStore.JDBC.DriverName == "" {
+			errs = append(errs, field.Required(sessionStorePath.Child("jdbc", "driverName"), "the class name of the JDBC driver is required"))
+		}
+	}
+
+	if ingress := t.Spec.Ingress; ingress != nil && ingress.Path != "" && !strings.HasPrefix(ingress.Path, "/") {
+		errs = append(errs, field.Invalid(specPath.Child("ingress", "path"), ingress.Path, "must be an absolute path"))
+	}
+
+	if route := t.Spec.Route; route != nil && route.TLS != nil {
+		tlsPath := specPath.Child("route", "tls")
+		if (route.TLS.Termination == "passthrough" || route.TLS.Termination == "reencrypt") && t.Spec.TLS == nil {
+			errs = append(errs, field.Required(specPath.Child("tls"), "the HTTPS connector is required by a "+route.TLS.Termination+" route"))
+		}
+		switch route.TLS.Termination {
+		case "passthrough":
+			if route.TLS.CertificateSecretName != "" {
+				errs = append(errs, field.Forbidden(tlsPath.Child("certificateSecretName"), "the certificate of a passthrough route is served by the application"))
+			}
+			if route.TLS.InsecureEdgeTerminationPolicy == "Allow" {
+				errs = append(errs, field.NotSupported(tlsPath.Child("insecureEdgeTerminationPolicy"), route.TLS.InsecureEdgeTerminationPolicy, []string{"None", "Redirect"}))
+			}
+		case "edge":
+			if route.TLS.DestinationCACertificateSecretName != "" {
+				errs = append(errs, field.Forbidden(tlsPath.Child("destinationCACertificateSecretName"), "only a reencrypt route validates the certificate of the application"))
+			}
+		}
+	}
+
+	if tls := t.Spec.TLS; tls != nil {
+		tlsPath := specPath.Child("tls")
+		if keystore := tls.Keystore; keystore != nil {
+			if keystore.Key == "" {
+				errs = append(errs, field.Required(tlsPath.Child("keystore", "key"), "the key of the keystore in the Secret is required"))
+			}
+			if keystore.PasswordSecretKeyRef.Name == "" || keystore.PasswordSecretKeyRef.Key == "" {
+				errs = append(errs, field.Required(tlsPath.Child("keystore", "passwordSecretKeyRef"), "the Secret containing the password of the keystore is required"))
+			}
+			if tls.CertManager != nil {
+				errs = append(errs, field.Forbidden(tlsPath.Child("keystore"), "cert-manager stores the certificate in PEM files"))
+			}
+		}
+		if certManager := tls.CertManager; certManager != nil && certManager.IssuerName == "" {
+			errs = append(errs, field.Required(tlsPath.Child("certManager", "issuerName"), "the name of the cert-manager issuer is required"))
+		}
+	}
+
+	if autoscaling := t.Spec.Autoscaling; autoscaling != nil {
+		autoscalingPath := specPath.Child("autoscaling")
+		if autoscaling.MinReplicas != nil && autoscaling.MaxReplicas < *autoscaling.MinReplicas {
+			errs = append(errs, field.Invalid(autoscalingPath.Child("maxReplicas"), autoscaling.MaxReplicas, "must be greater than or equal to minReplicas"))
+		}
+		for i, metric := range autoscaling.CustomMetrics {
+			if metric.Name == "" {
+				errs = append(errs, field.Required(autoscalingPath.Child("customMetrics").Index(i).Child("name"), "the name of the metric is required"))
+			}
+		}
+	}
+
+	if updateStrategy := t.Spec.UpdateStrategy; updateStrategy != nil {
+		updateStrategyPath := specPath.Child("updateStrategy")
+		if updateStrategy.Type == "RollingUpdate" {
+			maxSurge, err := intOrPercentValue(updateStrategy.MaxSurge)
+			if err != nil {
+				errs = append(errs, field.Invalid(updateStrategyPath.Child("maxSurge"), updateStrategy.MaxSurge.String(), err.Error()))
+			}
+			maxUnavailable, err := intOrPercentValue(updateStrategy.MaxUnavailable)
+			if err != nil {
+				errs = append(errs, field.Invalid(updateStrategyPath.Child("maxUnavailable"), updateStrategy.MaxUnavailable.String(), err.Error()))
+			}
+			if maxSurge == 0 && maxUnavailable == 0 {
+				errs = append(errs, field.Invalid(updateStrategyPath.Child("maxUnavailable"), updateStrategy.MaxUnavailable.String(), "may not be 0 when maxSurge is 0, the rolling update couldn't replace any pod"))
+			}
+		} else {
+			if updateStrategy.MaxSurge != nil {
+				errs = append(errs, field.Forbidden(updateStrategyPath.Child("maxSurge"), "only a RollingUpdate creates pods above the replicas"))
+			}
+			if updateStrategy.MaxUnavailable != nil {
+				errs = append(errs, field.Forbidden(updateStrategyPath.Child("maxUnavailable"), "only a RollingUpdate stops the pods progressively"))
+			}
+			if updateStrategy.MinReadySeconds != 0 {
+				errs = append(errs, field.Forbidden(updateStrategyPath.Child("minReadySeconds"), "only a RollingUpdate waits for the new pods before stopping the old ones"))
+			}
+		}
+		if (updateStrategy.Type == "BlueGreen" || updateStrategy.Type == "Canary") && t.Spec.WebImageStream != nil {
+			errs = append(errs, field.Forbidden(updateStrategyPath.Child("type"), "the DeploymentConfig of an image stream doesn't support blue/green updates and canary releases"))
+		}
+		if updateStrategy.Type == "Canary" && !isOpenShift {
+			errs = append(errs, field.Forbidden(updateStrategyPath.Child("type"), "the requests of a canary release are split by an OpenShift Route, use a BlueGreen update on Kubernetes"))
+		}
+		if updateStrategy.BlueGreen != nil && updateStrategy.Type != "BlueGreen" {
+			errs = append(errs, field.Forbidden(updateStrategyPath.Child("blueGreen"), "only a BlueGreen update verifies the new pods"))
+		}
+		if updateStrategy.AutomaticRollback {
+			if webserversv1alpha1.HasColors(t) {
+				errs = append(errs, field.Forbidden(updateStrategyPath.Child("automaticRollback"), "blue/green updates and canary releases only replace the active pods once the new pods are verified"))
+			}
+			if t.Spec.WebImageStream != nil {
+				errs = append(errs, field.Forbidden(updateStrategyPath.Child("automaticRollback"), "only the Deployment of an application image is rolled back"))
+			}
+		}
+		if canary := updateStrategy.Canary; canary != nil {
+			if updateStrategy.Type != "Canary" {
+				errs = append(errs, field.Forbidden(updateStrategyPath.Child("canary"), "only a Canary release shifts the traffic step by step"))
+			}
+			for i := 1; i < len(canary.Steps); i++ {
+				if canary.Steps[i].Weight <= canary.Steps[i-1].Weight {
+					errs = append(errs, field.Invalid(updateStrategyPath.Child("canary", "steps").Index(i).Child("weight"), canary.Steps[i].Weight, "must be greater than the weight of the previous step"))
+				}
+			}
+		}
+	}
+
+	return errs
+}
+
+// validateHealthCheck checks that the probes of the health check have a single handler and don't conflict with the scripts
+func validateHealthCheck(health *webserversv1alpha1.WebServerHealthCheckSpec, healthPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	if health == nil {
+		return errs
+	}
+	if health.ReadinessProbe != nil && health.ServerReadinessScript != "" {
+		errs = append(errs, field.Forbidden(healthPath.Child("readinessProbe"), "readinessProbe and serverReadinessScript are mutually exclusive"))
+	}
+	if health.LivenessProbe != nil && health.ServerLivenessScript != "" {
+		errs = append(errs, field.Forbidden(healthPath.Child("livenessProbe"), "livenessProbe and serverLivenessScript are mutually exclusive"))
+	}
+	probes := []*webserversv1alpha1.ProbeSpec{health.ReadinessProbe, health.LivenessProbe, health.StartupProbe}
+	for i, name := range []string{"readinessProbe", "livenessProbe", "startupProbe"} {
+		probe := probes[i]
+		if probe == nil {
+			continue
+		}
+		probePath := healthPath.Child(name)
+		handlers := 0
+		if probe.HTTPGet != nil {
+			handlers++
+			errs = append(errs, validateProbePort(probe.HTTPGet.Port, probePath.Child("httpGet", "port"))...)
+		}
+		if probe.TCPSocket != nil {
+			handlers++
+			errs = append(errs, validateProbePort(probe.TCPSocket.Port, probePath.Child("tcpSocket", "port"))...)
+		}
+		if probe.Exec != nil {
+			handlers++
+			if len(probe.Exec.Command) == 0 {
+				errs = append(errs, field.Required(probePath.Child("exec", "command"), "the command of the probe is required"))
+			}
+		}
+		if handlers > 1 {
+			errs = append(errs, field.Forbidden(probePath, "httpGet, tcpSocket and exec are mutually exclusive"))
+		}
+	}
+	return errs
+}
+
+// validateProbePort checks that the port of a probe is a valid port number or name
+func validateProbePort(port *intstr.IntOrString, portPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	if port == nil {
+		return errs
+	}
+	if port.Type == intstr.Int && (port.IntVal < 1 || port.IntVal > 65535) {
+		errs = append(errs, field.Invalid(portPath, port.IntVal, "must be between 1 and 65535"))
+	}
+	if port.Type == intstr.String && port.StrVal == "" {
+		errs = append(errs, field.Required(portPath, "the number or the name of the port is required"))
+	}
+	return errs
+}
+
+// validateEnv checks that the environment variables don't collide with the variables set by the operator or with
+// each other, and that each variable and each envFrom has a single source
+func validateEnv(env []corev1.EnvVar, envFrom []corev1.EnvFromSource, specPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	names := map[string]bool{}
+	for i, envVar := range env {
+		envVarPath := specPath.Child("env").Index(i)
+		switch {
+		case envVar.Name == "":
+			errs = append(errs, field.Required(envVarPath.Child("name"), "the name of the variable is required"))
+		case names[envVar.Name]:
+			errs = append(errs, field.Duplicate(envVarPath.Child("name"), envVar.Name))
+		case webserversv1alpha1.IsReservedEnvVar(envVar.Name):
+			errs = append(errs, field.Forbidden(envVarPath.Child("name"), envVar.Name+" is set by the operator, use catalinaOpts or javaOpts to add options"))
+		}
+		names[envVar.Name] = true
+		if envVar.Value != "" && envVar.ValueFrom != nil {
+			errs = append(errs, field.Forbidden(envVarPath.Child("valueFrom"), "value and valueFrom are mutually exclusive"))
+		}
+		if valueFrom := envVar.ValueFrom; valueFrom != nil {
+			sources := 0
+			for _, set := range []bool{valueFrom.FieldRef != nil, valueFrom.ResourceFieldRef != nil, valueFrom.ConfigMapKeyRef != nil, valueFrom.SecretKeyRef != nil} {
+				if set {
+					sources++
+				}
+			}
+			if sources == 0 {
+				errs = append(errs, field.Required(envVarPath.Child("valueFrom"), "one of fieldRef, resourceFieldRef, configMapKeyRef or secretKeyRef is required"))
+			} else if sources > 1 {
+				errs = append(errs, field.Forbidden(envVarPath.Child("valueFrom"), "fieldRef, resourceFieldRef, configMapKeyRef and secretKeyRef are mutually exclusive"))
+			}
+		}
+	}
+	for i, source := range envFrom {
+		envFromPath := specPath.Child("envFrom").Index(i)
+		if source.ConfigMapRef == nil && source.SecretRef == nil {
+			errs = append(errs, field.Required(envFromPath, "one of configMapRef or secretRef is required"))
+		}
+		if source.ConfigMapRef != nil && source.SecretRef != nil {
+			errs = append(errs, field.Forbidden(envFromPath.Child("secretRef"), "configMapRef and secretRef are mutually exclusive"))
+		}
+	}
+	return errs
+}
+
+// validateVolumes checks that the volumes don't collide with the volumes of the operator or with each other, that
+// the mounts use these volumes and that the containers added to the pods have distinct names
+func validateVolumes(t *webserversv1alpha1.WebServer, specPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	volumes := map[string]bool{}
+	for i, volume := range t.Spec.Volumes {
+		namePath := specPath.Child("volumes").Index(i).Child("name")
+		switch {
+		case volume.Name == "":
+			errs = append(errs, field.Required(namePath, "the name of the volume is required"))
+		case volumes[volume.Name]:
+			errs = append(errs, field.Duplicate(namePath, volume.Name))
+		case webserversv1alpha1.IsReservedVolumeName(volume.Name):
+			errs = append(errs, field.Forbidden(namePath, "app-volume and the names starting with "+webserversv1alpha1.ReservedVolumeNamePrefix+" are used by the volumes of the operator"))
+		}
+		volumes[volume.Name] = true
+	}
+	errs = append(errs, validateVolumeMounts(t.Spec.VolumeMounts, volumes, specPath.Child("volumeMounts"))...)
+
+	containers := map[string]bool{t.Spec.ApplicationName: true}
+	for _, kind := range []struct {
+		name       string
+		containers []corev1.Container
+	}{
+		{"initContainers", t.Spec.InitContainers},
+		{"sidecars", t.Spec.Sidecars},
+	} {
+		for i, container := range kind.containers {
+			containerPath := specPath.Child(kind.name).Index(i)
+			switch {
+			case container.Name == "":
+				errs = append(errs, field.Required(containerPath.Child("name"), "the name of the container is required"))
+			case container.Name == t.Spec.ApplicationName:
+				errs = append(errs, field.Forbidden(containerPath.Child("name"), "the Tomcat container is named after applicationName"))
+			case containers[container.Name]:
+				errs = append(errs, field.Duplicate(containerPath.Child("name"), container.Name))
+			}
+			containers[container.Name] = true
+			if container.Image == "" {
+				errs = append(errs, field.Required(containerPath.Child("image"), "the image of the container is required"))
+			}
+			errs = append(errs, validateVolumeMounts(container.VolumeMounts, volumes, containerPath.Child("volumeMounts"))...)
+		}
+	}
+	return errs
+}
+
+// validateVolumeMounts checks that the mounts of a container use the volumes of the WebServer at absolute paths
+func validateVolumeMounts(mounts []corev1.VolumeMount, volumes map[string]bool, mountsPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	for i, mount := range mounts {
+		mountPath := mountsPath.Index(i)
+		if !volumes[mount.Name] {
+			errs = append(errs, field.NotFound(mountPath.Child("name"), mount.Name))
+		}
+		if !strings.HasPrefix(mount.MountPath, "/") {
+			errs = append(errs, field.Invalid(mountPath.Child("mountPath"), mount.MountPath, "must be an absolute path"))
+		}
+	}
+	return errs
+}
+
+// validateResources checks that the requested resources of a container don't exceed its limits
+func validateResources(resources *corev1.ResourceRequirements, resourcesPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	if resources == nil {
+		return errs
+	}
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourceEphemeralStorage} {
+		request, requested := resources.Requests[name]
+		limit, limited := resources.Limits[name]
+		if requested && limited && request.Cmp(limit) > 0 {
+			errs = append(errs, field.Invalid(resourcesPath.Child("requests").Key(string(name)), request.String(), "must be less than or equal to the "+string(name)+" limit"))
+		}
+	}
+	return errs
+}
+
+// intOrPercentValue returns the value of a number or a percentage of a rolling update, the percentages are returned
+// as is and an unset value as -1
+func intOrPercentValue(value *intstr.IntOrString) (int, error) {
+	if value == nil {
+		return -1, nil
+	}
+	number, err := intstr.GetValueFromIntOrPercent(value, 100, true)
+	if err != nil {
+		return -1, fmt.Errorf("must be a number or a percentage like 25%%")
+	}
+	if number < 0 {
+		return -1, fmt.Errorf("must be greater than or equal to 0")
+	}
+	return number, nil
+}
+
+// validateWebServerUpdate checks that the fields which can't be changed once the application is deployed are unchanged
+func validateWebServerUpdate(t *webserversv1alpha1.WebServer, old *webserversv1alpha1.WebServer) field.ErrorList {
+	errs := field.ErrorList{}
+	specPath := field.NewPath("spec")
+
+	if t.Spec.ApplicationName != old.Spec.ApplicationName {
+		errs = append(errs, field.Forbidden(specPath.Child("applicationName"), "field is immutable, the resources of the application are named after it"))
+	}
+	if oldMethod, method := deploymentMethod(old), deploymentMethod(t); oldMethod != "" && method != "" && oldMethod != method {
+		errs = append(errs, field.Forbidden(specPath.Child(method), "the deployment method can't be changed from "+oldMethod+" to "+method))
+	}
+
+	return errs
+}
+
+// deploymentMethod returns the name of the field describing how the application is deployed,
+// an empty string if it can't be determined
+func deploymentMethod(t *webserversv1alpha1.WebServer) string {
+	if t.Spec.WebImage != nil && t.Spec.WebImageStream == nil {
+		return "webImage"
+	}
+	if t.Spec.WebImageStream != nil && t.Spec.WebImage == nil {
+		return "webImageStream"
+	}
+	return ""
+}
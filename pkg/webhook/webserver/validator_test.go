@@ -0,0 +1,938 @@
+package webserver
+
+import (
+	"context"
+	"testing"
+
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// validWebServer returns a WebServer deploying an application image accepted by the validator
+func validWebServer() *webserversv1alpha1.WebServer {
+	return &webserversv1alpha1.WebServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "test"},
+		Spec: webserversv1alpha1.WebServerSpec{
+			ApplicationName: "example",
+			Replicas:        2,
+			WebImage:        &webserversv1alpha1.WebImageSpec{ApplicationImage: "quay.io/example/tomcat:1.0"},
+		},
+	}
+}
+
+// useImageStream replaces the application image of the WebServer by an image stream
+func useImageStream(t *webserversv1alpha1.WebServer) {
+	t.Spec.WebImage = nil
+	t.Spec.WebImageStream = &webserversv1alpha1.WebImageStreamSpec{ImageStreamName: "tomcat", ImageStreamNamespace: "openshift"}
+}
+
+func intOrString(value intstr.IntOrString) *intstr.IntOrString {
+	return &value
+}
+
+func int32Ptr(value int32) *int32 {
+	return &value
+}
+
+// limits returns resources requesting and limiting the memory
+func limits(request string, limit string) *corev1.ResourceRequirements {
+	return &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(request)},
+		Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(limit)},
+	}
+}
+
+// hasErrorOn returns whether one of the errors is about the field
+func hasErrorOn(errs field.ErrorList, path string) bool {
+	for _, err := range errs {
+		if err.Field == path {
+			return true
+		}
+	}
+	return false
+}
+
+func TestValidateWebServer(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*webserversv1alpha1.WebServer)
+		// field is the path of the rejected field, an empty path when the WebServer is accepted
+		field string
+	}{
+		{name: "application image", modify: func(t *webserversv1alpha1.WebServer) {}},
+		{name: "image stream", modify: useImageStream},
+		{
+			name:   "no image",
+			modify: func(t *webserversv1alpha1.WebServer) { t.Spec.WebImage = nil },
+			field:  "spec",
+		},
+		{
+			name: "image and image stream",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.WebImageStream = &webserversv1alpha1.WebImageStreamSpec{ImageStreamName: "tomcat", ImageStreamNamespace: "openshift"}
+			},
+			field: "spec.webImageStream",
+		},
+		{
+			name:   "no application image",
+			modify: func(t *webserversv1alpha1.WebServer) { t.Spec.WebImage.ApplicationImage = "" },
+			field:  "spec.webImage.applicationImage",
+		},
+
+		// Health checks
+		{
+			name: "readiness probe",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.WebImage.WebServerHealthCheck = &webserversv1alpha1.WebServerHealthCheckSpec{
+					ReadinessProbe: &webserversv1alpha1.ProbeSpec{HTTPGet: &webserversv1alpha1.HTTPGetProbeSpec{Port: intOrString(intstr.FromString("http"))}},
+				}
+			},
+		},
+		{
+			name: "readiness probe and script",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.WebImage.WebServerHealthCheck = &webserversv1alpha1.WebServerHealthCheckSpec{
+					ServerReadinessScript: "curl localhost:8080/health",
+					ReadinessProbe:        &webserversv1alpha1.ProbeSpec{},
+				}
+			},
+			field: "spec.webImage.webServerHealthCheck.readinessProbe",
+		},
+		{
+			name: "liveness script",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.WebImage.WebServerHealthCheck = &webserversv1alpha1.WebServerHealthCheckSpec{ServerLivenessScript: "curl localhost:8080/health"}
+			},
+		},
+		{
+			name: "liveness probe and script",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.WebImage.WebServerHealthCheck = &webserversv1alpha1.WebServerHealthCheckSpec{
+					ServerLivenessScript: "curl localhost:8080/health",
+					LivenessProbe:        &webserversv1alpha1.ProbeSpec{},
+				}
+			},
+			field: "spec.webImage.webServerHealthCheck.livenessProbe",
+		},
+		{
+			name: "probe with two handlers",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.WebImage.WebServerHealthCheck = &webserversv1alpha1.WebServerHealthCheckSpec{
+					StartupProbe: &webserversv1alpha1.ProbeSpec{
+						HTTPGet:   &webserversv1alpha1.HTTPGetProbeSpec{},
+						TCPSocket: &webserversv1alpha1.TCPSocketProbeSpec{},
+					},
+				}
+			},
+			field: "spec.webImage.webServerHealthCheck.startupProbe",
+		},
+		{
+			name: "probe port out of range",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.WebImage.WebServerHealthCheck = &webserversv1alpha1.WebServerHealthCheckSpec{
+					LivenessProbe: &webserversv1alpha1.ProbeSpec{TCPSocket: &webserversv1alpha1.TCPSocketProbeSpec{Port: intOrString(intstr.FromInt(70000))}},
+				}
+			},
+			field: "spec.webImage.webServerHealthCheck.livenessProbe.tcpSocket.port",
+		},
+		{
+			name: "empty probe port name",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.WebImage.WebServerHealthCheck = &webserversv1alpha1.WebServerHealthCheckSpec{
+					ReadinessProbe: &webserversv1alpha1.ProbeSpec{HTTPGet: &webserversv1alpha1.HTTPGetProbeSpec{Port: intOrString(intstr.FromString(""))}},
+				}
+			},
+			field: "spec.webImage.webServerHealthCheck.readinessProbe.httpGet.port",
+		},
+		{
+			name: "exec probe",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.WebImage.WebServerHealthCheck = &webserversv1alpha1.WebServerHealthCheckSpec{
+					LivenessProbe: &webserversv1alpha1.ProbeSpec{Exec: &webserversv1alpha1.ExecProbeSpec{Command: []string{"/health.sh"}}},
+				}
+			},
+		},
+		{
+			name: "exec probe without command",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.WebImage.WebServerHealthCheck = &webserversv1alpha1.WebServerHealthCheckSpec{
+					LivenessProbe: &webserversv1alpha1.ProbeSpec{Exec: &webserversv1alpha1.ExecProbeSpec{}},
+				}
+			},
+			field: "spec.webImage.webServerHealthCheck.livenessProbe.exec.command",
+		},
+		{
+			name: "image stream probe with two handlers",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				useImageStream(t)
+				t.Spec.WebImageStream.WebServerHealthCheck = &webserversv1alpha1.WebServerHealthCheckSpec{
+					ReadinessProbe: &webserversv1alpha1.ProbeSpec{
+						HTTPGet: &webserversv1alpha1.HTTPGetProbeSpec{},
+						Exec:    &webserversv1alpha1.ExecProbeSpec{Command: []string{"/health.sh"}},
+					},
+				}
+			},
+			field: "spec.webImageStream.webServerHealthCheck.readinessProbe",
+		},
+
+		// Application built from sources
+		{
+			name: "web app",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.WebImage.WebApp = &webserversv1alpha1.WebAppSpec{
+					SourceRepositoryURL:  "https://github.com/example/app.git",
+					ApplicationSizeLimit: "1Gi",
+					Builder: &webserversv1alpha1.BuilderSpec{
+						Image:     "quay.io/example/builder:1.0",
+						Resources: limits("512Mi", "1Gi"),
+					},
+				}
+			},
+		},
+		{
+			name: "web app without sources",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.WebImage.WebApp = &webserversv1alpha1.WebAppSpec{Builder: &webserversv1alpha1.BuilderSpec{Image: "quay.io/example/builder:1.0"}}
+			},
+			field: "spec.webImage.webApp.sourceRepositoryURL",
+		},
+		{
+			name: "invalid application size limit",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.WebImage.WebApp = &webserversv1alpha1.WebAppSpec{
+					SourceRepositoryURL:  "https://github.com/example/app.git",
+					ApplicationSizeLimit: "1 gigabyte",
+					Builder:              &webserversv1alpha1.BuilderSpec{Image: "quay.io/example/builder:1.0"},
+				}
+			},
+			field: "spec.webImage.webApp.applicationSizeLimit",
+		},
+		{
+			name: "web app without builder",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.WebImage.WebApp = &webserversv1alpha1.WebAppSpec{SourceRepositoryURL: "https://github.com/example/app.git"}
+			},
+			field: "spec.webImage.webApp.builder",
+		},
+		{
+			name: "builder without image",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.WebImage.WebApp = &webserversv1alpha1.WebAppSpec{
+					SourceRepositoryURL: "https://github.com/example/app.git",
+					Builder:             &webserversv1alpha1.BuilderSpec{},
+				}
+			},
+			field: "spec.webImage.webApp.builder.image",
+		},
+		{
+			name: "builder requests above limits",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.WebImage.WebApp = &webserversv1alpha1.WebAppSpec{
+					SourceRepositoryURL: "https://github.com/example/app.git",
+					Builder:             &webserversv1alpha1.BuilderSpec{Image: "quay.io/example/builder:1.0", Resources: limits("2Gi", "1Gi")},
+				}
+			},
+			field: "spec.webImage.webApp.builder.resources.requests[memory]",
+		},
+		{
+			name: "image stream without name",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				useImageStream(t)
+				t.Spec.WebImageStream.ImageStreamName = ""
+			},
+			field: "spec.webImageStream.imageStreamName",
+		},
+		{
+			name: "image stream without namespace",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				useImageStream(t)
+				t.Spec.WebImageStream.ImageStreamNamespace = ""
+			},
+			field: "spec.webImageStream.imageStreamNamespace",
+		},
+		{
+			name: "web sources",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				useImageStream(t)
+				t.Spec.WebImageStream.WebSources = &webserversv1alpha1.WebSourcesSpec{
+					SourceRepositoryURL: "https://github.com/example/app.git",
+					WebSourcesParams:    &webserversv1alpha1.WebSourcesParamsSpec{Resources: limits("1Gi", "1Gi")},
+				}
+			},
+		},
+		{
+			name: "web sources without URL",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				useImageStream(t)
+				t.Spec.WebImageStream.WebSources = &webserversv1alpha1.WebSourcesSpec{}
+			},
+			field: "spec.webImageStream.webSources.sourceRepositoryUrl",
+		},
+		{
+			name: "web sources requests above limits",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				useImageStream(t)
+				t.Spec.WebImageStream.WebSources = &webserversv1alpha1.WebSourcesSpec{
+					SourceRepositoryURL: "https://github.com/example/app.git",
+					WebSourcesParams:    &webserversv1alpha1.WebSourcesParamsSpec{Resources: limits("2Gi", "1Gi")},
+				}
+			},
+			field: "spec.webImageStream.webSources.webSourcesParams.resources.requests[memory]",
+		},
+
+		// Pods
+		{
+			name:   "resources",
+			modify: func(t *webserversv1alpha1.WebServer) { t.Spec.Resources = limits("512Mi", "1Gi") },
+		},
+		{
+			name:   "requests above limits",
+			modify: func(t *webserversv1alpha1.WebServer) { t.Spec.Resources = limits("2Gi", "1Gi") },
+			field:  "spec.resources.requests[memory]",
+		},
+		{
+			name: "heap not sized",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.JVMHeap = &webserversv1alpha1.JVMHeapSpec{Sizing: "None"}
+			},
+		},
+		{
+			name: "heap percentage not sized",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.JVMHeap = &webserversv1alpha1.JVMHeapSpec{Sizing: "None", Percentage: 50}
+			},
+			field: "spec.jvmHeap.percentage",
+		},
+		{
+			name:   "catalina base",
+			modify: func(t *webserversv1alpha1.WebServer) { t.Spec.CatalinaBase = "/opt/tomcat" },
+		},
+		{
+			name:   "relative catalina base",
+			modify: func(t *webserversv1alpha1.WebServer) { t.Spec.CatalinaBase = "opt/tomcat" },
+			field:  "spec.catalinaBase",
+		},
+
+		// Environment
+		{
+			name: "env",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.Env = []corev1.EnvVar{
+					{Name: "MODE", Value: "production"},
+					{Name: "POD_NAME", ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"}}},
+				}
+				t.Spec.EnvFrom = []corev1.EnvFromSource{{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "credentials"}}}}
+			},
+		},
+		{
+			name:   "variable without name",
+			modify: func(t *webserversv1alpha1.WebServer) { t.Spec.Env = []corev1.EnvVar{{Value: "production"}} },
+			field:  "spec.env[0].name",
+		},
+		{
+			name: "duplicate variable",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.Env = []corev1.EnvVar{{Name: "MODE", Value: "production"}, {Name: "MODE", Value: "test"}}
+			},
+			field: "spec.env[1].name",
+		},
+		{
+			name: "reserved variable",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.Env = []corev1.EnvVar{{Name: "JAVA_OPTS", Value: "-Xmx1g"}}
+			},
+			field: "spec.env[0].name",
+		},
+		{
+			name: "value and valueFrom",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.Env = []corev1.EnvVar{{
+					Name:      "POD_NAME",
+					Value:     "example",
+					ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"}},
+				}}
+			},
+			field: "spec.env[0].valueFrom",
+		},
+		{
+			name: "valueFrom without source",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.Env = []corev1.EnvVar{{Name: "POD_NAME", ValueFrom: &corev1.EnvVarSource{}}}
+			},
+			field: "spec.env[0].valueFrom",
+		},
+		{
+			name: "valueFrom with two sources",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.Env = []corev1.EnvVar{{Name: "POD_NAME", ValueFrom: &corev1.EnvVarSource{
+					FieldRef:        &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
+					ConfigMapKeyRef: &corev1.ConfigMapKeySelector{Key: "name"},
+				}}}
+			},
+			field: "spec.env[0].valueFrom",
+		},
+		{
+			name:   "envFrom without source",
+			modify: func(t *webserversv1alpha1.WebServer) { t.Spec.EnvFrom = []corev1.EnvFromSource{{Prefix: "APP_"}} },
+			field:  "spec.envFrom[0]",
+		},
+		{
+			name: "envFrom with two sources",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.EnvFrom = []corev1.EnvFromSource{{
+					ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "config"}},
+					SecretRef:    &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "credentials"}},
+				}}
+			},
+			field: "spec.envFrom[0].secretRef",
+		},
+
+		// Volumes and containers
+		{
+			name: "volumes and containers",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.Volumes = []corev1.Volume{{Name: "libs"}, {Name: "logs"}}
+				t.Spec.VolumeMounts = []corev1.VolumeMount{{Name: "libs", MountPath: "/opt/jws-5.4/tomcat/lib/ext"}}
+				t.Spec.InitContainers = []corev1.Container{{Name: "fetch-libs", Image: "quay.io/example/fetch:1.0", VolumeMounts: []corev1.VolumeMount{{Name: "libs", MountPath: "/libs"}}}}
+				t.Spec.Sidecars = []corev1.Container{{Name: "log-shipper", Image: "quay.io/example/shipper:1.0", VolumeMounts: []corev1.VolumeMount{{Name: "logs", MountPath: "/logs"}}}}
+			},
+		},
+		{
+			name:   "volume without name",
+			modify: func(t *webserversv1alpha1.WebServer) { t.Spec.Volumes = []corev1.Volume{{}} },
+			field:  "spec.volumes[0].name",
+		},
+		{
+			name: "duplicate volume",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.Volumes = []corev1.Volume{{Name: "libs"}, {Name: "libs"}}
+			},
+			field: "spec.volumes[1].name",
+		},
+		{
+			name:   "reserved volume",
+			modify: func(t *webserversv1alpha1.WebServer) { t.Spec.Volumes = []corev1.Volume{{Name: "app-volume"}} },
+			field:  "spec.volumes[0].name",
+		},
+		{
+			name:   "reserved volume prefix",
+			modify: func(t *webserversv1alpha1.WebServer) { t.Spec.Volumes = []corev1.Volume{{Name: "webserver-libs"}} },
+			field:  "spec.volumes[0].name",
+		},
+		{
+			name: "mount of an unknown volume",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.VolumeMounts = []corev1.VolumeMount{{Name: "libs", MountPath: "/opt/jws-5.4/tomcat/lib/ext"}}
+			},
+			field: "spec.volumeMounts[0].name",
+		},
+		{
+			name: "relative mount path",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.Volumes = []corev1.Volume{{Name: "libs"}}
+				t.Spec.VolumeMounts = []corev1.VolumeMount{{Name: "libs", MountPath: "lib/ext"}}
+			},
+			field: "spec.volumeMounts[0].mountPath",
+		},
+		{
+			name: "container without name",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.Sidecars = []corev1.Container{{Image: "quay.io/example/shipper:1.0"}}
+			},
+			field: "spec.sidecars[0].name",
+		},
+		{
+			name: "container named after the application",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.InitContainers = []corev1.Container{{Name: "example", Image: "quay.io/example/fetch:1.0"}}
+			},
+			field: "spec.initContainers[0].name",
+		},
+		{
+			name: "duplicate container",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.InitContainers = []corev1.Container{{Name: "helper", Image: "quay.io/example/fetch:1.0"}}
+				t.Spec.Sidecars = []corev1.Container{{Name: "helper", Image: "quay.io/example/shipper:1.0"}}
+			},
+			field: "spec.sidecars[0].name",
+		},
+		{
+			name:   "container without image",
+			modify: func(t *webserversv1alpha1.WebServer) { t.Spec.Sidecars = []corev1.Container{{Name: "log-shipper"}} },
+			field:  "spec.sidecars[0].image",
+		},
+		{
+			name: "container mount of an unknown volume",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.Sidecars = []corev1.Container{{Name: "log-shipper", Image: "quay.io/example/shipper:1.0", VolumeMounts: []corev1.VolumeMount{{Name: "logs", MountPath: "/logs"}}}}
+			},
+			field: "spec.sidecars[0].volumeMounts[0].name",
+		},
+
+		// Sessions
+		{
+			name: "session clustering",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.SessionClustering = &webserversv1alpha1.SessionClusteringSpec{ManagerType: "BackupManager", ReplicationFilter: ".*\\.gif"}
+			},
+		},
+		{
+			name: "expired sessions with the BackupManager",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.SessionClustering = &webserversv1alpha1.SessionClusteringSpec{ManagerType: "BackupManager", ExpireSessionsOnShutdown: true}
+			},
+			field: "spec.sessionClustering.expireSessionsOnShutdown",
+		},
+		{
+			name: "invalid replication filter",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.SessionClustering = &webserversv1alpha1.SessionClusteringSpec{ReplicationFilter: "(.*"}
+			},
+			field: "spec.sessionClustering.replicationFilter",
+		},
+		{
+			name: "redis session store",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.SessionStore = &webserversv1alpha1.SessionStoreSpec{SecretName: "redis", Redis: &webserversv1alpha1.RedisSessionStoreSpec{}}
+			},
+		},
+		{
+			name: "session store and clustering",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.UseSessionClustering = true
+				t.Spec.SessionStore = &webserversv1alpha1.SessionStoreSpec{SecretName: "redis", Redis: &webserversv1alpha1.RedisSessionStoreSpec{}}
+			},
+			field: "spec.sessionStore",
+		},
+		{
+			name: "session store without Secret",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.SessionStore = &webserversv1alpha1.SessionStoreSpec{Redis: &webserversv1alpha1.RedisSessionStoreSpec{}}
+			},
+			field: "spec.sessionStore.secretName",
+		},
+		{
+			name: "session store without store",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.SessionStore = &webserversv1alpha1.SessionStoreSpec{SecretName: "redis"}
+			},
+			field: "spec.sessionStore",
+		},
+		{
+			name: "redis and jdbc session stores",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.SessionStore = &webserversv1alpha1.SessionStoreSpec{
+					SecretName: "store",
+					Redis:      &webserversv1alpha1.RedisSessionStoreSpec{},
+					JDBC:       &webserversv1alpha1.JDBCSessionStoreSpec{DriverName: "org.postgresql.Driver"},
+				}
+			},
+			field: "spec.sessionStore.jdbc",
+		},
+		{
+			name: "jdbc session store without driver",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.SessionStore = &webserversv1alpha1.SessionStoreSpec{SecretName: "database", JDBC: &webserversv1alpha1.JDBCSessionStoreSpec{}}
+			},
+			field: "spec.sessionStore.jdbc.driverName",
+		},
+
+		// Exposure
+		{
+			name: "ingress",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.Ingress = &webserversv1alpha1.IngressSpec{Path: "/example"}
+			},
+		},
+		{
+			name: "relative ingress path",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.Ingress = &webserversv1alpha1.IngressSpec{Path: "example"}
+			},
+			field: "spec.ingress.path",
+		},
+		{
+			name: "edge route",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.Route = &webserversv1alpha1.RouteSpec{TLS: &webserversv1alpha1.RouteTLSSpec{Termination: "edge", InsecureEdgeTerminationPolicy: "Allow"}}
+			},
+		},
+		{
+			name: "edge route with destination CA",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.Route = &webserversv1alpha1.RouteSpec{TLS: &webserversv1alpha1.RouteTLSSpec{Termination: "edge", DestinationCACertificateSecretName: "ca"}}
+			},
+			field: "spec.route.tls.destinationCACertificateSecretName",
+		},
+		{
+			name: "passthrough route",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.TLS = &webserversv1alpha1.TLSSpec{}
+				t.Spec.Route = &webserversv1alpha1.RouteSpec{TLS: &webserversv1alpha1.RouteTLSSpec{Termination: "passthrough"}}
+			},
+		},
+		{
+			name: "passthrough route without HTTPS connector",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.Route = &webserversv1alpha1.RouteSpec{TLS: &webserversv1alpha1.RouteTLSSpec{Termination: "passthrough"}}
+			},
+			field: "spec.tls",
+		},
+		{
+			name: "reencrypt route",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.TLS = &webserversv1alpha1.TLSSpec{}
+				t.Spec.Route = &webserversv1alpha1.RouteSpec{TLS: &webserversv1alpha1.RouteTLSSpec{Termination: "reencrypt", DestinationCACertificateSecretName: "ca"}}
+			},
+		},
+		{
+			name: "reencrypt route without HTTPS connector",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.Route = &webserversv1alpha1.RouteSpec{TLS: &webserversv1alpha1.RouteTLSSpec{Termination: "reencrypt"}}
+			},
+			field: "spec.tls",
+		},
+		{
+			name: "passthrough route with certificate",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.TLS = &webserversv1alpha1.TLSSpec{}
+				t.Spec.Route = &webserversv1alpha1.RouteSpec{TLS: &webserversv1alpha1.RouteTLSSpec{Termination: "passthrough", CertificateSecretName: "route-tls"}}
+			},
+			field: "spec.route.tls.certificateSecretName",
+		},
+		{
+			name: "passthrough route allowing HTTP",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.TLS = &webserversv1alpha1.TLSSpec{}
+				t.Spec.Route = &webserversv1alpha1.RouteSpec{TLS: &webserversv1alpha1.RouteTLSSpec{Termination: "passthrough", InsecureEdgeTerminationPolicy: "Allow"}}
+			},
+			field: "spec.route.tls.insecureEdgeTerminationPolicy",
+		},
+
+		// HTTPS connector
+		{
+			name: "keystore",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.TLS = &webserversv1alpha1.TLSSpec{Keystore: &webserversv1alpha1.KeystoreSpec{
+					Key:                  "keystore.p12",
+					PasswordSecretKeyRef: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "keystore"}, Key: "password"},
+				}}
+			},
+		},
+		{
+			name: "keystore without key",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.TLS = &webserversv1alpha1.TLSSpec{Keystore: &webserversv1alpha1.KeystoreSpec{
+					PasswordSecretKeyRef: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "keystore"}, Key: "password"},
+				}}
+			},
+			field: "spec.tls.keystore.key",
+		},
+		{
+			name: "keystore without password",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.TLS = &webserversv1alpha1.TLSSpec{Keystore: &webserversv1alpha1.KeystoreSpec{Key: "keystore.p12"}}
+			},
+			field: "spec.tls.keystore.passwordSecretKeyRef",
+		},
+		{
+			name: "keystore and cert-manager",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.TLS = &webserversv1alpha1.TLSSpec{
+					Keystore: &webserversv1alpha1.KeystoreSpec{
+						Key:                  "keystore.p12",
+						PasswordSecretKeyRef: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "keystore"}, Key: "password"},
+					},
+					CertManager: &webserversv1alpha1.CertManagerSpec{IssuerName: "letsencrypt"},
+				}
+			},
+			field: "spec.tls.keystore",
+		},
+		{
+			name: "cert-manager",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.TLS = &webserversv1alpha1.TLSSpec{CertManager: &webserversv1alpha1.CertManagerSpec{IssuerName: "letsencrypt"}}
+			},
+		},
+		{
+			name: "cert-manager without issuer",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.TLS = &webserversv1alpha1.TLSSpec{CertManager: &webserversv1alpha1.CertManagerSpec{}}
+			},
+			field: "spec.tls.certManager.issuerName",
+		},
+
+		// Autoscaling
+		{
+			name: "autoscaling",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.Autoscaling = &webserversv1alpha1.AutoscalingSpec{
+					MinReplicas:   int32Ptr(2),
+					MaxReplicas:   5,
+					CustomMetrics: []webserversv1alpha1.CustomMetricSpec{{Name: "requests_per_second", TargetAverageValue: resource.MustParse("100")}},
+				}
+			},
+		},
+		{
+			name: "max replicas below min replicas",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.Autoscaling = &webserversv1alpha1.AutoscalingSpec{MinReplicas: int32Ptr(3), MaxReplicas: 2}
+			},
+			field: "spec.autoscaling.maxReplicas",
+		},
+		{
+			name: "custom metric without name",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.Autoscaling = &webserversv1alpha1.AutoscalingSpec{MaxReplicas: 5, CustomMetrics: []webserversv1alpha1.CustomMetricSpec{{}}}
+			},
+			field: "spec.autoscaling.customMetrics[0].name",
+		},
+
+		// Update strategy
+		{
+			name: "rolling update",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.UpdateStrategy = &webserversv1alpha1.UpdateStrategySpec{
+					Type:            "RollingUpdate",
+					MaxSurge:        intOrString(intstr.FromString("25%")),
+					MaxUnavailable:  intOrString(intstr.FromInt(0)),
+					MinReadySeconds: 10,
+				}
+			},
+		},
+		{
+			name: "invalid max surge",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.UpdateStrategy = &webserversv1alpha1.UpdateStrategySpec{Type: "RollingUpdate", MaxSurge: intOrString(intstr.FromString("many"))}
+			},
+			field: "spec.updateStrategy.maxSurge",
+		},
+		{
+			name: "negative max unavailable",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.UpdateStrategy = &webserversv1alpha1.UpdateStrategySpec{Type: "RollingUpdate", MaxUnavailable: intOrString(intstr.FromInt(-1))}
+			},
+			field: "spec.updateStrategy.maxUnavailable",
+		},
+		{
+			name: "rolling update replacing no pod",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.UpdateStrategy = &webserversv1alpha1.UpdateStrategySpec{
+					Type:           "RollingUpdate",
+					MaxSurge:       intOrString(intstr.FromInt(0)),
+					MaxUnavailable: intOrString(intstr.FromString("0%")),
+				}
+			},
+			field: "spec.updateStrategy.maxUnavailable",
+		},
+		{
+			name: "recreate with max surge",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.UpdateStrategy = &webserversv1alpha1.UpdateStrategySpec{Type: "Recreate", MaxSurge: intOrString(intstr.FromInt(1))}
+			},
+			field: "spec.updateStrategy.maxSurge",
+		},
+		{
+			name: "recreate with max unavailable",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.UpdateStrategy = &webserversv1alpha1.UpdateStrategySpec{Type: "Recreate", MaxUnavailable: intOrString(intstr.FromInt(1))}
+			},
+			field: "spec.updateStrategy.maxUnavailable",
+		},
+		{
+			name: "recreate with min ready seconds",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.UpdateStrategy = &webserversv1alpha1.UpdateStrategySpec{Type: "Recreate", MinReadySeconds: 10}
+			},
+			field: "spec.updateStrategy.minReadySeconds",
+		},
+		{
+			name: "blue/green update",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.UpdateStrategy = &webserversv1alpha1.UpdateStrategySpec{
+					Type:      "BlueGreen",
+					BlueGreen: &webserversv1alpha1.BlueGreenSpec{SmokeTests: []webserversv1alpha1.SmokeTestSpec{{Path: "/health", ExpectedStatus: 200}}},
+				}
+			},
+		},
+		{
+			name: "blue/green update of an image stream",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				useImageStream(t)
+				t.Spec.UpdateStrategy = &webserversv1alpha1.UpdateStrategySpec{Type: "BlueGreen"}
+			},
+			field: "spec.updateStrategy.type",
+		},
+		{
+			name: "blue/green parameters of a rolling update",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.UpdateStrategy = &webserversv1alpha1.UpdateStrategySpec{Type: "RollingUpdate", BlueGreen: &webserversv1alpha1.BlueGreenSpec{}}
+			},
+			field: "spec.updateStrategy.blueGreen",
+		},
+		{
+			name: "automatic rollback",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.UpdateStrategy = &webserversv1alpha1.UpdateStrategySpec{Type: "Recreate", AutomaticRollback: true}
+			},
+		},
+		{
+			name: "automatic rollback of a blue/green update",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.UpdateStrategy = &webserversv1alpha1.UpdateStrategySpec{Type: "BlueGreen", AutomaticRollback: true}
+			},
+			field: "spec.updateStrategy.automaticRollback",
+		},
+		{
+			name: "automatic rollback of an image stream",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				useImageStream(t)
+				t.Spec.UpdateStrategy = &webserversv1alpha1.UpdateStrategySpec{Type: "Recreate", AutomaticRollback: true}
+			},
+			field: "spec.updateStrategy.automaticRollback",
+		},
+		{
+			name: "canary release",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.UpdateStrategy = &webserversv1alpha1.UpdateStrategySpec{
+					Type:   "Canary",
+					Canary: &webserversv1alpha1.CanarySpec{Steps: []webserversv1alpha1.CanaryStepSpec{{Weight: 20}, {Weight: 100}}},
+				}
+			},
+		},
+		{
+			name: "canary parameters of a blue/green update",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.UpdateStrategy = &webserversv1alpha1.UpdateStrategySpec{Type: "BlueGreen", Canary: &webserversv1alpha1.CanarySpec{}}
+			},
+			field: "spec.updateStrategy.canary",
+		},
+		{
+			name: "decreasing canary weights",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.UpdateStrategy = &webserversv1alpha1.UpdateStrategySpec{
+					Type:   "Canary",
+					Canary: &webserversv1alpha1.CanarySpec{Steps: []webserversv1alpha1.CanaryStepSpec{{Weight: 50}, {Weight: 20}}},
+				}
+			},
+			field: "spec.updateStrategy.canary.steps[1].weight",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			webServer := validWebServer()
+			test.modify(webServer)
+			errs := validateWebServer(webServer, true)
+			if test.field == "" && len(errs) > 0 {
+				t.Errorf("the WebServer was rejected: %v", errs.ToAggregate())
+			}
+			if test.field != "" && !hasErrorOn(errs, test.field) {
+				t.Errorf("got %v, expected an error on %s", errs.ToAggregate(), test.field)
+			}
+		})
+	}
+}
+
+func TestValidateCanaryOnKubernetes(t *testing.T) {
+	webServer := validWebServer()
+	webServer.Spec.UpdateStrategy = &webserversv1alpha1.UpdateStrategySpec{Type: "BlueGreen"}
+	if errs := validateWebServer(webServer, false); len(errs) > 0 {
+		t.Errorf("the blue/green update was rejected: %v", errs.ToAggregate())
+	}
+	webServer.Spec.UpdateStrategy.Type = "Canary"
+	if errs := validateWebServer(webServer, false); !hasErrorOn(errs, "spec.updateStrategy.type") {
+		t.Errorf("got %v, expected the canary release to be rejected", errs.ToAggregate())
+	}
+}
+
+func TestValidateWebServerUpdate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*webserversv1alpha1.WebServer)
+		// field is the path of the rejected field, an empty path when the update is accepted
+		field string
+	}{
+		{
+			name:   "replicas",
+			modify: func(t *webserversv1alpha1.WebServer) { t.Spec.Replicas = 5 },
+		},
+		{
+			name:   "application name",
+			modify: func(t *webserversv1alpha1.WebServer) { t.Spec.ApplicationName = "other" },
+			field:  "spec.applicationName",
+		},
+		{
+			name:   "application image",
+			modify: func(t *webserversv1alpha1.WebServer) { t.Spec.WebImage.ApplicationImage = "quay.io/example/tomcat:2.0" },
+		},
+		{
+			name:   "deployment method",
+			modify: useImageStream,
+			field:  "spec.webImageStream",
+		},
+		{
+			name: "rolling update",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.UpdateStrategy = &webserversv1alpha1.UpdateStrategySpec{Type: "RollingUpdate"}
+			},
+		},
+		{
+			name: "blue/green update",
+			modify: func(t *webserversv1alpha1.WebServer) {
+				t.Spec.UpdateStrategy = &webserversv1alpha1.UpdateStrategySpec{Type: "BlueGreen"}
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			old := validWebServer()
+			webServer := old.DeepCopy()
+			test.modify(webServer)
+			errs := validateWebServerUpdate(webServer, old)
+			if test.field == "" && len(errs) > 0 {
+				t.Errorf("the update was rejected: %v", errs.ToAggregate())
+			}
+			if test.field != "" && !hasErrorOn(errs, test.field) {
+				t.Errorf("got %v, expected an error on %s", errs.ToAggregate(), test.field)
+			}
+		})
+	}
+}
+
+func TestValidateApplicationNameIsUnique(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := webserversv1alpha1.SchemeBuilder.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	other := validWebServer()
+	other.Name = "other"
+	otherNamespace := validWebServer()
+	otherNamespace.Name = "other"
+	otherNamespace.Namespace = "production"
+	validator := &webServerValidator{client: fake.NewFakeClientWithScheme(scheme, other, otherNamespace)}
+
+	webServer := validWebServer()
+	errs, err := validator.validateApplicationNameIsUnique(context.TODO(), webServer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasErrorOn(errs, "spec.applicationName") {
+		t.Errorf("got %v, expected the applicationName used by WebServer other to be rejected", errs.ToAggregate())
+	}
+
+	webServer.Spec.ApplicationName = "unique"
+	errs, err = validator.validateApplicationNameIsUnique(context.TODO(), webServer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) > 0 {
+		t.Errorf("the unique applicationName was rejected: %v", errs.ToAggregate())
+	}
+}
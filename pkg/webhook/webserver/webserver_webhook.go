@@ -0,0 +1,35 @@
+package webserver
+
+import (
+	routev1 "github.com/openshift/api/route/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
+)
+
+var log = logf.Log.WithName("webhook_webserver")
+
+const (
+	// ValidatingWebhookPath is the path on which the validating webhook for WebServer is served
+	ValidatingWebhookPath = "/validate-web-servers-org-v1alpha1-webserver"
+	// MutatingWebhookPath is the path on which the defaulting webhook for WebServer is served
+	MutatingWebhookPath = "/mutate-web-servers-org-v1alpha1-webserver"
+	// ConversionWebhookPath is the path on which the WebServers are converted between API versions
+	ConversionWebhookPath = "/convert"
+)
+
+// Add registers the WebServer webhooks in the webhook server of the Manager
+func Add(mgr manager.Manager) error {
+	server := mgr.GetWebhookServer()
+	// The canary releases are only validated on OpenShift, where the Routes split the requests
+	_, err := mgr.GetRESTMapper().RESTMapping(schema.GroupKind{Group: routev1.GroupName, Kind: "Route"})
+	isOpenShift := err == nil
+	server.Register(ValidatingWebhookPath, &webhook.Admission{Handler: &webServerValidator{client: mgr.GetClient(), isOpenShift: isOpenShift}})
+	server.Register(MutatingWebhookPath, &webhook.Admission{Handler: &webServerDefaulter{}})
+	// The conversion webhook gets the scheme of the Manager, in which all the WebServer versions are registered
+	server.Register(ConversionWebhookPath, &conversion.Webhook{})
+	log.Info("Registered the WebServer webhooks", "ValidatingWebhookPath", ValidatingWebhookPath, "MutatingWebhookPath", MutatingWebhookPath, "ConversionWebhookPath", ConversionWebhookPath)
+	return nil
+}
@@ -31,6 +31,7 @@ func TestWebServer54(t *testing.T) {
 	t.Run("ImageStreamScaleTest", webServerImageStreamScaleTest)
 	t.Run("SourcesBasicTest", webServerSourcesBasicTest)
 	t.Run("SourcesScaleTest", webServerSourcesScaleTest)
+	t.Run("SessionStoreTest", webServerSessionStoreTest)
 }
 
 func webServerApplicationImageBasicTest(t *testing.T) {
@@ -61,3 +62,7 @@ func webServerSourcesBasicTest(t *testing.T) {
 func webServerSourcesScaleTest(t *testing.T) {
 	webserversframework.WebServerSourcesScaleTest(t, "jboss-webserver54-openjdk8-tomcat9-ubi8-openshift", "https://github.com/jfclere/demo-webapp", "/demo-1.0/demo")
 }
+
+func webServerSessionStoreTest(t *testing.T) {
+	webserversframework.WebServerSessionStoreTest(t, "jboss-webserver54-openjdk8-tomcat9-ubi8-openshift", "https://github.com/jfclere/demo-webapp", "/demo-1.0/demo")
+}
@@ -152,6 +152,33 @@ func WebServerSourcesScaleTest(t *testing.T, imageStreamName string, gitURL stri
 	testContext.Cleanup()
 }
 
+// WebServerSessionStoreTest tests that the sessions of an Image Stream operator with sources are shared by the pods
+// through a Redis session store, the Redisson Tomcat libraries are added to the image of the Image Stream
+func WebServerSessionStoreTest(t *testing.T, imageStreamName string, gitURL string, testURI string) {
+	testContext, framework := webServerTestSetup(t)
+
+	redisSecret := deployRedis(framework, testContext, t, name, namespace)
+
+	webServer := makeSourcesWebServer(namespace, name, imageStreamName, namespace, gitURL, 2)
+	webServer.Spec.UseSessionClustering = false
+	webServer.Spec.SessionStore = &webserversv1alpha1.SessionStoreSpec{
+		SecretName: redisSecret.Name,
+		Redis:      &webserversv1alpha1.RedisSessionStoreSpec{},
+	}
+	addRedissonLibraries(webServer)
+
+	deployWebServer(framework, testContext, t, webServer)
+
+	// The counter of the session is kept while the requests go to the different pods
+	cookie := webServerRouteTest(framework, t, name, namespace, testURI, true, nil)
+
+	// and while the pods are replaced
+	webServerScale(t, framework, testContext, webServer, testURI, 1)
+	webServerRouteTest(framework, t, name, namespace, testURI, true, cookie)
+
+	testContext.Cleanup()
+}
+
 // webServerBasicTest tests if the deployed pods of the operator are working
 func webServerBasicTest(t *testing.T, framework *test.Framework, testContext *test.Context, webServer *webserversv1alpha1.WebServer, testURI string) {
 
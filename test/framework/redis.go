@@ -0,0 +1,127 @@
+package framework
+
+import (
+	"context"
+	"path"
+	"testing"
+
+	"github.com/operator-framework/operator-sdk/pkg/test"
+	webserversv1alpha1 "github.com/web-servers/jws-operator/pkg/apis/webservers/v1alpha1"
+	kbappsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// redisImage is the image of the Redis server used as session store by the tests
+	redisImage = "docker.io/library/redis:6"
+	// redissonVersion is the version of the Redisson Tomcat libraries added to the JWS image, which doesn't ship them
+	redissonVersion = "3.16.4"
+	// redissonDownloaderImage is the image of the init container downloading the Redisson Tomcat libraries
+	redissonDownloaderImage = "registry.access.redhat.com/ubi8/ubi-minimal:latest"
+	// redissonVolumeName is the name of the volume holding the Redisson Tomcat libraries
+	redissonVolumeName = "redisson-libraries"
+)
+
+// addRedissonLibraries adds the Redisson Tomcat libraries to the lib directory of Tomcat: an init container downloads
+// them from Maven Central in a volume and each library is mounted in the lib directory
+func addRedissonLibraries(webServer *webserversv1alpha1.WebServer) {
+	catalinaBase := webServer.Spec.CatalinaBase
+	if catalinaBase == "" {
+		catalinaBase = webserversv1alpha1.DefaultCatalinaBase
+	}
+	libraries := map[string]string{
+		"redisson-all.jar":      "https://repo1.maven.org/maven2/org/redisson/redisson-all/" + redissonVersion + "/redisson-all-" + redissonVersion + ".jar",
+		"redisson-tomcat-9.jar": "https://repo1.maven.org/maven2/org/redisson/redisson-tomcat-9/" + redissonVersion + "/redisson-tomcat-9-" + redissonVersion + ".jar",
+	}
+	download := "set -e"
+	for _, library := range []string{"redisson-all.jar", "redisson-tomcat-9.jar"} {
+		download += " && curl -fsSL -o /redisson/" + library + " " + libraries[library]
+		webServer.Spec.VolumeMounts = append(webServer.Spec.VolumeMounts, corev1.VolumeMount{
+			Name:      redissonVolumeName,
+			MountPath: path.Join(catalinaBase, "lib", library),
+			SubPath:   library,
+			ReadOnly:  true,
+		})
+	}
+	webServer.Spec.Volumes = append(webServer.Spec.Volumes, corev1.Volume{
+		Name:         redissonVolumeName,
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	})
+	webServer.Spec.InitContainers = append(webServer.Spec.InitContainers, corev1.Container{
+		Name:         "redisson-libraries",
+		Image:        redissonDownloaderImage,
+		Command:      []string{"sh", "-c", download},
+		VolumeMounts: []corev1.VolumeMount{{Name: redissonVolumeName, MountPath: "/redisson"}},
+	})
+}
+
+// deployRedis deploys a Redis server without persistence and returns the Secret containing its connection details
+func deployRedis(framework *test.Framework, testContext *test.Context, t *testing.T, name string, namespace string) *corev1.Secret {
+	redisName := name + "-redis"
+	labels := map[string]string{"app": redisName}
+	replicas := int32(1)
+	cleanupOptions := &test.CleanupOptions{TestContext: testContext, Timeout: cleanupTimeout, RetryInterval: cleanupRetryInterval}
+
+	deployment := &kbappsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: redisName, Namespace: namespace, Labels: labels},
+		Spec: kbappsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:  "redis",
+						Image: redisImage,
+						Args:  []string{"--save", "", "--appendonly", "no"},
+						Ports: []corev1.ContainerPort{{Name: "redis", ContainerPort: 6379}},
+						ReadinessProbe: &corev1.Probe{
+							Handler: corev1.Handler{
+								Exec: &corev1.ExecAction{Command: []string{"redis-cli", "ping"}},
+							},
+						},
+					}},
+				},
+			},
+		},
+	}
+	if err := framework.Client.Create(context.TODO(), deployment, cleanupOptions); err != nil {
+		t.Fatal(err)
+	}
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: redisName, Namespace: namespace, Labels: labels},
+		Spec: corev1.ServiceSpec{
+			Selector: labels,
+			Ports:    []corev1.ServicePort{{Name: "redis", Port: 6379, TargetPort: intstr.FromString("redis")}},
+		},
+	}
+	if err := framework.Client.Create(context.TODO(), service, cleanupOptions); err != nil {
+		t.Fatal(err)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: redisName, Namespace: namespace, Labels: labels},
+		StringData: map[string]string{"url": "redis://" + redisName + ":6379"},
+	}
+	if err := framework.Client.Create(context.TODO(), secret, cleanupOptions); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Logf("Waiting until Redis %s is ready", redisName)
+	err := wait.Poll(retryInterval, timeout, func() (done bool, err error) {
+		found := &kbappsv1.Deployment{}
+		if err := framework.Client.Get(context.TODO(), types.NamespacedName{Name: redisName, Namespace: namespace}, found); err != nil {
+			return false, nil
+		}
+		return found.Status.ReadyReplicas == replicas, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return secret
+}